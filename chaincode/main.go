@@ -3,11 +3,20 @@ package main
 import (
 	"chaincode/trading"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
 
 func main() {
+	if mspID := os.Getenv("TRADING_PLATFORM_MSPID"); mspID != "" {
+		trading.PlatformMSPID = mspID
+	}
+	if mspIDs := os.Getenv("TRADING_MEMBER_MSPIDS"); mspIDs != "" {
+		trading.MemberMSPIDs = strings.Split(mspIDs, ",")
+	}
+
 	chaincode, err := contractapi.NewChaincode(&trading.TradingContract{})
 	if err != nil {
 		log.Panicf("Error creating trading chaincode: %v", err)
@@ -0,0 +1,220 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Role is the trading role carried by the caller's certificate.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleMerchant Role = "merchant"
+	RoleUser     Role = "user"
+)
+
+// X.509 attributes issued by the Fabric CA on registration, e.g.
+// --id.attrs "trading.role=merchant:ecert,trading.id=MERCHANT1:ecert".
+const (
+	roleAttribute     = "trading.role"
+	entityIDAttribute = "trading.id"
+)
+
+// PlatformMSPID is the organization that runs the platform. Only its
+// identities can be admins. MemberMSPIDs are the organizations whose CAs may
+// issue merchant and user roles. main overrides both from the environment.
+var (
+	PlatformMSPID = "Org1MSP"
+	MemberMSPIDs  = []string{"Org1MSP", "Org2MSP", "Org3MSP"}
+)
+
+// Role matrix enforced by the contract:
+//
+//	transaction                       admin   merchant        user
+//	InitLedger, Deposit, SetVATRate   yes     -               -
+//	SetPayoutPolicy, GetPayoutsByDay  yes     -               -
+//	SetEscrowPolicy                   yes     -               -
+//	AddMerchantType, AddCategory      yes     -               -
+//	TransferOwnership, migrations     yes     -               -
+//	CreateMerchant                    yes     own ID          -
+//	AddProducts, promotion changes    yes     owned record    -
+//	SetLoyaltyProgram                 yes     owned record    -
+//	  (platform-wide program)         yes     -               -
+//	inventory transactions            -       owned product   -
+//	CreateUser                        yes     -               own ID
+//	Purchase, PurchaseCart            -       -               owned record
+//	Withdraw                          owner   owned record    owned record
+//	Transfer                          -       -               owned sender
+//	GetTransfer                       yes     -               either party
+//	GetTransfersByUser                yes     -               own ID
+//	GetLoyaltyAccount, statement      yes     -               own ID
+//	GetPayouts                        yes     own ID          own ID
+//	ShipOrder                         -       owned record    -
+//	ConfirmDelivery                   -       -               owned record
+//	ReleaseOrder (after timeout)      yes     owned record    -
+//	CancelOrder (before shipping)     yes     owned record    owned record
+//	GetOrder, GetOrders               yes     own ID          own ID
+//	RequestReturn                     -       -               owned record
+//	ApproveReturn, RejectReturn       -       owned record    -
+//	GetReturn                         yes     own ID          own ID
+//	ReviewPurchase                    -       -               owned record
+//	GetUserByID, user invoices        yes     -               own ID
+//	GetUserHistory, GetUserPII        yes     -               own ID
+//	GetMerchantHistory                yes     own ID          -
+//	GetProductHistory                 yes     owned product   -
+//	GetUsersWithMinBalance            yes     -               -
+//	merchant invoices, expired stock  yes     own ID          -
+//	GetVATSummary                     yes     own ID          -
+//	sales statistics                  yes     own ID          -
+//	GetPromotionsByMerchant           yes     own ID          -
+//	stock queries                     yes     yes             -
+//	catalog, taxonomy, merchants      yes     yes             yes
+//	GetProductReviewsPaginated        yes     yes             yes
+//	GetLoyaltyProgram                 yes     yes             yes
+
+// Caller describes the identity that submitted the transaction.
+type Caller struct {
+	MSPID    string
+	ID       string
+	Role     Role
+	EntityID string
+}
+
+// IsAdmin reports whether the caller is a platform admin.
+func (c *Caller) IsAdmin() bool {
+	return c.Role == RoleAdmin
+}
+
+// Owner returns the caller's identity as recorded on owned ledger records.
+func (c *Caller) Owner() models.Owner {
+	return models.Owner{MSPID: c.MSPID, ID: c.ID}
+}
+
+// actsAs reports whether the caller holds role and is bound to entityID.
+func (c *Caller) actsAs(role Role, entityID string) bool {
+	return c.Role == role && c.EntityID != "" && c.EntityID == entityID
+}
+
+// getCaller reads the MSP ID and trading attributes of the submitting
+// identity. Certificates of PlatformMSPID without a trading.role attribute
+// that carry the "admin" OU (e.g. Admin@org1.example.com) are treated as
+// platform admins. Roles issued by an MSP not allowed to grant them are
+// ignored, leaving the caller without a role.
+func getCaller(ctx contractapi.TransactionContextInterface) (*Caller, error) {
+	id, err := cid.New(ctx.GetStub())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client identity: %v", err)
+	}
+
+	mspID, err := id.GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client MSP ID: %v", err)
+	}
+	clientID, err := id.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client ID: %v", err)
+	}
+
+	caller := &Caller{MSPID: mspID, ID: clientID}
+
+	role, found, err := id.GetAttributeValue(roleAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s attribute: %v", roleAttribute, err)
+	}
+	if found {
+		caller.Role = Role(role)
+	} else if isAdmin, _ := id.HasOUValue("admin"); isAdmin {
+		caller.Role = RoleAdmin
+	}
+	if !mayGrant(mspID, caller.Role) {
+		caller.Role = ""
+	}
+
+	entityID, _, err := id.GetAttributeValue(entityIDAttribute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s attribute: %v", entityIDAttribute, err)
+	}
+	caller.EntityID = entityID
+
+	return caller, nil
+}
+
+// mayGrant reports whether identities of mspID may hold role.
+func mayGrant(mspID string, role Role) bool {
+	switch role {
+	case RoleAdmin:
+		return mspID == PlatformMSPID
+	case RoleMerchant, RoleUser:
+		for _, member := range MemberMSPIDs {
+			if mspID == member {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requireRole fails with ErrAccessDenied unless the caller holds one of roles.
+func requireRole(ctx contractapi.TransactionContextInterface, roles ...Role) (*Caller, error) {
+	caller, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range roles {
+		if caller.Role == r {
+			return caller, nil
+		}
+	}
+
+	return nil, accessDenied(caller, "requires role %v", roles)
+}
+
+// requireSelf fails with ErrAccessDenied unless the caller holds role and is
+// bound to entityID. Callers holding one of the bypass roles always pass.
+func requireSelf(ctx contractapi.TransactionContextInterface, role Role, entityID string, bypass ...Role) (*Caller, error) {
+	caller, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range bypass {
+		if caller.Role == r {
+			return caller, nil
+		}
+	}
+
+	if !caller.actsAs(role, entityID) {
+		return nil, accessDenied(caller, "only %s %s may perform this action", role, entityID)
+	}
+
+	return caller, nil
+}
+
+// requireOwner fails with ErrAccessDenied unless the caller is the recorded
+// owner. Callers holding one of the bypass roles always pass.
+func requireOwner(caller *Caller, owner models.Owner, bypass ...Role) error {
+	for _, r := range bypass {
+		if caller.Role == r {
+			return nil
+		}
+	}
+
+	return services.CheckOwner(owner, caller.MSPID, caller.ID)
+}
+
+func accessDenied(caller *Caller, format string, args ...interface{}) error {
+	role := caller.Role
+	if role == "" {
+		role = "none"
+	}
+
+	return fmt.Errorf("%w: %s (caller %s, role %s)",
+		services.ErrAccessDenied, fmt.Sprintf(format, args...), caller.MSPID, role)
+}
@@ -0,0 +1,96 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestGetCaller(t *testing.T) {
+	attrs := func(role, id string) map[string]string {
+		return map[string]string{roleAttribute: role, entityIDAttribute: id}
+	}
+
+	tests := []struct {
+		name       string
+		mspID, ou  string
+		attrs      map[string]string
+		wantRole   Role
+		wantEntity string
+	}{
+		{name: "platform admin OU", mspID: "Org1MSP", ou: "admin", wantRole: RoleAdmin},
+		{name: "platform admin attribute", mspID: "Org1MSP", ou: "client", attrs: attrs("admin", ""), wantRole: RoleAdmin},
+		{name: "admin OU of a member org", mspID: "Org3MSP", ou: "admin"},
+		{name: "admin attribute of a member org", mspID: "Org3MSP", ou: "client", attrs: attrs("admin", "")},
+		{name: "merchant of a member org", mspID: "Org2MSP", ou: "client", attrs: attrs("merchant", "MERCHANT1"), wantRole: RoleMerchant, wantEntity: "MERCHANT1"},
+		{name: "user of a member org", mspID: "Org3MSP", ou: "client", attrs: attrs("user", "USER1"), wantRole: RoleUser, wantEntity: "USER1"},
+		{name: "user of an unknown org", mspID: "Org9MSP", ou: "client", attrs: attrs("user", "USER1")},
+		{name: "unknown role", mspID: "Org1MSP", ou: "client", attrs: attrs("auditor", "")},
+		{name: "client without attributes", mspID: "Org1MSP", ou: "client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newFakeStub()
+			stub.creator = identity(t, tt.mspID, "caller", tt.ou, tt.attrs)
+			ctx := &contractapi.TransactionContext{}
+			ctx.SetStub(stub)
+
+			caller, err := getCaller(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if caller.MSPID != tt.mspID || caller.Role != tt.wantRole {
+				t.Errorf("caller = %s/%q, want %s/%q", caller.MSPID, caller.Role, tt.mspID, tt.wantRole)
+			}
+			if tt.wantRole != "" && caller.EntityID != tt.wantEntity {
+				t.Errorf("entity = %q, want %q", caller.EntityID, tt.wantEntity)
+			}
+		})
+	}
+}
+
+func TestRoleMatrix(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	stranger := identity(t, "Org9MSP", "stranger", "client", map[string]string{roleAttribute: "user", entityIDAttribute: "USER1"})
+	bread := []models.ProductInput{{ID: "PROD9", Name: "Kifla", Price: "10.00", Quantity: 5}}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		call    func() error
+		allowed bool
+	}{
+		{"admin deposits", l.admin, func() error { return c.Deposit(l.ctx, "user", "USER1", "10.00") }, true},
+		{"merchant deposits", l.merchant1, func() error { return c.Deposit(l.ctx, "merchant", "MERCHANT1", "10.00") }, false},
+		{"user deposits", l.user1, func() error { return c.Deposit(l.ctx, "user", "USER1", "10.00") }, false},
+		{"user reads itself", l.user1, func() error { _, err := c.GetUserByID(l.ctx, "USER1"); return err }, true},
+		{"user reads another user", l.user1, func() error { _, err := c.GetUserByID(l.ctx, "USER2"); return err }, false},
+		{"merchant reads a user", l.merchant1, func() error { _, err := c.GetUserByID(l.ctx, "USER1"); return err }, false},
+		{"admin reads a user", l.admin, func() error { _, err := c.GetUserByID(l.ctx, "USER1"); return err }, true},
+		{"merchant adds to own catalog", l.merchant1, func() error { return c.AddProducts(l.ctx, "MERCHANT1", bread) }, true},
+		{"merchant adds to another catalog", l.merchant2, func() error { return c.AddProducts(l.ctx, "MERCHANT1", bread) }, false},
+		{"user buys as itself", l.user1, func() error { _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); return err }, true},
+		{"user buys as another user", l.user1, func() error { _, err := c.Purchase(l.ctx, "USER2", "PROD1", 1, models.PurchaseOptions{}); return err }, false},
+		{"admin buys for a user", l.admin, func() error { _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); return err }, false},
+		{"user lists balances", l.user1, func() error { _, err := c.GetUsersWithMinBalance(l.ctx, "0"); return err }, false},
+		{"caller from an unknown org", stranger, func() error { _, err := c.GetMerchantByID(l.ctx, "MERCHANT1"); return err }, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(tt.creator, "matrix-"+string(rune('a'+i)))
+			err := tt.call()
+			if tt.allowed && err != nil {
+				t.Errorf("got %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, services.ErrAccessDenied) {
+				t.Errorf("got %v, want ErrAccessDenied", err)
+			}
+		})
+	}
+}
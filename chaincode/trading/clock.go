@@ -0,0 +1,25 @@
+package trading
+
+import (
+	"chaincode/trading/services"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// auditable is implemented by every model embedding models.Audit.
+type auditable interface {
+	Touch(now time.Time)
+}
+
+// txClock returns a clock fixed at the transaction proposal timestamp, which
+// is identical on every endorsing peer.
+func txClock(ctx contractapi.TransactionContextInterface) (services.Clock, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+
+	return services.FixedClock(ts.AsTime().UTC()), nil
+}
@@ -2,8 +2,11 @@ package trading
 
 import (
 	"chaincode/trading/models"
+	"chaincode/trading/money"
 	"chaincode/trading/services"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -13,42 +16,126 @@ type TradingContract struct {
 }
 
 func (t *TradingContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	merchant1, _ := services.CreateMerchant("MERCHANT1", "supermarket", "123456789")
-	merchant2, _ := services.CreateMerchant("MERCHANT2", "auto_parts", "987654321")
+	caller, err := requireRole(ctx, RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	// Seeded records belong to the admin until TransferOwnership hands them
+	// over to the merchant and user identities.
+	owner := caller.Owner()
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+	rsd := func(major int64) money.Money { return money.New(major*money.Scale, money.DefaultCurrency) }
+	// Seeded expirations are relative to the transaction time so they are
+	// always in the future when InitLedger runs.
+	expiresIn := func(days int) string { return clock.Now().AddDate(0, 0, days).UTC().Format(time.RFC3339) }
+
+	if _, err := putMissingMerchantTypes(ctx, models.MerchantTypes); err != nil {
+		return err
+	}
 
-	product1, _ := services.CreateProduct("PROD1", "Mleko", "2026-12-31T23:59:59Z", 50, 10, merchant1.ID, merchant1.Type)
-	product2, _ := services.CreateProduct("PROD2", "Hleb", "2026-11-15T23:59:59Z", 20, 15, merchant1.ID, merchant1.Type)
-	product3, _ := services.CreateProduct("PROD3", "Kocnica", "2026-10-03T23:59:59Z", 150, 5, merchant2.ID, merchant2.Type)
-	product4, _ := services.CreateProduct("PROD4", "Filter ulja", "2026-10-10T23:59:59Z", 80, 8, merchant2.ID, merchant2.Type)
+	merchant1, _ := services.CreateMerchant("MERCHANT1", models.MerchantTypeSupermarket, "123456788", owner, models.MerchantTypes)
+	merchant2, _ := services.CreateMerchant("MERCHANT2", models.MerchantTypeAutoParts, "987654328", owner, models.MerchantTypes)
+
+	product1, _ := services.CreateProduct(clock, "PROD1", "Mleko", expiresIn(75), rsd(50), 10, merchant1.ID, merchant1.Type)
+	product2, _ := services.CreateProduct(clock, "PROD2", "Hleb", expiresIn(30), rsd(20), 15, merchant1.ID, merchant1.Type)
+	product3, _ := services.CreateProduct(clock, "PROD3", "Kocnica", expiresIn(10), rsd(150), 5, merchant2.ID, merchant2.Type)
+	product4, _ := services.CreateProduct(clock, "PROD4", "Filter ulja", expiresIn(14), rsd(80), 8, merchant2.ID, merchant2.Type)
+
+	// A small category tree to file the seeded products under.
+	categories := make(map[string]*models.Category)
+	for _, c := range []struct{ id, name, parentID string }{
+		{"food", "Hrana", ""},
+		{"dairy", "Mlečni proizvodi", "food"},
+		{"bakery", "Pekarski proizvodi", "food"},
+		{"car_parts", "Auto delovi", ""},
+		{"brakes", "Kočnice", "car_parts"},
+		{"filters", "Filteri", "car_parts"},
+	} {
+		category, err := services.NewCategory(c.id, c.name, categories[c.parentID])
+		if err != nil {
+			return err
+		}
+		categories[category.ID] = category
+	}
+	_ = services.AssignCategory(product1, categories["dairy"], "dairy")
+	_ = services.AssignCategory(product2, categories["bakery"], "bakery")
+	_ = services.AssignCategory(product3, categories["brakes"], "brakes")
+	_ = services.AssignCategory(product4, categories["filters"], "filters")
 
 	_ = services.AddProductsToMerchant(merchant1, product1, product2)
 	_ = services.AddProductsToMerchant(merchant2, product3, product4)
 
-	user1, _ := services.CreateUser("USER1", "Marko", "Markovic", "marko@example.com")
-	user2, _ := services.CreateUser("USER2", "Jelena", "Jovanovic", "jelena@example.com")
+	secret, err := transientSaltSecret(ctx)
+	if err != nil {
+		return err
+	}
+	salt1, err := services.DeriveSalt(secret, "USER1")
+	if err != nil {
+		return err
+	}
+	salt2, _ := services.DeriveSalt(secret, "USER2")
+	user1, pii1, _ := services.CreateUser("USER1", models.UserPII{FirstName: "Marko", LastName: "Markovic", Email: "marko@example.com", Salt: salt1}, owner)
+	user2, pii2, _ := services.CreateUser("USER2", models.UserPII{FirstName: "Jelena", LastName: "Jovanovic", Email: "jelena@example.com", Salt: salt2}, owner)
 
-	_ = services.DepositToEntity(user1, 500)
-	_ = services.DepositToEntity(user2, 300)
-	_ = services.DepositToEntity(merchant1, 1000)
-	_ = services.DepositToEntity(merchant2, 1000)
+	_ = services.DepositToEntity(user1, rsd(500))
+	_ = services.DepositToEntity(user2, rsd(300))
+	_ = services.DepositToEntity(merchant1, rsd(1000))
+	_ = services.DepositToEntity(merchant2, rsd(1000))
 
 	entities := []struct {
-		key  string
-		data interface{}
+		docType models.DocType
+		id      string
+		data    auditable
 	}{
-		{"MERCHANT_" + merchant1.ID, merchant1},
-		{"MERCHANT_" + merchant2.ID, merchant2},
-		{"PRODUCT_" + product1.ID, product1},
-		{"PRODUCT_" + product2.ID, product2},
-		{"PRODUCT_" + product3.ID, product3},
-		{"PRODUCT_" + product4.ID, product4},
-		{"USER_" + user1.ID, user1},
-		{"USER_" + user2.ID, user2},
+		{models.DocTypeMerchant, merchant1.ID, merchant1},
+		{models.DocTypeMerchant, merchant2.ID, merchant2},
+		{models.DocTypeProduct, product1.ID, product1},
+		{models.DocTypeProduct, product2.ID, product2},
+		{models.DocTypeProduct, product3.ID, product3},
+		{models.DocTypeProduct, product4.ID, product4},
+		{models.DocTypeCategory, "food", categories["food"]},
+		{models.DocTypeCategory, "dairy", categories["dairy"]},
+		{models.DocTypeCategory, "bakery", categories["bakery"]},
+		{models.DocTypeCategory, "car_parts", categories["car_parts"]},
+		{models.DocTypeCategory, "brakes", categories["brakes"]},
+		{models.DocTypeCategory, "filters", categories["filters"]},
+		{models.DocTypeUser, user1.ID, user1},
+		{models.DocTypeUser, user2.ID, user2},
 	}
 
 	for _, e := range entities {
-		bytes, _ := json.Marshal(e.data)
-		if err := ctx.GetStub().PutState(e.key, bytes); err != nil {
+		if err := requireAbsent(ctx, e.docType, e.id); err != nil {
+			return fmt.Errorf("ledger already initialized: %w", err)
+		}
+		if err := putEntity(ctx, e.data, e.docType, e.id); err != nil {
+			return err
+		}
+	}
+
+	for _, pii := range []*models.UserPII{pii1, pii2} {
+		if err := putPrivateEntity(ctx, models.UserPIICollection, pii, models.DocTypeUserPII, pii.UserID); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range []*models.Product{product1, product2, product3, product4} {
+		if err := putIndex(ctx, indexMerchantProduct, p.MerchantID, p.ID); err != nil {
+			return err
+		}
+	}
+
+	// Food and medicines carry the reduced 10% PDV rate; everything else
+	// falls back to models.DefaultVATPercent.
+	for _, merchantType := range []string{models.MerchantTypeSupermarket, models.MerchantTypePharmacy} {
+		rate, err := services.NewVATRate(models.VATScopeMerchantType, merchantType, 10, models.MerchantTypes)
+		if err != nil {
+			return err
+		}
+		if err := putEntity(ctx, rate, models.DocTypeVATRate, string(rate.Scope), rate.Target); err != nil {
 			return err
 		}
 	}
@@ -57,106 +144,122 @@ func (t *TradingContract) InitLedger(ctx contractapi.TransactionContextInterface
 }
 
 func (t *TradingContract) CreateMerchant(ctx contractapi.TransactionContextInterface, id, merchantType, pib string) error {
-	merchant, err := services.CreateMerchant(id, merchantType, pib)
+	caller, err := requireSelf(ctx, RoleMerchant, id, RoleAdmin)
 	if err != nil {
 		return err
 	}
 
-	key := "MERCHANT_" + merchant.ID
-	bytes, _ := json.Marshal(merchant)
-	return ctx.GetStub().PutState(key, bytes)
-}
-
-func (t *TradingContract) AddProducts(ctx contractapi.TransactionContextInterface, merchantID string, productsData []models.Product) error {
-	merchantBytes, err := ctx.GetStub().GetState("MERCHANT_" + merchantID)
-	if err != nil || merchantBytes == nil {
-		return services.ErrNotFound
+	merchantTypes, err := merchantTypeIDs(ctx)
+	if err != nil {
+		return err
 	}
 
-	var merchant models.Merchant
-	_ = json.Unmarshal(merchantBytes, &merchant)
-
-	var products []*models.Product
-	for _, pd := range productsData {
-		p, err := services.CreateProduct(pd.ID, pd.Name, pd.Expiration, pd.Price, pd.Quantity, merchantID, merchant.Type)
-		if err != nil {
-			return err
-		}
-
-		products = append(products, p)
+	merchant, err := services.CreateMerchant(id, merchantType, pib, caller.Owner(), merchantTypes)
+	if err != nil {
+		return err
+	}
 
-		key := "PRODUCT_" + p.ID
-		bytes, _ := json.Marshal(p)
-		if err := ctx.GetStub().PutState(key, bytes); err != nil {
-			return err
-		}
+	if err := requireAbsent(ctx, models.DocTypeMerchant, merchant.ID); err != nil {
+		return err
 	}
 
-	if err := services.AddProductsToMerchant(&merchant, products...); err != nil {
+	if err := putEntity(ctx, merchant, models.DocTypeMerchant, merchant.ID); err != nil {
 		return err
 	}
 
-	updatedMerchantBytes, _ := json.Marshal(merchant)
-	return ctx.GetStub().PutState("MERCHANT_"+merchant.ID, updatedMerchantBytes)
+	return emitEvent(ctx, models.EventMerchantCreated, models.MerchantCreatedEvent{
+		MerchantID:   merchant.ID,
+		MerchantType: merchant.Type,
+	})
 }
 
-func (t *TradingContract) CreateUser(ctx contractapi.TransactionContextInterface, id, firstName, lastName, email string) error {
-	user, err := services.CreateUser(id, firstName, lastName, email)
+func (t *TradingContract) AddProducts(ctx contractapi.TransactionContextInterface, merchantID string, productsData []models.ProductInput) error {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
 	if err != nil {
 		return err
 	}
 
-	key := "USER_" + user.ID
-	bytes, _ := json.Marshal(user)
-	return ctx.GetStub().PutState(key, bytes)
-}
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, merchantID); err != nil {
+		return err
+	}
 
-func (t *TradingContract) Purchase(ctx contractapi.TransactionContextInterface,
-	userID, productID, invoiceID string, quantity int) error {
+	if err := requireOwner(caller, merchant.Owner, RoleAdmin); err != nil {
+		return err
+	}
 
-	userBytes, err := ctx.GetStub().GetState("USER_" + userID)
-	if err != nil || userBytes == nil {
-		return services.ErrNotFound
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
 	}
 
-	var user models.User
-	_ = json.Unmarshal(userBytes, &user)
+	var products []*models.Product
+	seen := make(map[string]bool)
+	for _, pd := range productsData {
+		if seen[pd.ID] {
+			return fmt.Errorf("%w: product %s is listed more than once", services.ErrAlreadyExists, pd.ID)
+		}
+		seen[pd.ID] = true
 
-	productBytes, err := ctx.GetStub().GetState("PRODUCT_" + productID)
-	if err != nil || productBytes == nil {
-		return services.ErrNotFound
-	}
+		if err := requireNewProduct(ctx, pd.ID); err != nil {
+			return err
+		}
 
-	var product models.Product
-	_ = json.Unmarshal(productBytes, &product)
+		price, err := services.ParseAmount(pd.Price)
+		if err != nil {
+			return err
+		}
 
-	merchantBytes, err := ctx.GetStub().GetState("MERCHANT_" + product.MerchantID)
-	if err != nil || merchantBytes == nil {
-		return services.ErrNotFound
-	}
+		p, err := services.CreateProduct(clock, pd.ID, pd.Name, pd.Expiration, price, pd.Quantity, merchantID, merchant.Type)
+		if err != nil {
+			return err
+		}
+		if pd.Category != "" {
+			category, err := getCategory(ctx, pd.Category)
+			if err != nil {
+				return err
+			}
+			if err := services.AssignCategory(p, category, pd.Category); err != nil {
+				return err
+			}
+		}
 
-	var merchant models.Merchant
-	_ = json.Unmarshal(merchantBytes, &merchant)
+		products = append(products, p)
 
-	invoice, err := services.Purchase(&user, &product, &merchant, quantity, invoiceID)
-	if err != nil {
-		return err
+		if err := putEntity(ctx, p, models.DocTypeProduct, p.ID); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexMerchantProduct, merchantID, p.ID); err != nil {
+			return err
+		}
 	}
 
-	if err := ctx.GetStub().PutState("USER_"+user.ID, mustMarshal(user)); err != nil {
+	if err := services.AddProductsToMerchant(&merchant, products...); err != nil {
 		return err
 	}
-	if err := ctx.GetStub().PutState("PRODUCT_"+product.ID, mustMarshal(product)); err != nil {
+
+	if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
 		return err
 	}
-	if err := ctx.GetStub().PutState("MERCHANT_"+merchant.ID, mustMarshal(merchant)); err != nil {
-		return err
+
+	event := models.ProductsAddedEvent{MerchantID: merchant.ID, ProductIDs: make([]string, 0, len(products))}
+	for _, p := range products {
+		event.ProductIDs = append(event.ProductIDs, p.ID)
 	}
-	if err := ctx.GetStub().PutState("INVOICE_"+invoice.ID, mustMarshal(invoice)); err != nil {
-		return err
+	return emitEvent(ctx, models.EventProductsAdded, event)
+}
+
+// Purchase buys quantity units of one product and returns the issued invoice.
+// The invoice ID is derived from the transaction ID.
+func (t *TradingContract) Purchase(ctx contractapi.TransactionContextInterface,
+	userID, productID string, quantity int, options models.PurchaseOptions) (*models.Invoice, error) {
+
+	invoices, err := t.purchase(ctx, userID, []models.CartLine{{ProductID: productID, Quantity: quantity}}, options)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return invoices[0], nil
 }
 
 func mustMarshal(v interface{}) []byte {
@@ -165,36 +268,55 @@ func mustMarshal(v interface{}) []byte {
 }
 
 func (t *TradingContract) Deposit(ctx contractapi.TransactionContextInterface,
-	entityType, id string, amount float64) error {
+	entityType, id, amountStr string) error {
+
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return err
+	}
+
+	amount, err := services.ParseAmount(amountStr)
+	if err != nil {
+		return err
+	}
 
 	switch entityType {
 	case "user":
-		userBytes, err := ctx.GetStub().GetState("USER_" + id)
-		if err != nil || userBytes == nil {
-			return services.ErrNotFound
-		}
-
 		var user models.User
-		_ = json.Unmarshal(userBytes, &user)
+		if err := getEntity(ctx, &user, models.DocTypeUser, id); err != nil {
+			return err
+		}
 		if err := services.DepositToEntity(&user, amount); err != nil {
 			return err
 		}
+		if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+			return err
+		}
 
-		return ctx.GetStub().PutState("USER_"+user.ID, mustMarshal(user))
+		return emitEvent(ctx, models.EventFundsDeposited, models.FundsDepositedEvent{
+			EntityType: entityType,
+			EntityID:   user.ID,
+			Amount:     amount,
+			Balance:    user.Balance,
+		})
 
 	case "merchant":
-		merchantBytes, err := ctx.GetStub().GetState("MERCHANT_" + id)
-		if err != nil || merchantBytes == nil {
-			return services.ErrNotFound
-		}
-
 		var merchant models.Merchant
-		_ = json.Unmarshal(merchantBytes, &merchant)
+		if err := getEntity(ctx, &merchant, models.DocTypeMerchant, id); err != nil {
+			return err
+		}
 		if err := services.DepositToEntity(&merchant, amount); err != nil {
 			return err
 		}
+		if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+			return err
+		}
 
-		return ctx.GetStub().PutState("MERCHANT_"+merchant.ID, mustMarshal(merchant))
+		return emitEvent(ctx, models.EventFundsDeposited, models.FundsDepositedEvent{
+			EntityType: entityType,
+			EntityID:   merchant.ID,
+			Amount:     amount,
+			Balance:    merchant.Balance,
+		})
 
 	default:
 		return services.ErrInvalidInput
@@ -202,12 +324,78 @@ func (t *TradingContract) Deposit(ctx contractapi.TransactionContextInterface,
 }
 
 func (t *TradingContract) GetUserByID(ctx contractapi.TransactionContextInterface, userID string) (*models.User, error) {
-	userBytes, err := ctx.GetStub().GetState("USER_" + userID)
-	if err != nil || userBytes == nil {
-		return nil, services.ErrNotFound
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
 	}
 
 	var user models.User
-	_ = json.Unmarshal(userBytes, &user)
+	if err := getEntity(ctx, &user, models.DocTypeUser, userID); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
+
+// TransferOwnership rebinds a user or merchant record to a new identity,
+// e.g. after the owner's certificate has been re-issued. Admin only.
+func (t *TradingContract) TransferOwnership(ctx contractapi.TransactionContextInterface,
+	entityType, id, newMSPID, newOwnerID string) error {
+
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return err
+	}
+
+	newOwner, err := services.NewOwner(newMSPID, newOwnerID)
+	if err != nil {
+		return err
+	}
+
+	var previousOwner models.Owner
+	switch entityType {
+	case "user":
+		var user models.User
+		if err := getEntity(ctx, &user, models.DocTypeUser, id); err != nil {
+			return err
+		}
+		previousOwner = user.Owner
+		if err := services.TransferOwnership(&user, newOwner); err != nil {
+			return err
+		}
+		if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+			return err
+		}
+
+	case "merchant":
+		var merchant models.Merchant
+		if err := getEntity(ctx, &merchant, models.DocTypeMerchant, id); err != nil {
+			return err
+		}
+		previousOwner = merchant.Owner
+		if err := services.TransferOwnership(&merchant, newOwner); err != nil {
+			return err
+		}
+		if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+			return err
+		}
+
+	default:
+		return services.ErrInvalidInput
+	}
+
+	return emitEvent(ctx, models.EventOwnershipTransferred, models.OwnershipTransferredEvent{
+		EntityType:    entityType,
+		EntityID:      id,
+		PreviousOwner: previousOwner,
+		NewOwner:      newOwner,
+	})
+}
+
+// WhoAmI returns the caller's identity in the form TransferOwnership expects.
+func (t *TradingContract) WhoAmI(ctx contractapi.TransactionContextInterface) (*models.Owner, error) {
+	caller, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := caller.Owner()
+	return &owner, nil
+}
@@ -0,0 +1,138 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// GetUserHistory returns every committed version of a user, oldest first.
+func (t *TradingContract) GetUserHistory(ctx contractapi.TransactionContextInterface, userID string) ([]*models.UserHistoryEntry, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	entries := []*models.UserHistoryEntry{}
+	err := readHistory(ctx, models.DocTypeUser, userID, func(entry models.HistoryEntry, value []byte) error {
+		e := &models.UserHistoryEntry{HistoryEntry: entry}
+		if !entry.IsDelete {
+			e.Value = &models.User{}
+			if err := json.Unmarshal(value, e.Value); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, e)
+		return nil
+	})
+
+	return entries, err
+}
+
+// GetProductHistory returns every committed version of a product, oldest
+// first. Available to admins and the merchant selling the product, also
+// after the product has been deleted.
+func (t *TradingContract) GetProductHistory(ctx contractapi.TransactionContextInterface, productID string) ([]*models.ProductHistoryEntry, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merchant is taken from the latest version, as a deleted product
+	// has no current state to read it from.
+	merchantID := ""
+	entries := []*models.ProductHistoryEntry{}
+	err = readHistory(ctx, models.DocTypeProduct, productID, func(entry models.HistoryEntry, value []byte) error {
+		e := &models.ProductHistoryEntry{HistoryEntry: entry}
+		if !entry.IsDelete {
+			e.Value = &models.Product{}
+			if err := json.Unmarshal(value, e.Value); err != nil {
+				return err
+			}
+			merchantID = e.Value.MerchantID
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if merchantID == "" {
+		return nil, services.ErrNotFound
+	}
+
+	if !caller.IsAdmin() && !caller.actsAs(RoleMerchant, merchantID) {
+		return nil, accessDenied(caller, "product %s belongs to another merchant", productID)
+	}
+
+	return entries, nil
+}
+
+// GetMerchantHistory returns every committed version of a merchant, oldest
+// first.
+func (t *TradingContract) GetMerchantHistory(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.MerchantHistoryEntry, error) {
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	entries := []*models.MerchantHistoryEntry{}
+	err := readHistory(ctx, models.DocTypeMerchant, merchantID, func(entry models.HistoryEntry, value []byte) error {
+		e := &models.MerchantHistoryEntry{HistoryEntry: entry}
+		if !entry.IsDelete {
+			e.Value = &models.Merchant{}
+			if err := json.Unmarshal(value, e.Value); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, e)
+		return nil
+	})
+
+	return entries, err
+}
+
+// readHistory calls visit for every version of the record, oldest first.
+// Fabric returns history newest first, so the versions are buffered and
+// replayed in reverse.
+func readHistory(ctx contractapi.TransactionContextInterface, docType models.DocType, id string,
+	visit func(entry models.HistoryEntry, value []byte) error) error {
+
+	key, err := ledgerKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	type version struct {
+		entry models.HistoryEntry
+		value []byte
+	}
+	var versions []version
+	for resultsIterator.HasNext() {
+		mod, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		entry := models.HistoryEntry{TxID: mod.TxId, IsDelete: mod.IsDelete}
+		if mod.Timestamp != nil {
+			entry.Timestamp = mod.Timestamp.AsTime().UTC().Format(time.RFC3339)
+		}
+		versions = append(versions, version{entry: entry, value: mod.Value})
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if err := visit(versions[i].entry, versions[i].value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
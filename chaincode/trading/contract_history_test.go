@@ -0,0 +1,68 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProductHistory(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	bought := l.stub.ts.Add(time.Hour)
+
+	l.stub.ts = bought
+	l.as(l.user1, "buy")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 2, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.merchant1, "history")
+	entries, err := c.GetProductHistory(l.ctx, "PROD1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d versions, want 2", len(entries))
+	}
+	if entries[0].Value.Quantity != 10 || entries[1].Value.Quantity != 8 {
+		t.Errorf("quantities = %d, %d; want 10 then 8", entries[0].Value.Quantity, entries[1].Value.Quantity)
+	}
+	if last := entries[1]; last.TxID != "buy" || last.Timestamp != bought.Format(time.RFC3339) || last.IsDelete {
+		t.Errorf("last version = %+v, want the purchase", last.HistoryEntry)
+	}
+
+	l.as(l.merchant2, "history-other")
+	if _, err := c.GetProductHistory(l.ctx, "PROD1"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another merchant: got %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestUserHistory(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.admin, "deposit")
+	if err := c.Deposit(l.ctx, "user", "USER1", "25.00"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.user1, "history")
+	entries, err := c.GetUserHistory(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(entries); n < 2 || entries[n-1].TxID != "deposit" {
+		t.Fatalf("history = %d versions, want the deposit last", n)
+	}
+	first, last := entries[0].Value.Balance, entries[len(entries)-1].Value.Balance
+	if diff, err := last.Sub(first); err != nil || diff.Amount != 2500 {
+		t.Errorf("balance went from %v to %v, want +25.00", first, last)
+	}
+
+	if _, err := c.GetUserHistory(l.ctx, "USER2"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another user: got %v, want ErrAccessDenied", err)
+	}
+}
@@ -0,0 +1,189 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// The inventory transactions below may only be called by the merchant that
+// owns the product. Each one records the change on the product (see
+// models.ProductChange) and emits ProductChanged.
+
+// RestockProduct adds quantity units to a product's stock.
+func (t *TradingContract) RestockProduct(ctx contractapi.TransactionContextInterface, productID string, quantity int) (*models.Product, error) {
+	caller, _, product, err := getOwnedProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := services.RestockProduct(product, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, putProductChange(ctx, caller, product, models.ProductRestocked, fields)
+}
+
+// UpdateProductPrice sets a product's catalog price, e.g. "129.90".
+func (t *TradingContract) UpdateProductPrice(ctx contractapi.TransactionContextInterface, productID, priceStr string) (*models.Product, error) {
+	caller, _, product, err := getOwnedProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := services.ParseAmount(priceStr)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := services.UpdateProductPrice(product, price)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, putProductChange(ctx, caller, product, models.ProductRepriced, fields)
+}
+
+// UpdateProductDetails changes a product's name, expiration and category.
+func (t *TradingContract) UpdateProductDetails(ctx contractapi.TransactionContextInterface,
+	productID string, input models.ProductDetailsInput) (*models.Product, error) {
+
+	caller, _, product, err := getOwnedProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	if input.Category != "" {
+		if category, err = getCategory(ctx, input.Category); err != nil {
+			return nil, err
+		}
+	}
+
+	fields, err := services.UpdateProductDetails(clock, product, input, category)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, putProductChange(ctx, caller, product, models.ProductDetailsUpdated, fields)
+}
+
+// DelistProduct stops selling a product. It stays on the ledger so its
+// invoices and returns keep working.
+func (t *TradingContract) DelistProduct(ctx contractapi.TransactionContextInterface, productID string) (*models.Product, error) {
+	caller, merchant, product, err := getOwnedProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := services.DelistProduct(merchant, product)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return nil, err
+	}
+
+	return product, putProductChange(ctx, caller, product, models.ProductDelisted, fields)
+}
+
+// DeleteProduct removes a product from the ledger. Its earlier versions stay
+// in GetProductHistory; returns of it are refunded without restocking. A
+// tombstone keeps its ID from being used for another product.
+func (t *TradingContract) DeleteProduct(ctx contractapi.TransactionContextInterface, productID string) error {
+	caller, merchant, product, err := getOwnedProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	if err := services.DeleteProduct(merchant, product); err != nil {
+		return err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := putEntity(ctx, merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return err
+	}
+	if err := delEntity(ctx, models.DocTypeProduct, product.ID); err != nil {
+		return err
+	}
+	if err := delIndex(ctx, indexMerchantProduct, merchant.ID, product.ID); err != nil {
+		return err
+	}
+	tombstone := &models.DeletedProduct{
+		DocType:    models.DocTypeDeletedProduct,
+		ID:         product.ID,
+		MerchantID: merchant.ID,
+		DeletedBy:  caller.Owner(),
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+	if err := putEntity(ctx, tombstone, models.DocTypeDeletedProduct, product.ID); err != nil {
+		return err
+	}
+
+	change := services.NewProductChange(clock, models.ProductDeleted, nil, caller.Owner(), ctx.GetStub().GetTxID())
+	return emitEvent(ctx, models.EventProductChanged, models.ProductChangedEvent{
+		ProductID:  product.ID,
+		MerchantID: merchant.ID,
+		Change:     *change,
+	})
+}
+
+// getOwnedProduct reads a product and its merchant, and checks that the
+// caller is that merchant.
+func getOwnedProduct(ctx contractapi.TransactionContextInterface, productID string) (*Caller, *models.Merchant, *models.Product, error) {
+	caller, err := requireRole(ctx, RoleMerchant)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var product models.Product
+	if err := getEntity(ctx, &product, models.DocTypeProduct, productID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, product.MerchantID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := requireOwner(caller, merchant.Owner); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return caller, &merchant, &product, nil
+}
+
+// putProductChange records the change on the product, stores it and emits
+// ProductChanged.
+func putProductChange(ctx contractapi.TransactionContextInterface, caller *Caller,
+	product *models.Product, action models.ProductAction, fields []models.FieldChange) error {
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	product.LastChange = services.NewProductChange(clock, action, fields, caller.Owner(), ctx.GetStub().GetTxID())
+	if err := putEntity(ctx, product, models.DocTypeProduct, product.ID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventProductChanged, models.ProductChangedEvent{
+		ProductID:  product.ID,
+		MerchantID: product.MerchantID,
+		Change:     *product.LastChange,
+	})
+}
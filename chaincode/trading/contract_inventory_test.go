@@ -0,0 +1,132 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDelistProduct(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.merchant2, "delist-other")
+	if _, err := c.DelistProduct(l.ctx, "PROD2"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another merchant: got %v, want ErrAccessDenied", err)
+	}
+
+	l.as(l.merchant1, "delist")
+	product, err := c.DelistProduct(l.ctx, "PROD2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !product.Delisted || product.LastChange == nil || product.LastChange.Action != models.ProductDelisted {
+		t.Errorf("product = %+v, want it delisted with the change recorded", product)
+	}
+	var event struct {
+		Payload models.ProductChangedEvent `json:"payload"`
+	}
+	onlyEvent(t, l.stub, models.EventProductChanged, &event)
+	if event.Payload.ProductID != "PROD2" || event.Payload.Change.Action != models.ProductDelisted {
+		t.Errorf("event = %+v", event.Payload)
+	}
+
+	l.as(l.merchant1, "delist-again")
+	if _, err := c.DelistProduct(l.ctx, "PROD2"); !errors.Is(err, services.ErrInvalidState) {
+		t.Errorf("delisting twice: got %v, want ErrInvalidState", err)
+	}
+
+	merchant, err := c.GetMerchantByID(l.ctx, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(merchant.ProductsForSale, "PROD2") {
+		t.Errorf("products for sale = %v, want PROD2 gone", merchant.ProductsForSale)
+	}
+	if _, err := getMerchantProducts(l.ctx, "MERCHANT1"); err != nil {
+		t.Errorf("delisted product breaks the merchant's catalog: %v", err)
+	}
+
+	l.as(l.user1, "buy-delisted")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{}); !errors.Is(err, services.ErrProductDelisted) {
+		t.Errorf("buying a delisted product: got %v, want ErrProductDelisted", err)
+	}
+}
+
+func TestDeleteProduct(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 2, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "request-return")
+	if _, err := c.RequestReturn(l.ctx, "RET1", "INV-buy", "PROD1", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.merchant1, "delete")
+	if err := c.DeleteProduct(l.ctx, "PROD1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var product models.Product
+	if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD1"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("reading the deleted product: got %v, want ErrNotFound", err)
+	}
+	ids, err := indexedIDs(l.ctx, indexMerchantProduct, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ids, "PROD1") {
+		t.Errorf("merchant~product still lists PROD1: %v", ids)
+	}
+	var tombstone models.DeletedProduct
+	if err := getEntity(l.ctx, &tombstone, models.DocTypeDeletedProduct, "PROD1"); err != nil || tombstone.MerchantID != "MERCHANT1" {
+		t.Errorf("tombstone = %+v, %v", tombstone, err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		creator []byte
+		call    func() error
+	}{
+		{"same merchant", l.merchant1, func() error {
+			return c.AddProducts(l.ctx, "MERCHANT1", []models.ProductInput{{ID: "PROD1", Name: "Mleko", Price: "55", Quantity: 5}})
+		}},
+		{"another merchant", l.merchant2, func() error {
+			return c.AddProducts(l.ctx, "MERCHANT2", []models.ProductInput{{ID: "PROD1", Name: "Antifriz", Price: "900", Quantity: 4}})
+		}},
+	} {
+		l.as(tt.creator, "reuse-"+tt.name)
+		if err := tt.call(); !errors.Is(err, services.ErrAlreadyExists) || !strings.Contains(err.Error(), "deleted") {
+			t.Errorf("%s reusing the ID: got %v, want ErrAlreadyExists naming the deletion", tt.name, err)
+		}
+	}
+
+	// The return is still refunded, just not restocked.
+	l.as(l.merchant1, "approve-return")
+	note, err := c.ApproveReturn(l.ctx, "RET1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Amount.Amount != 10000 {
+		t.Errorf("refund = %v, want 100.00", note.Amount)
+	}
+	if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD1"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("the return recreated the deleted product: %v", err)
+	}
+
+	l.as(l.merchant1, "history")
+	history, err := c.GetProductHistory(l.ctx, "PROD1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) == 0 || !history[len(history)-1].IsDelete {
+		t.Errorf("history = %+v, want it to end with the deletion", history)
+	}
+}
@@ -0,0 +1,197 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// SetLoyaltyProgram sets the points a merchant's purchases earn, in percent
+// of the amount paid from the balance, how many days the points stay valid
+// and whether they can be redeemed at every merchant or only at this one.
+// The new rules apply to points earned from then on. Points redeemable at
+// every merchant are a liability of the whole platform, so only an admin
+// may set or change a platform-wide program.
+func (t *TradingContract) SetLoyaltyProgram(ctx contractapi.TransactionContextInterface,
+	merchantID string, earnPercent, expiryDays int, platformWide bool) (*models.LoyaltyProgram, error) {
+
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, merchantID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, merchant.Owner, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if !caller.IsAdmin() {
+		var current models.LoyaltyProgram
+		err := getEntity(ctx, &current, models.DocTypeLoyaltyProgram, merchant.ID)
+		if err != nil && err != services.ErrNotFound {
+			return nil, err
+		}
+		if platformWide || current.PlatformWide {
+			return nil, accessDenied(caller, "only an admin may set a platform-wide loyalty program")
+		}
+	}
+
+	program, err := services.NewLoyaltyProgram(merchant.ID, earnPercent, expiryDays, platformWide)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, program, models.DocTypeLoyaltyProgram, program.MerchantID); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, models.EventLoyaltyProgramChanged, models.LoyaltyProgramChangedEvent{
+		MerchantID:   program.MerchantID,
+		EarnPercent:  program.EarnPercent,
+		ExpiryDays:   program.ExpiryDays,
+		PlatformWide: program.PlatformWide,
+	}); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// GetLoyaltyProgram returns a merchant's loyalty program.
+func (t *TradingContract) GetLoyaltyProgram(ctx contractapi.TransactionContextInterface, merchantID string) (*models.LoyaltyProgram, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	var program models.LoyaltyProgram
+	if err := getEntity(ctx, &program, models.DocTypeLoyaltyProgram, merchantID); err != nil {
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+// GetLoyaltyAccount returns a user's points and their lots as of now, to
+// the user itself or an admin. Expired lots are left out even if no
+// transaction has recorded their expiry yet.
+func (t *TradingContract) GetLoyaltyAccount(ctx contractapi.TransactionContextInterface, userID string) (*models.LoyaltyAccount, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	loyalty, err := loadLoyalty(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return loyalty.Account, nil
+}
+
+// GetLoyaltyStatement returns every points entry of a user, oldest first,
+// to the user itself or an admin.
+func (t *TradingContract) GetLoyaltyStatement(ctx contractapi.TransactionContextInterface, userID string) ([]*models.LoyaltyEntry, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	entryIDs, err := indexedIDs(ctx, indexUserLoyalty, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*models.LoyaltyEntry, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		var entry models.LoyaltyEntry
+		if err := getEntity(ctx, &entry, models.DocTypeLoyaltyEntry, id); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	// The index orders entries by transaction ID; the entries of one
+	// transaction keep their numbered order.
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date < entries[j].Date })
+
+	return entries, nil
+}
+
+// loadLoyalty reads a user's loyalty account and the programs of
+// merchantIDs, and expires the lots that are past their date.
+func loadLoyalty(ctx contractapi.TransactionContextInterface, userID string, merchantIDs []string) (*services.Loyalty, error) {
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loyalty := &services.Loyalty{
+		Programs: make(map[string]*models.LoyaltyProgram, len(merchantIDs)),
+		EntryID:  "LOY-" + ctx.GetStub().GetTxID(),
+	}
+
+	var account models.LoyaltyAccount
+	err = getEntity(ctx, &account, models.DocTypeLoyaltyAccount, userID)
+	switch {
+	case err == services.ErrNotFound:
+		loyalty.Account = services.NewLoyaltyAccount(userID)
+	case err != nil:
+		return nil, err
+	default:
+		loyalty.Account = &account
+	}
+
+	for _, merchantID := range merchantIDs {
+		var program models.LoyaltyProgram
+		err := getEntity(ctx, &program, models.DocTypeLoyaltyProgram, merchantID)
+		if err == services.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		loyalty.Programs[merchantID] = &program
+	}
+
+	services.ExpirePoints(clock, loyalty)
+
+	return loyalty, nil
+}
+
+// invoiceLoyalty loads the buyer's loyalty account for a refund of invoice,
+// or returns nil if the invoice neither redeemed nor earned points.
+func invoiceLoyalty(ctx contractapi.TransactionContextInterface, invoice *models.Invoice) (*services.Loyalty, error) {
+	if invoice.PointsRedeemed == 0 && invoice.PointsEarned == 0 {
+		return nil, nil
+	}
+
+	return loadLoyalty(ctx, invoice.UserID, []string{invoice.MerchantID})
+}
+
+// putLoyalty writes the account and the entries of a transaction that
+// changed it.
+func putLoyalty(ctx contractapi.TransactionContextInterface, loyalty *services.Loyalty) error {
+	if loyalty == nil || len(loyalty.Entries) == 0 {
+		return nil
+	}
+
+	if err := putEntity(ctx, loyalty.Account, models.DocTypeLoyaltyAccount, loyalty.Account.UserID); err != nil {
+		return err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	for _, entry := range loyalty.Entries {
+		entry.TxID = txID
+		if err := putEntity(ctx, entry, models.DocTypeLoyaltyEntry, entry.ID); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexUserLoyalty, entry.UserID, entry.Date, entry.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
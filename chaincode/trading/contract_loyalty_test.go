@@ -0,0 +1,106 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlatformWideProgramIsAdminOnly(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.merchant1, "merchant-platform-wide")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 5, 90, true); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("platform-wide program by the merchant: %v, want ErrAccessDenied", err)
+	}
+	l.as(l.merchant1, "merchant-own")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 5, 90, false); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.merchant2, "other-merchant")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 1, 90, false); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("program of another merchant: %v, want ErrAccessDenied", err)
+	}
+
+	l.as(l.admin, "admin-platform-wide")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 5, 90, true); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.merchant1, "merchant-narrows")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 10, 90, false); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("merchant changing a platform-wide program: %v, want ErrAccessDenied", err)
+	}
+
+	program, err := c.GetLoyaltyProgram(l.ctx, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !program.PlatformWide || program.EarnPercent != 5 {
+		t.Errorf("program = %+v, want the admin's platform-wide 5%%", program)
+	}
+}
+
+func TestLoyaltyEarnAndRedeem(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.merchant1, "program")
+	if _, err := c.SetLoyaltyProgram(l.ctx, "MERCHANT1", 10, 90, false); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.user1, "buy-milk")
+	earned, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if earned.PointsEarned != 500 {
+		t.Errorf("points earned = %d, want 500 for 50.00 at 10%%", earned.PointsEarned)
+	}
+
+	l.as(l.user1, "buy-parts-with-points")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD4", 1, models.PurchaseOptions{RedeemPoints: 100}); !errors.Is(err, services.ErrInsufficientPoints) {
+		t.Errorf("redeeming MERCHANT1 points at MERCHANT2: %v, want ErrInsufficientPoints", err)
+	}
+
+	l.stub.ts = l.stub.ts.Add(time.Hour)
+	l.as(l.user1, "buy-bread-with-points")
+	redeemed, err := c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{RedeemPoints: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemed.PointsRedeemed != 500 || redeemed.PointsEarned != 150 {
+		t.Errorf("invoice points = redeemed %d, earned %d; want 500 and 150", redeemed.PointsRedeemed, redeemed.PointsEarned)
+	}
+
+	user, err := c.GetUserByID(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Balance.Amount != 43500 {
+		t.Errorf("balance = %v, want 435.00 after paying 50.00 and 15.00", user.Balance)
+	}
+
+	account, err := c.GetLoyaltyAccount(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Points != 150 {
+		t.Errorf("points = %d, want 150", account.Points)
+	}
+	statement, err := c.GetLoyaltyStatement(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []models.LoyaltyEntryKind
+	for _, entry := range statement {
+		kinds = append(kinds, entry.Kind)
+	}
+	if want := []models.LoyaltyEntryKind{models.LoyaltyEarned, models.LoyaltyRedeemed, models.LoyaltyEarned}; !slices.Equal(kinds, want) {
+		t.Errorf("statement = %v, want %v", kinds, want)
+	}
+}
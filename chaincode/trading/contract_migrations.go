@@ -0,0 +1,407 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// legacyMoneyDocs lists, per docType, the top-level fields that held float64
+// amounts before they were stored as money.Money. Invoices issued with line
+// items also held float64 amounts in legacyItemAmounts.
+var legacyMoneyDocs = []struct {
+	docType models.DocType
+	fields  []string
+}{
+	{models.DocTypeUser, []string{"balance"}},
+	{models.DocTypeMerchant, []string{"balance"}},
+	{models.DocTypeProduct, []string{"price"}},
+	{models.DocTypeInvoice, []string{"totalPrice"}},
+}
+
+var legacyItemAmounts = []string{"unitPrice", "totalPrice"}
+
+// legacyKeyPrefixes maps the "TYPE_" prefixes of the keys used before
+// composite keys to the docType that now names the record.
+var legacyKeyPrefixes = []struct {
+	prefix  string
+	docType models.DocType
+}{
+	{"USER_", models.DocTypeUser},
+	{"MERCHANT_", models.DocTypeMerchant},
+	{"PRODUCT_", models.DocTypeProduct},
+	{"INVOICE_", models.DocTypeInvoice},
+}
+
+// MigrateMoney rewrites documents that still store amounts as float64 major
+// units into integer minor units with a currency code. Already migrated
+// documents are left untouched, so running it again is harmless. It only
+// reads composite keys, so run MigrateKeys first on ledgers that still use
+// "TYPE_ID" keys. Admin only. Returns the number of rewritten documents.
+func (t *TradingContract) MigrateMoney(ctx contractapi.TransactionContextInterface) (int, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, docs := range legacyMoneyDocs {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(docs.docType), []string{})
+		if err != nil {
+			return migrated, err
+		}
+
+		for resultsIterator.HasNext() {
+			kv, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return migrated, err
+			}
+
+			value, changed, err := migrateAmounts(kv.Value, docs.fields)
+			if err != nil {
+				resultsIterator.Close()
+				return migrated, fmt.Errorf("cannot migrate %s: %v", kv.Key, err)
+			}
+			if !changed {
+				continue
+			}
+			if err := ctx.GetStub().PutState(kv.Key, value); err != nil {
+				resultsIterator.Close()
+				return migrated, err
+			}
+			migrated++
+		}
+
+		resultsIterator.Close()
+	}
+
+	return migrationCompleted(ctx, "MigrateMoney", migrated)
+}
+
+// migrateAmounts converts the bare JSON numbers in fields, and in the
+// legacyItemAmounts of any line items, to money.Money. Every other field is
+// kept as stored, including those the current models no longer have, such
+// as the productId and quantity of invoices issued before line items or the
+// personal data of users not yet moved by MigrateUserPII. It reports whether
+// anything was converted.
+func migrateAmounts(value []byte, fields []string) ([]byte, bool, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return nil, false, err
+	}
+
+	changed, err := convertAmounts(doc, fields)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if raw, ok := doc["items"]; ok {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err == nil {
+			itemsChanged := false
+			for _, item := range items {
+				c, err := convertAmounts(item, legacyItemAmounts)
+				if err != nil {
+					return nil, false, err
+				}
+				itemsChanged = itemsChanged || c
+			}
+			if itemsChanged {
+				doc["items"] = mustMarshal(items)
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return value, false, nil
+	}
+
+	return mustMarshal(doc), true, nil
+}
+
+// convertAmounts replaces the fields of doc that hold a bare number with the
+// money.Money it stands for, in major units of the default currency.
+func convertAmounts(doc map[string]json.RawMessage, fields []string) (bool, error) {
+	changed := false
+	for _, field := range fields {
+		raw := doc[field]
+		if len(raw) == 0 || !(raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9')) {
+			continue
+		}
+
+		var amount money.Money
+		if err := json.Unmarshal(raw, &amount); err != nil {
+			return false, fmt.Errorf("%s: %v", field, err)
+		}
+		doc[field] = mustMarshal(amount)
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// MigrateKeys moves records stored under the old "TYPE_ID" keys to composite
+// keys, builds the merchant~product, user~invoice and merchant~invoice
+// indexes for them and deletes the old keys. Records already under composite
+// keys are not touched, so running it again is harmless. Admin only.
+// Returns the number of moved records.
+func (t *TradingContract) MigrateKeys(ctx contractapi.TransactionContextInterface) (int, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, legacy := range legacyKeyPrefixes {
+		// Composite keys start with 0x00, so this range only sees old keys.
+		// MaxRune as the end bound also covers IDs containing "~".
+		resultsIterator, err := ctx.GetStub().GetStateByRange(legacy.prefix, legacy.prefix+string(utf8.MaxRune))
+		if err != nil {
+			return migrated, err
+		}
+
+		for resultsIterator.HasNext() {
+			kv, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return migrated, err
+			}
+
+			if err := migrateKey(ctx, legacy.docType, strings.TrimPrefix(kv.Key, legacy.prefix), kv.Key, kv.Value); err != nil {
+				resultsIterator.Close()
+				return migrated, fmt.Errorf("cannot migrate %s: %v", kv.Key, err)
+			}
+			migrated++
+		}
+
+		resultsIterator.Close()
+	}
+
+	return migrationCompleted(ctx, "MigrateKeys", migrated)
+}
+
+// MigrateTaxonomy writes the merchant types that ledgers created before the
+// taxonomy lack: the built-in ones and every type an existing merchant uses.
+// Types that are not valid taxonomy IDs are skipped, and their merchants are
+// left for an admin to correct. Running it again is harmless. Admin only.
+// Returns the number of merchant types written.
+func (t *TradingContract) MigrateTaxonomy(ctx contractapi.TransactionContextInterface) (int, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	ids := append([]string{}, models.MerchantTypes...)
+	for _, id := range ids {
+		seen[id] = true
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeMerchant), []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	var merchantTypes []string
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		var merchant models.Merchant
+		if err := json.Unmarshal(kv.Value, &merchant); err != nil {
+			return 0, err
+		}
+		if !seen[merchant.Type] && services.ValidTaxonomyID(merchant.Type) {
+			seen[merchant.Type] = true
+			merchantTypes = append(merchantTypes, merchant.Type)
+		}
+	}
+	sort.Strings(merchantTypes)
+
+	written, err := putMissingMerchantTypes(ctx, append(ids, merchantTypes...))
+	if err != nil {
+		return written, err
+	}
+
+	return migrationCompleted(ctx, "MigrateTaxonomy", written)
+}
+
+// MigrateSalesStats rebuilds the sales statistics of every merchant and
+// product from the invoices on the ledger, for ledgers with invoices issued
+// before purchases kept them. Cancelled orders are left out and approved
+// returns subtracted. Statistics are overwritten with the recomputed values,
+// and those no invoice accounts for any more are reset, so running it again
+// is harmless. Admin only. Returns the number of statistics documents
+// written.
+func (t *TradingContract) MigrateSalesStats(ctx contractapi.TransactionContextInterface) (int, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeInvoice), []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	stats := make(map[string]*models.SalesStats)
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		var invoice models.Invoice
+		if err := json.Unmarshal(kv.Value, &invoice); err != nil {
+			return 0, err
+		}
+		if invoice.OrderStatus == models.OrderCancelled {
+			continue
+		}
+		sales, err := services.InvoiceSales(&invoice)
+		if err != nil {
+			return 0, err
+		}
+		for _, noteID := range invoice.CreditNotes {
+			var note models.CreditNote
+			if err := getEntity(ctx, &note, models.DocTypeCreditNote, noteID); err != nil {
+				return 0, err
+			}
+			returned, err := services.ReturnedSales(&invoice, &note)
+			if err != nil {
+				return 0, err
+			}
+			sales = append(sales, returned...)
+		}
+
+		for _, sale := range sales {
+			key, err := ledgerKey(ctx, sale.DocType, salesStatsKey(sale)...)
+			if err != nil {
+				return 0, err
+			}
+			if s, ok := stats[key]; ok {
+				if err := services.AddSales(s, sale); err != nil {
+					return 0, err
+				}
+			} else {
+				stats[key] = sale
+			}
+		}
+	}
+
+	if err := resetStaleSalesStats(ctx, stats); err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := stats[key]
+		if err := putEntity(ctx, s, s.DocType, salesStatsKey(s)...); err != nil {
+			return 0, err
+		}
+	}
+
+	return migrationCompleted(ctx, "MigrateSalesStats", len(keys))
+}
+
+// resetStaleSalesStats adds empty statistics to stats for every statistics
+// document on the ledger that the recomputed stats do not cover, such as
+// those of merchants whose orders were all cancelled.
+func resetStaleSalesStats(ctx contractapi.TransactionContextInterface, stats map[string]*models.SalesStats) error {
+	for _, docType := range []models.DocType{
+		models.DocTypeMerchantStats, models.DocTypeMerchantDaily,
+		models.DocTypeProductStats, models.DocTypeProductDaily,
+	} {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(docType), []string{})
+		if err != nil {
+			return err
+		}
+
+		for resultsIterator.HasNext() {
+			kv, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return err
+			}
+			if _, ok := stats[kv.Key]; ok {
+				continue
+			}
+
+			var s models.SalesStats
+			if err := json.Unmarshal(kv.Value, &s); err != nil {
+				resultsIterator.Close()
+				return err
+			}
+			stats[kv.Key] = services.NewSalesStats(s.DocType, s.MerchantID, s.ProductID, s.Day, s.Revenue.Currency)
+		}
+
+		resultsIterator.Close()
+	}
+
+	return nil
+}
+
+// migrationCompleted emits the MigrationCompleted event of a migration that
+// wrote count records and returns count.
+func migrationCompleted(ctx contractapi.TransactionContextInterface, migration string, count int) (int, error) {
+	if err := emitEvent(ctx, models.EventMigrationCompleted, models.MigrationCompletedEvent{
+		Migration: migration,
+		Count:     count,
+	}); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// migrateKey copies value from oldKey to the composite key of docType/id,
+// adds the secondary index entries of products and invoices, and deletes
+// oldKey.
+func migrateKey(ctx contractapi.TransactionContextInterface, docType models.DocType, id, oldKey string, value []byte) error {
+	key, err := ledgerKey(ctx, docType, id)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, value); err != nil {
+		return err
+	}
+
+	switch docType {
+	case models.DocTypeProduct:
+		var p models.Product
+		if err := json.Unmarshal(value, &p); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexMerchantProduct, p.MerchantID, id); err != nil {
+			return err
+		}
+
+	case models.DocTypeInvoice:
+		var inv models.Invoice
+		if err := json.Unmarshal(value, &inv); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexUserInvoice, inv.UserID, id); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexMerchantInvoice, inv.MerchantID, id); err != nil {
+			return err
+		}
+	}
+
+	return ctx.GetStub().DelState(oldKey)
+}
@@ -0,0 +1,154 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+)
+
+// putRaw stores value under the composite key of docType/id as an older
+// version of the chaincode would have.
+func putRaw(t *testing.T, stub *fakeStub, docType models.DocType, id, value string) string {
+	t.Helper()
+
+	key, err := shim.CreateCompositeKey(string(docType), []string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.state[key] = []byte(value)
+	return key
+}
+
+func migrationEvent(t *testing.T, stub *fakeStub) models.MigrationCompletedEvent {
+	t.Helper()
+
+	var envelope struct {
+		Payload models.MigrationCompletedEvent `json:"payload"`
+	}
+	if err := json.Unmarshal(stub.events[string(models.EventMigrationCompleted)], &envelope); err != nil {
+		t.Fatalf("MigrationCompleted event: %v", err)
+	}
+	return envelope.Payload
+}
+
+func TestMigrateMoney(t *testing.T) {
+	l := newTestLedger(t)
+
+	userKey := putRaw(t, l.stub, models.DocTypeUser, "USER9",
+		`{"docType":"user","id":"USER9","firstName":"Ana","lastName":"Anić","email":"ana@example.rs","balance":120.5}`)
+	invoiceKey := putRaw(t, l.stub, models.DocTypeInvoice, "INV9",
+		`{"docType":"invoice","id":"INV9","merchantId":"MERCHANT1","userId":"USER1","productId":"PROD1","quantity":2,"totalPrice":100,"date":"2026-01-02T10:00:00Z"}`)
+	itemsKey := putRaw(t, l.stub, models.DocTypeInvoice, "INV10",
+		`{"docType":"invoice","id":"INV10","merchantId":"MERCHANT1","userId":"USER1","items":[{"productId":"PROD2","quantity":1,"unitPrice":20,"totalPrice":20}],"totalPrice":20,"date":"2026-01-02T10:00:00Z"}`)
+
+	l.as(l.admin, "migrate-money")
+	migrated, err := l.contract.MigrateMoney(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 3 {
+		t.Errorf("migrated %d documents, want 3", migrated)
+	}
+	if event := migrationEvent(t, l.stub); event.Migration != "MigrateMoney" || event.Count != 3 {
+		t.Errorf("event = %+v, want MigrateMoney with count 3", event)
+	}
+
+	var user map[string]json.RawMessage
+	if err := json.Unmarshal(l.stub.state[userKey], &user); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(user["balance"]); got != `{"amount":12050,"currency":"RSD"}` {
+		t.Errorf("user balance = %s", got)
+	}
+	if got := string(user["email"]); got != `"ana@example.rs"` {
+		t.Errorf("user email = %s, want it kept", got)
+	}
+
+	invoice, err := getInvoice(l.ctx, "INV9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoice.Items) != 1 || invoice.Items[0].ProductID != "PROD1" || invoice.Items[0].Quantity != 2 ||
+		invoice.Items[0].UnitPrice.Amount != 5000 || invoice.TotalPrice.Amount != 10000 {
+		t.Errorf("legacy invoice = %+v, want one item of 2 × PROD1 at 50.00", invoice)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(l.stub.state[invoiceKey], &raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["productId"]) != `"PROD1"` || string(raw["quantity"]) != "2" {
+		t.Errorf("legacy invoice lost productId or quantity: %s", l.stub.state[invoiceKey])
+	}
+
+	itemsInvoice, err := getInvoice(l.ctx, "INV10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := itemsInvoice.Items[0]; item.UnitPrice.Amount != 2000 || item.TotalPrice.Amount != 2000 {
+		t.Errorf("item amounts = %v, %v; want 20.00", item.UnitPrice, item.TotalPrice)
+	}
+	migratedItems := string(l.stub.state[itemsKey])
+
+	l.as(l.admin, "migrate-money-again")
+	if migrated, err := l.contract.MigrateMoney(l.ctx); err != nil || migrated != 0 {
+		t.Errorf("second run migrated %d, %v; want 0", migrated, err)
+	}
+	if string(l.stub.state[itemsKey]) != migratedItems {
+		t.Errorf("second run rewrote a migrated document")
+	}
+}
+
+func TestMigrateKeys(t *testing.T) {
+	l := newTestLedger(t)
+
+	l.stub.state["PRODUCT_PROD9"] = []byte(`{"docType":"product","id":"PROD9","name":"Hleb","price":{"amount":9000,"currency":"RSD"},"quantity":3,"merchantId":"MERCHANT1","merchantType":"supermarket"}`)
+	l.stub.state["INVOICE_INV9"] = []byte(`{"docType":"invoice","id":"INV9","merchantId":"MERCHANT1","userId":"USER1","items":[],"totalPrice":{"amount":9000,"currency":"RSD"},"date":"2026-01-02T10:00:00Z"}`)
+
+	l.as(l.admin, "migrate-keys")
+	migrated, err := l.contract.MigrateKeys(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 2 {
+		t.Errorf("migrated %d records, want 2", migrated)
+	}
+	if event := migrationEvent(t, l.stub); event.Migration != "MigrateKeys" || event.Count != 2 {
+		t.Errorf("event = %+v, want MigrateKeys with count 2", event)
+	}
+
+	for _, oldKey := range []string{"PRODUCT_PROD9", "INVOICE_INV9"} {
+		if _, ok := l.stub.state[oldKey]; ok {
+			t.Errorf("old key %s was not deleted", oldKey)
+		}
+	}
+
+	var product models.Product
+	if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD9"); err != nil {
+		t.Errorf("product under composite key: %v", err)
+	}
+	for _, index := range []struct {
+		name   string
+		prefix string
+		id     string
+	}{
+		{indexMerchantProduct, "MERCHANT1", "PROD9"},
+		{indexMerchantInvoice, "MERCHANT1", "INV9"},
+		{indexUserInvoice, "USER1", "INV9"},
+	} {
+		ids, err := indexedIDs(l.ctx, index.name, index.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(ids, index.id) {
+			t.Errorf("%s{%s} = %v, want %s in it", index.name, index.prefix, ids, index.id)
+		}
+	}
+
+	l.as(l.admin, "migrate-keys-again")
+	if migrated, err := l.contract.MigrateKeys(l.ctx); err != nil || migrated != 0 {
+		t.Errorf("second run migrated %d, %v; want 0", migrated, err)
+	}
+}
@@ -0,0 +1,375 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ShipOrder is called by the selling merchant once an escrowed order has
+// been handed to the carrier. A shipped order can no longer be cancelled.
+func (t *TradingContract) ShipOrder(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, error) {
+	caller, err := requireRole(ctx, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	order, invoice, err := getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, order.MerchantID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, merchant.Owner); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := services.ShipOrder(clock, order, invoice); err != nil {
+		return nil, err
+	}
+
+	return order, putOrder(ctx, order, invoice)
+}
+
+// ConfirmDelivery is called by the buyer when a shipped order arrives. The
+// escrowed amount is credited to the merchant.
+func (t *TradingContract) ConfirmDelivery(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, error) {
+	caller, err := requireRole(ctx, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	order, invoice, err := getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, order.UserID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, user.Owner); err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, order.MerchantID); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := services.ConfirmDelivery(clock, order, invoice, &merchant); err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return nil, err
+	}
+
+	return order, putOrder(ctx, order, invoice)
+}
+
+// ReleaseOrder credits the merchant with a shipped order the buyer has not
+// confirmed within the escrow policy's timeout. Callable by the selling
+// merchant or an admin.
+func (t *TradingContract) ReleaseOrder(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	order, invoice, err := getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, order.MerchantID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, merchant.Owner, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	policy, err := getEscrowPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := services.ReleaseOrder(clock, policy, order, invoice, &merchant); err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return nil, err
+	}
+
+	return order, putOrder(ctx, order, invoice)
+}
+
+// CancelOrder cancels an order that has not shipped yet, refunds the buyer
+// and restocks the products. Callable by the buyer, the selling merchant or
+// an admin.
+func (t *TradingContract) CancelOrder(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	order, invoice, err := getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, order.UserID); err != nil {
+		return nil, err
+	}
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, order.MerchantID); err != nil {
+		return nil, err
+	}
+
+	switch caller.Role {
+	case RoleUser:
+		err = requireOwner(caller, user.Owner)
+	case RoleMerchant:
+		err = requireOwner(caller, merchant.Owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	products := make(map[string]*models.Product, len(invoice.Items))
+	for _, item := range invoice.Items {
+		product, err := restockableProduct(ctx, item.ProductID, order.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		if product != nil {
+			products[product.ID] = product
+		}
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loyalty, err := invoiceLoyalty(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	withheld := invoice.PointsWithheld
+	if err := services.CancelOrder(clock, order, invoice, &user, &merchant, products, loyalty); err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+		return nil, err
+	}
+	if invoice.PointsWithheld != withheld {
+		if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range invoice.Items {
+		if product, ok := products[item.ProductID]; ok {
+			if err := putEntity(ctx, product, models.DocTypeProduct, product.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := putLoyalty(ctx, loyalty); err != nil {
+		return nil, err
+	}
+	sales, err := services.CancelledSales(invoice)
+	if err != nil {
+		return nil, err
+	}
+	if err := addSales(ctx, sales); err != nil {
+		return nil, err
+	}
+
+	return order, putOrder(ctx, order, invoice)
+}
+
+// GetOrder returns an order to its buyer, its merchant or an admin.
+func (t *TradingContract) GetOrder(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	order, _, err := getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !caller.IsAdmin() && !caller.actsAs(RoleUser, order.UserID) && !caller.actsAs(RoleMerchant, order.MerchantID) {
+		return nil, accessDenied(caller, "order %s belongs to another user or merchant", orderID)
+	}
+
+	return order, nil
+}
+
+// GetOrders returns the escrowed orders of a user or merchant, to the user
+// or merchant itself or an admin.
+func (t *TradingContract) GetOrders(ctx contractapi.TransactionContextInterface, entityType, id string) ([]*models.Order, error) {
+	var (
+		role  Role
+		index string
+	)
+	switch entityType {
+	case "user":
+		role, index = RoleUser, indexUserOrder
+	case "merchant":
+		role, index = RoleMerchant, indexMerchantOrder
+	default:
+		return nil, services.ErrInvalidInput
+	}
+
+	if _, err := requireSelf(ctx, role, id, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	orderIDs, err := indexedIDs(ctx, index, id)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*models.Order, 0, len(orderIDs))
+	for _, orderID := range orderIDs {
+		var order models.Order
+		if err := getEntity(ctx, &order, models.DocTypeOrder, orderID); err != nil {
+			return nil, err
+		}
+		orders = append(orders, &order)
+	}
+
+	return orders, nil
+}
+
+// SetEscrowPolicy sets how many days after shipping an unconfirmed order
+// may be released to the merchant. Admin only.
+func (t *TradingContract) SetEscrowPolicy(ctx contractapi.TransactionContextInterface, releaseAfterDays int) error {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return err
+	}
+
+	policy, err := services.NewEscrowPolicy(releaseAfterDays)
+	if err != nil {
+		return err
+	}
+
+	if err := putEntity(ctx, policy, models.DocTypeEscrowPolicy); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventEscrowPolicyChanged, models.EscrowPolicyChangedEvent{
+		ReleaseAfterDays: policy.ReleaseAfterDays,
+	})
+}
+
+// GetEscrowPolicy returns the escrow policy in force.
+func (t *TradingContract) GetEscrowPolicy(ctx contractapi.TransactionContextInterface) (*models.EscrowPolicy, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	return getEscrowPolicy(ctx)
+}
+
+// getEscrowPolicy returns the stored policy, or the default one if an admin
+// has not set any.
+func getEscrowPolicy(ctx contractapi.TransactionContextInterface) (*models.EscrowPolicy, error) {
+	var policy models.EscrowPolicy
+	err := getEntity(ctx, &policy, models.DocTypeEscrowPolicy)
+	if err == services.ErrNotFound {
+		return services.DefaultEscrowPolicy(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// getOrder reads an order and the invoice it pays.
+func getOrder(ctx contractapi.TransactionContextInterface, orderID string) (*models.Order, *models.Invoice, error) {
+	var order models.Order
+	if err := getEntity(ctx, &order, models.DocTypeOrder, orderID); err != nil {
+		return nil, nil, err
+	}
+
+	invoice, err := getInvoice(ctx, order.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &order, invoice, nil
+}
+
+// putOrder stores an order after a status change, together with its invoice,
+// and emits the change.
+func putOrder(ctx contractapi.TransactionContextInterface, order *models.Order, invoice *models.Invoice) error {
+	if err := putEntity(ctx, invoice, models.DocTypeInvoice, invoice.ID); err != nil {
+		return err
+	}
+	if err := putEntity(ctx, order, models.DocTypeOrder, order.ID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventOrderStatusChanged, models.OrderStatusChangedEvent{
+		OrderID:      order.ID,
+		UserID:       order.UserID,
+		MerchantID:   order.MerchantID,
+		Status:       order.Status,
+		Amount:       order.Amount,
+		AutoReleased: order.AutoReleased,
+	})
+}
+
+// openOrders stores an order for every escrowed invoice of a purchase.
+func openOrders(ctx contractapi.TransactionContextInterface, invoices []*models.Invoice) error {
+	for _, invoice := range invoices {
+		if invoice.OrderStatus == "" {
+			continue
+		}
+
+		order, err := services.OpenOrder(invoice)
+		if err != nil {
+			return err
+		}
+		if err := putEntity(ctx, order, models.DocTypeOrder, order.ID); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexUserOrder, order.UserID, order.ID); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, indexMerchantOrder, order.MerchantID, order.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"testing"
+)
+
+func TestEscrowOrderLifecycle(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy-parts")
+	invoice, err := c.Purchase(l.ctx, "USER1", "PROD4", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.OrderStatus != models.OrderPending {
+		t.Fatalf("order status = %q, want pending", invoice.OrderStatus)
+	}
+	merchant, err := c.GetMerchantByID(l.ctx, "MERCHANT2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merchant.Balance.Amount != 100000 {
+		t.Errorf("merchant balance = %v, want it unchanged while the order is pending", merchant.Balance)
+	}
+
+	l.as(l.user1, "user-ships")
+	if _, err := c.ShipOrder(l.ctx, invoice.ID); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("buyer shipping: got %v, want ErrAccessDenied", err)
+	}
+	l.as(l.merchant1, "other-merchant-ships")
+	if _, err := c.ShipOrder(l.ctx, invoice.ID); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another merchant shipping: got %v, want ErrAccessDenied", err)
+	}
+	l.as(l.merchant2, "ship")
+	if _, err := c.ShipOrder(l.ctx, invoice.ID); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "cancel-shipped")
+	if _, err := c.CancelOrder(l.ctx, invoice.ID); !errors.Is(err, services.ErrInvalidState) {
+		t.Errorf("cancelling a shipped order: got %v, want ErrInvalidState", err)
+	}
+	l.as(l.user1, "confirm")
+	order, err := c.ConfirmDelivery(l.ctx, invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.OrderDelivered {
+		t.Errorf("order status = %q, want delivered", order.Status)
+	}
+
+	merchant, err = c.GetMerchantByID(l.ctx, "MERCHANT2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merchant.Balance.Amount != 108000 {
+		t.Errorf("merchant balance = %v, want 1080.00 after delivery", merchant.Balance)
+	}
+}
+
+func TestCancelOrderRestocksOnlyTheSellersProduct(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy-parts")
+	invoice, err := c.Purchase(l.ctx, "USER1", "PROD3", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PROD3 now names a product of MERCHANT1.
+	putRaw(t, l.stub, models.DocTypeProduct, "PROD3",
+		`{"docType":"product","id":"PROD3","name":"Sok","price":{"amount":15000,"currency":"RSD"},"quantity":7,"merchantId":"MERCHANT1","merchantType":"supermarket"}`)
+
+	l.as(l.user1, "cancel")
+	if _, err := c.CancelOrder(l.ctx, invoice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var product models.Product
+	if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD3"); err != nil {
+		t.Fatal(err)
+	}
+	if product.MerchantID != "MERCHANT1" || product.Quantity != 7 {
+		t.Errorf("product = %+v, want MERCHANT1's PROD3 untouched", product)
+	}
+	user, err := c.GetUserByID(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Balance.Amount != 50000 {
+		t.Errorf("user balance = %v, want the escrowed amount back", user.Balance)
+	}
+}
@@ -0,0 +1,233 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// maxPageSize caps the page size of the paginated queries so a single call
+// stays well below the peer's total query limit.
+const maxPageSize = 200
+
+// The *Paginated transactions mirror the unbounded queries in
+// contract_queries.go with the same access rules and selectors. They take a
+// page size and the bookmark of the previous page ("" for the first page).
+// Fabric only allows paginated queries in evaluated (read-only) calls.
+
+func (t *TradingContract) GetAllProductsPaginated(ctx contractapi.TransactionContextInterface,
+	pageSize int32, bookmark string) (*models.ProductPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(
+		string(models.DocTypeProduct), []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ProductPage{Records: []*models.Product{}}
+	page.PageInfo, err = readPage(resultsIterator, metadata, func(value []byte) error {
+		var p models.Product
+		if err := json.Unmarshal(value, &p); err != nil {
+			return err
+		}
+		page.Records = append(page.Records, &p)
+		return nil
+	})
+
+	return page, err
+}
+
+func (t *TradingContract) RichQueryProductsPaginated(ctx contractapi.TransactionContextInterface,
+	filterJSON string, pageSize int32, bookmark string) (*models.ProductPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	query, err := productFilterQuery(filterJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryProductPage(ctx, query, pageSize, bookmark)
+}
+
+func (t *TradingContract) GetProductsExpiringSoonPaginated(ctx contractapi.TransactionContextInterface,
+	expiresBeforeDate string, pageSize int32, bookmark string) (*models.ProductPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant); err != nil {
+		return nil, err
+	}
+
+	query, err := expiringSoonQuery(expiresBeforeDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryProductPage(ctx, query, pageSize, bookmark)
+}
+
+func (t *TradingContract) GetUsersWithMinBalancePaginated(ctx contractapi.TransactionContextInterface,
+	minBalance string, pageSize int32, bookmark string) (*models.UserPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	query, err := minBalanceQuery(minBalance)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("GetUsersWithMinBalancePaginated query failed: %v", err)
+	}
+
+	page := &models.UserPage{Records: []*models.User{}}
+	page.PageInfo, err = readPage(resultsIterator, metadata, func(value []byte) error {
+		var u models.User
+		if err := json.Unmarshal(value, &u); err != nil {
+			return err
+		}
+		page.Records = append(page.Records, &u)
+		return nil
+	})
+
+	return page, err
+}
+
+func (t *TradingContract) GetInvoicesByUserAndDateRangePaginated(ctx contractapi.TransactionContextInterface,
+	userID, fromDate, toDate string, pageSize int32, bookmark string) (*models.InvoicePage, error) {
+
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	query, err := userDateRangeQuery(userID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryInvoicePage(ctx, query, pageSize, bookmark)
+}
+
+func (t *TradingContract) GetLowStockProductsPaginated(ctx contractapi.TransactionContextInterface,
+	merchantType string, maxQuantity int, pageSize int32, bookmark string) (*models.ProductPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant); err != nil {
+		return nil, err
+	}
+
+	query, err := lowStockQuery(merchantType, maxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryProductPage(ctx, query, pageSize, bookmark)
+}
+
+func (t *TradingContract) GetMerchantHighValueInvoicesPaginated(ctx contractapi.TransactionContextInterface,
+	merchantID, minTotalPrice string, pageSize int32, bookmark string) (*models.InvoicePage, error) {
+
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	query, err := highValueInvoicesQuery(merchantID, minTotalPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryInvoicePage(ctx, query, pageSize, bookmark)
+}
+
+func queryProductPage(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) (*models.ProductPage, error) {
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("product query failed: %v", err)
+	}
+
+	page := &models.ProductPage{Records: []*models.Product{}}
+	page.PageInfo, err = readPage(resultsIterator, metadata, func(value []byte) error {
+		var p models.Product
+		if err := json.Unmarshal(value, &p); err != nil {
+			return err
+		}
+		page.Records = append(page.Records, &p)
+		return nil
+	})
+
+	return page, err
+}
+
+func queryInvoicePage(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) (*models.InvoicePage, error) {
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("invoice query failed: %v", err)
+	}
+
+	page := &models.InvoicePage{Records: []*models.Invoice{}}
+	page.PageInfo, err = readPage(resultsIterator, metadata, func(value []byte) error {
+		var inv models.Invoice
+		if err := json.Unmarshal(value, &inv); err != nil {
+			return err
+		}
+		page.Records = append(page.Records, &inv)
+		return nil
+	})
+
+	return page, err
+}
+
+// readPage passes every record of one page to visit, closes the iterator and
+// returns the page's bookmark and record count.
+func readPage(resultsIterator shim.StateQueryIteratorInterface, metadata *peer.QueryResponseMetadata,
+	visit func(value []byte) error) (models.PageInfo, error) {
+
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return models.PageInfo{}, err
+		}
+		if err := visit(kv.Value); err != nil {
+			return models.PageInfo{}, err
+		}
+	}
+
+	return models.PageInfo{
+		Bookmark:     metadata.GetBookmark(),
+		RecordsCount: metadata.GetFetchedRecordsCount(),
+	}, nil
+}
+
+func validatePageSize(pageSize int32) error {
+	if pageSize <= 0 || pageSize > maxPageSize {
+		return fmt.Errorf("pageSize must be between 1 and %d", maxPageSize)
+	}
+	return nil
+}
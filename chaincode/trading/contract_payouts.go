@@ -0,0 +1,230 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"chaincode/trading/services"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Withdraw pays amount out of a user's or merchant's balance to the bank
+// account named by reference. The payout must meet the minimum of the payout
+// policy and stay within its daily limit. Only the owner of the record may
+// withdraw.
+func (t *TradingContract) Withdraw(ctx contractapi.TransactionContextInterface,
+	entityType, id, amountStr, reference string) (*models.Payout, error) {
+
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := services.ParseAmount(amountStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entity  auditable
+		docType models.DocType
+		owner   models.Owner
+		role    Role
+	)
+	switch entityType {
+	case "user":
+		var user models.User
+		if err := getEntity(ctx, &user, models.DocTypeUser, id); err != nil {
+			return nil, err
+		}
+		entity, docType, owner, role = &user, models.DocTypeUser, user.Owner, RoleUser
+	case "merchant":
+		var merchant models.Merchant
+		if err := getEntity(ctx, &merchant, models.DocTypeMerchant, id); err != nil {
+			return nil, err
+		}
+		entity, docType, owner, role = &merchant, models.DocTypeMerchant, merchant.Owner, RoleMerchant
+	default:
+		return nil, services.ErrInvalidInput
+	}
+
+	if !caller.IsAdmin() && caller.Role != role {
+		return nil, accessDenied(caller, "only a %s may withdraw from a %s balance", role, entityType)
+	}
+	if err := requireOwner(caller, owner); err != nil {
+		return nil, err
+	}
+
+	policy, err := getPayoutPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	day := services.PayoutDay(clock.Now())
+	paidToday, err := paidOutOn(ctx, entityType, id, day, policy.DailyLimit.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	payout, err := services.Payout(clock, policy, entity, amount, paidToday, "PAY-"+txID, reference)
+	if err != nil {
+		return nil, err
+	}
+	payout.TxID = txID
+
+	if err := requireAbsent(ctx, models.DocTypePayout, payout.ID); err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, entity, docType, id); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, payout, models.DocTypePayout, payout.ID); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, indexEntityPayout, entityType, id, payout.Day, payout.ID); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, indexDayPayout, payout.Day, payout.ID); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, models.EventFundsWithdrawn, models.FundsWithdrawnEvent{
+		EntityType: entityType,
+		EntityID:   id,
+		PayoutID:   payout.ID,
+		Amount:     payout.Amount,
+		Balance:    payout.BalanceAfter,
+	}); err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+// SetPayoutPolicy sets the minimum payout and the per-day limit of each
+// user and merchant, e.g. "100.00" and "100000.00". Admin only.
+func (t *TradingContract) SetPayoutPolicy(ctx contractapi.TransactionContextInterface, minPayout, dailyLimit string) error {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return err
+	}
+
+	policy, err := services.NewPayoutPolicy(minPayout, dailyLimit)
+	if err != nil {
+		return err
+	}
+
+	if err := putEntity(ctx, policy, models.DocTypePayoutPolicy); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventPayoutPolicyChanged, models.PayoutPolicyChangedEvent{
+		MinPayout:  policy.MinPayout,
+		DailyLimit: policy.DailyLimit,
+	})
+}
+
+// GetPayoutPolicy returns the payout policy in force.
+func (t *TradingContract) GetPayoutPolicy(ctx contractapi.TransactionContextInterface) (*models.PayoutPolicy, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	return getPayoutPolicy(ctx)
+}
+
+// GetPayouts returns a user's or merchant's payouts, oldest day first, to
+// the user or merchant itself or an admin.
+func (t *TradingContract) GetPayouts(ctx contractapi.TransactionContextInterface, entityType, id string) ([]*models.Payout, error) {
+	var role Role
+	switch entityType {
+	case "user":
+		role = RoleUser
+	case "merchant":
+		role = RoleMerchant
+	default:
+		return nil, services.ErrInvalidInput
+	}
+
+	if _, err := requireSelf(ctx, role, id, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	payoutIDs, err := indexedIDs(ctx, indexEntityPayout, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPayouts(ctx, payoutIDs)
+}
+
+// GetPayoutsByDay returns every payout of one UTC day ("2026-10-17"), for
+// reconciliation against the bank file of that day. Admin only.
+func (t *TradingContract) GetPayoutsByDay(ctx contractapi.TransactionContextInterface, day string) ([]*models.Payout, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	payoutIDs, err := indexedIDs(ctx, indexDayPayout, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPayouts(ctx, payoutIDs)
+}
+
+// getPayoutPolicy returns the stored policy, or the default one if an admin
+// has not set any.
+func getPayoutPolicy(ctx contractapi.TransactionContextInterface) (*models.PayoutPolicy, error) {
+	var policy models.PayoutPolicy
+	err := getEntity(ctx, &policy, models.DocTypePayoutPolicy)
+	if err == services.ErrNotFound {
+		return services.DefaultPayoutPolicy(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// paidOutOn sums an entity's payouts on day.
+func paidOutOn(ctx contractapi.TransactionContextInterface, entityType, id, day, currency string) (money.Money, error) {
+	total := money.Zero(currency)
+
+	payoutIDs, err := indexedIDs(ctx, indexEntityPayout, entityType, id, day)
+	if err != nil {
+		return total, err
+	}
+
+	payouts, err := getPayouts(ctx, payoutIDs)
+	if err != nil {
+		return total, err
+	}
+	for _, p := range payouts {
+		if total, err = total.Add(p.Amount); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func getPayouts(ctx contractapi.TransactionContextInterface, payoutIDs []string) ([]*models.Payout, error) {
+	payouts := make([]*models.Payout, 0, len(payoutIDs))
+	for _, id := range payoutIDs {
+		var p models.Payout
+		if err := getEntity(ctx, &p, models.DocTypePayout, id); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, &p)
+	}
+
+	return payouts, nil
+}
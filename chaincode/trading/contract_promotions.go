@@ -0,0 +1,215 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// CreatePromotion adds a discount on one of the merchant's products, or on
+// its whole catalog, for a date window. Promo codes are unique across the
+// ledger and case-insensitive.
+func (t *TradingContract) CreatePromotion(ctx contractapi.TransactionContextInterface,
+	merchantID string, input models.PromotionInput) (*models.Promotion, error) {
+
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, merchantID); err != nil {
+		return nil, err
+	}
+
+	if err := requireOwner(caller, merchant.Owner, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := services.CreatePromotion(clock, merchant.ID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypePromotion, promotion.ID); err != nil {
+		return nil, err
+	}
+
+	if promotion.ProductID != "" {
+		var product models.Product
+		if err := getEntity(ctx, &product, models.DocTypeProduct, promotion.ProductID); err != nil {
+			return nil, err
+		}
+		if product.MerchantID != merchant.ID {
+			return nil, &services.ValidationError{Fields: []services.FieldError{
+				{Field: "productId", Message: fmt.Sprintf("product %s belongs to merchant %s", product.ID, product.MerchantID)},
+			}}
+		}
+	}
+
+	if promotion.Code != "" {
+		ids, err := indexedIDs(ctx, indexPromoCode, promotion.Code)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			return nil, fmt.Errorf("%w: promo code %s", services.ErrAlreadyExists, promotion.Code)
+		}
+		if err := putIndex(ctx, indexPromoCode, promotion.Code, promotion.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putEntity(ctx, promotion, models.DocTypePromotion, promotion.ID); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, indexMerchantPromo, merchant.ID, promotion.ID); err != nil {
+		return nil, err
+	}
+	if promotion.Code == "" {
+		if err := putIndex(ctx, indexActivePromo, merchant.ID, promotion.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := emitEvent(ctx, models.EventPromotionChanged, promotionChangedEvent(promotion)); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// DeactivatePromotion ends a promotion early. Its promo code stays reserved.
+func (t *TradingContract) DeactivatePromotion(ctx contractapi.TransactionContextInterface, promotionID string) error {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant)
+	if err != nil {
+		return err
+	}
+
+	var promotion models.Promotion
+	if err := getEntity(ctx, &promotion, models.DocTypePromotion, promotionID); err != nil {
+		return err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, promotion.MerchantID); err != nil {
+		return err
+	}
+
+	if err := requireOwner(caller, merchant.Owner, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := services.DeactivatePromotion(&promotion); err != nil {
+		return err
+	}
+
+	if err := putEntity(ctx, &promotion, models.DocTypePromotion, promotion.ID); err != nil {
+		return err
+	}
+	if err := delIndex(ctx, indexActivePromo, promotion.MerchantID, promotion.ID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventPromotionChanged, promotionChangedEvent(&promotion))
+}
+
+// GetPromotionsByMerchant returns all of a merchant's promotions, including
+// their promo codes, to the merchant or an admin.
+func (t *TradingContract) GetPromotionsByMerchant(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Promotion, error) {
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return getMerchantPromotions(ctx, merchantID)
+}
+
+func promotionChangedEvent(p *models.Promotion) models.PromotionChangedEvent {
+	return models.PromotionChangedEvent{
+		PromotionID: p.ID,
+		MerchantID:  p.MerchantID,
+		ProductID:   p.ProductID,
+		Kind:        p.Kind,
+		StartsAt:    p.StartsAt,
+		EndsAt:      p.EndsAt,
+		Active:      p.Active,
+		HasCode:     p.Code != "",
+	}
+}
+
+func getMerchantPromotions(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Promotion, error) {
+	ids, err := indexedIDs(ctx, indexMerchantPromo, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions := make([]*models.Promotion, 0, len(ids))
+	for _, id := range ids {
+		var p models.Promotion
+		if err := getEntity(ctx, &p, models.DocTypePromotion, id); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, &p)
+	}
+
+	return promotions, nil
+}
+
+// loadPricing collects the promotions a purchase from merchants may apply:
+// their active promotions without a code, plus the one promoCode names, which
+// is looked up by its key. Promotions that have ended or are used up are
+// dropped from the active index on the way, so it only grows with running
+// promotions.
+func loadPricing(ctx contractapi.TransactionContextInterface, merchants map[string]*models.Merchant, promoCode string) (services.Pricing, error) {
+	pricing := services.Pricing{PromoCode: promoCode}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return pricing, err
+	}
+
+	for _, merchantID := range sortedMerchantIDs(merchants) {
+		ids, err := indexedIDs(ctx, indexActivePromo, merchantID)
+		if err != nil {
+			return pricing, err
+		}
+		for _, id := range ids {
+			var p models.Promotion
+			if err := getEntity(ctx, &p, models.DocTypePromotion, id); err != nil {
+				return pricing, err
+			}
+			if services.PromotionEnded(&p, clock.Now()) {
+				if err := delIndex(ctx, indexActivePromo, merchantID, id); err != nil {
+					return pricing, err
+				}
+				continue
+			}
+			pricing.Promotions = append(pricing.Promotions, &p)
+		}
+	}
+
+	if promoCode == "" {
+		return pricing, nil
+	}
+
+	ids, err := indexedIDs(ctx, indexPromoCode, services.NormalizePromoCode(promoCode))
+	if err != nil {
+		return pricing, err
+	}
+	for _, id := range ids {
+		var p models.Promotion
+		if err := getEntity(ctx, &p, models.DocTypePromotion, id); err != nil {
+			return pricing, err
+		}
+		pricing.Promotions = append(pricing.Promotions, &p)
+	}
+
+	return pricing, nil
+}
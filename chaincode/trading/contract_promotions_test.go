@@ -0,0 +1,147 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// promotionWindow returns a window around the ledger time that ends after
+// days.
+func (l *testLedger) promotionWindow(days int) (string, string) {
+	return l.stub.ts.Add(-time.Hour).Format(time.RFC3339), l.stub.ts.AddDate(0, 0, days).Format(time.RFC3339)
+}
+
+func TestPromoCodeUseLimit(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	startsAt, endsAt := l.promotionWindow(30)
+
+	l.as(l.admin, "create-promo")
+	if _, err := c.CreatePromotion(l.ctx, "MERCHANT1", models.PromotionInput{
+		ID: "PROMO1", ProductID: "PROD1", Kind: models.DiscountPercent, PercentOff: 10,
+		StartsAt: startsAt, EndsAt: endsAt, Code: "spring10", MaxUses: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.admin, "create-same-code")
+	if _, err := c.CreatePromotion(l.ctx, "MERCHANT1", models.PromotionInput{
+		ID: "PROMO2", Kind: models.DiscountPercent, PercentOff: 5, StartsAt: startsAt, EndsAt: endsAt, Code: "SPRING10",
+	}); !errors.Is(err, services.ErrAlreadyExists) {
+		t.Errorf("reusing the code: got %v, want ErrAlreadyExists", err)
+	}
+
+	tests := []struct {
+		name      string
+		productID string
+		code      string
+		wantErr   error
+		wantPrice int64
+	}{
+		{name: "unknown code", productID: "PROD1", code: "AUTUMN10", wantErr: services.ErrPromoUnavailable},
+		{name: "code for another product", productID: "PROD2", code: "spring10", wantErr: services.ErrPromoUnavailable},
+		{name: "first use", productID: "PROD1", code: "Spring10", wantPrice: 4500},
+		{name: "used up", productID: "PROD1", code: "spring10", wantErr: services.ErrPromoUnavailable},
+		{name: "no code", productID: "PROD1", wantPrice: 5000},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(l.user1, "buy-"+string(rune('a'+i)))
+			invoice, err := c.Purchase(l.ctx, "USER1", tt.productID, 1, models.PurchaseOptions{PromoCode: tt.code})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if item := invoice.Items[0]; item.UnitPrice.Amount != tt.wantPrice {
+				t.Errorf("unit price = %v, want %d", item.UnitPrice, tt.wantPrice)
+			}
+			if tt.code != "" && (invoice.PromoCode != "SPRING10" || invoice.Items[0].PromotionID != "PROMO1") {
+				t.Errorf("invoice = %+v, want PROMO1 applied through SPRING10", invoice)
+			}
+		})
+	}
+
+	l.as(l.admin, "list-promos")
+	promotions, err := c.GetPromotionsByMerchant(l.ctx, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promotions) != 1 || promotions[0].Uses != 1 {
+		t.Errorf("promotions = %+v, want PROMO1 used once", promotions)
+	}
+}
+
+func TestActivePromotionIndex(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	for _, input := range []models.PromotionInput{
+		{ID: "SHORT", ProductID: "PROD2", Kind: models.DiscountFixed, AmountOff: "5"},
+		{ID: "STOPPED", ProductID: "PROD1", Kind: models.DiscountPercent, PercentOff: 20},
+		{ID: "CODED", Kind: models.DiscountPercent, PercentOff: 5, Code: "CODED-5"},
+	} {
+		days := 30
+		if input.ID == "SHORT" {
+			days = 1
+		}
+		input.StartsAt, input.EndsAt = l.promotionWindow(days)
+
+		l.as(l.admin, "create-"+input.ID)
+		if _, err := c.CreatePromotion(l.ctx, "MERCHANT1", input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	active := func() []string {
+		t.Helper()
+		ids, err := indexedIDs(l.ctx, indexActivePromo, "MERCHANT1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+	if got := active(); !slices.Equal(got, []string{"SHORT", "STOPPED"}) {
+		t.Errorf("active promotions = %v, want the two without a code", got)
+	}
+
+	l.as(l.admin, "deactivate")
+	if err := c.DeactivatePromotion(l.ctx, "STOPPED"); err != nil {
+		t.Fatal(err)
+	}
+	if got := active(); !slices.Equal(got, []string{"SHORT"}) {
+		t.Errorf("after DeactivatePromotion: active promotions = %v, want [SHORT]", got)
+	}
+
+	l.as(l.user1, "buy-discounted")
+	invoice, err := c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Items[0].UnitPrice.Amount != 1500 {
+		t.Errorf("unit price = %v, want 15.00 with SHORT", invoice.Items[0].UnitPrice)
+	}
+
+	l.stub.ts = l.stub.ts.AddDate(0, 0, 2)
+	l.as(l.user1, "buy-after-end")
+	invoice, err = c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Items[0].UnitPrice.Amount != 2000 || invoice.Items[0].PromotionID != "" {
+		t.Errorf("item = %+v, want the list price after SHORT ended", invoice.Items[0])
+	}
+	if got := active(); len(got) != 0 {
+		t.Errorf("active promotions = %v, want the ended SHORT dropped by the purchase", got)
+	}
+}
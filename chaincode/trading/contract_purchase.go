@@ -0,0 +1,245 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// PurchaseCart buys several products, possibly from different merchants, in
+// one transaction. Either every line is bought or the transaction fails and
+// nothing is written. One invoice is returned per merchant.
+func (t *TradingContract) PurchaseCart(ctx contractapi.TransactionContextInterface,
+	userID string, lines []models.CartLine, options models.PurchaseOptions) ([]*models.Invoice, error) {
+
+	return t.purchase(ctx, userID, lines, options)
+}
+
+// purchase issues invoices whose IDs are derived from the transaction ID, so
+// clients never pick them. Invoices of merchants that sell through escrow
+// also open an order of the same ID. With an idempotency key, a retried
+// submission that already went through returns the original invoices and
+// charges nothing.
+func (t *TradingContract) purchase(ctx contractapi.TransactionContextInterface,
+	userID string, lines []models.CartLine, options models.PurchaseOptions) ([]*models.Invoice, error) {
+
+	caller, err := requireRole(ctx, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, userID); err != nil {
+		return nil, err
+	}
+
+	if err := requireOwner(caller, user.Owner); err != nil {
+		return nil, err
+	}
+
+	if options.IdempotencyKey != "" {
+		var record models.PurchaseRecord
+		err := getEntity(ctx, &record, models.DocTypePurchase, user.ID, options.IdempotencyKey)
+		if err == nil {
+			return getInvoices(ctx, record.InvoiceIDs)
+		}
+		if err != services.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	products, merchants, err := loadCart(ctx, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := loadPricing(ctx, merchants, options.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+	if pricing.VATPercents, err = loadVATPercents(ctx, products); err != nil {
+		return nil, err
+	}
+
+	loyalty, err := loadLoyalty(ctx, user.ID, sortedMerchantIDs(merchants))
+	if err != nil {
+		return nil, err
+	}
+	loyalty.Redeem = options.RedeemPoints
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	invoices, err := services.PurchaseCart(clock, &user, lines, products, merchants, pricing, loyalty, "INV-"+txID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, invoice := range invoices {
+		if err := requireAbsent(ctx, models.DocTypeInvoice, invoice.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+		return nil, err
+	}
+	for _, invoice := range invoices {
+		for _, item := range invoice.Items {
+			if err := putEntity(ctx, products[item.ProductID], models.DocTypeProduct, item.ProductID); err != nil {
+				return nil, err
+			}
+		}
+		if err := putEntity(ctx, merchants[invoice.MerchantID], models.DocTypeMerchant, invoice.MerchantID); err != nil {
+			return nil, err
+		}
+		if err := putEntity(ctx, invoice, models.DocTypeInvoice, invoice.ID); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, indexUserInvoice, invoice.UserID, invoice.ID); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, indexMerchantInvoice, invoice.MerchantID, invoice.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putAppliedPromotions(ctx, pricing, invoices); err != nil {
+		return nil, err
+	}
+	if err := openOrders(ctx, invoices); err != nil {
+		return nil, err
+	}
+	if err := putLoyalty(ctx, loyalty); err != nil {
+		return nil, err
+	}
+	if err := recordSales(ctx, invoices); err != nil {
+		return nil, err
+	}
+
+	if options.IdempotencyKey != "" {
+		record := &models.PurchaseRecord{
+			DocType:        models.DocTypePurchase,
+			UserID:         user.ID,
+			IdempotencyKey: options.IdempotencyKey,
+			TxID:           txID,
+			InvoiceIDs:     make([]string, 0, len(invoices)),
+		}
+		for _, invoice := range invoices {
+			record.InvoiceIDs = append(record.InvoiceIDs, invoice.ID)
+		}
+		if err := putEntity(ctx, record, models.DocTypePurchase, user.ID, options.IdempotencyKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := emitEvent(ctx, models.EventPurchaseCompleted, purchaseCompletedEvent(user.ID, invoices)); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// putAppliedPromotions stores the promotions whose usage count the purchase
+// raised.
+func putAppliedPromotions(ctx contractapi.TransactionContextInterface, pricing services.Pricing, invoices []*models.Invoice) error {
+	used := make(map[string]bool)
+	for _, invoice := range invoices {
+		for _, item := range invoice.Items {
+			if item.PromotionID != "" {
+				used[item.PromotionID] = true
+			}
+		}
+	}
+
+	for _, p := range pricing.Promotions {
+		if used[p.ID] {
+			if err := putEntity(ctx, p, models.DocTypePromotion, p.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func purchaseCompletedEvent(userID string, invoices []*models.Invoice) models.PurchaseCompletedEvent {
+	event := models.PurchaseCompletedEvent{UserID: userID, Invoices: make([]models.PurchasedInvoiceRef, 0, len(invoices))}
+	for _, invoice := range invoices {
+		ref := models.PurchasedInvoiceRef{
+			InvoiceID:      invoice.ID,
+			MerchantID:     invoice.MerchantID,
+			ProductIDs:     make([]string, 0, len(invoice.Items)),
+			Total:          invoice.TotalPrice,
+			PointsRedeemed: invoice.PointsRedeemed,
+			PointsEarned:   invoice.PointsEarned,
+		}
+		for _, item := range invoice.Items {
+			ref.ProductIDs = append(ref.ProductIDs, item.ProductID)
+		}
+
+		event.Invoices = append(event.Invoices, ref)
+		event.Total, _ = event.Total.Add(invoice.TotalPrice)
+	}
+
+	return event
+}
+
+func getInvoices(ctx contractapi.TransactionContextInterface, invoiceIDs []string) ([]*models.Invoice, error) {
+	invoices := make([]*models.Invoice, 0, len(invoiceIDs))
+	for _, id := range invoiceIDs {
+		invoice, err := getInvoice(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, nil
+}
+
+// loadCart reads every product in the cart and the merchants selling them.
+func loadCart(ctx contractapi.TransactionContextInterface, lines []models.CartLine) (map[string]*models.Product, map[string]*models.Merchant, error) {
+	products := make(map[string]*models.Product)
+	merchants := make(map[string]*models.Merchant)
+
+	for _, line := range lines {
+		if _, ok := products[line.ProductID]; ok {
+			continue
+		}
+
+		var product models.Product
+		if err := getEntity(ctx, &product, models.DocTypeProduct, line.ProductID); err != nil {
+			return nil, nil, err
+		}
+		products[product.ID] = &product
+
+		if _, ok := merchants[product.MerchantID]; ok {
+			continue
+		}
+
+		var merchant models.Merchant
+		if err := getEntity(ctx, &merchant, models.DocTypeMerchant, product.MerchantID); err != nil {
+			return nil, nil, err
+		}
+		merchants[merchant.ID] = &merchant
+	}
+
+	return products, merchants, nil
+}
+
+// sortedMerchantIDs returns the IDs of merchants in a fixed order.
+func sortedMerchantIDs(merchants map[string]*models.Merchant) []string {
+	merchantIDs := make([]string, 0, len(merchants))
+	for merchantID := range merchants {
+		merchantIDs = append(merchantIDs, merchantID)
+	}
+	sort.Strings(merchantIDs)
+
+	return merchantIDs
+}
@@ -0,0 +1,71 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"slices"
+	"testing"
+)
+
+func TestPurchaseIdempotencyKey(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	lines := []models.CartLine{{ProductID: "PROD2", Quantity: 1}, {ProductID: "PROD4", Quantity: 1}}
+	key := models.PurchaseOptions{IdempotencyKey: "cart-42"}
+
+	l.as(l.user1, "tx-first")
+	first, err := c.PurchaseCart(l.ctx, "USER1", lines, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].ID != "INV-tx-first-MERCHANT1" || first[1].ID != "INV-tx-first-MERCHANT2" {
+		t.Fatalf("invoices = %v, want one per merchant named after the tx ID", invoiceIDs(first))
+	}
+	user, err := c.GetUserByID(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := user.Balance
+
+	tests := []struct {
+		name      string
+		txID      string
+		options   models.PurchaseOptions
+		wantIDs   []string
+		wantSpent bool
+	}{
+		{name: "retry with the same key", txID: "tx-retry", options: key, wantIDs: invoiceIDs(first)},
+		{name: "another key", txID: "tx-other", options: models.PurchaseOptions{IdempotencyKey: "cart-43"},
+			wantIDs: []string{"INV-tx-other-MERCHANT1", "INV-tx-other-MERCHANT2"}, wantSpent: true},
+		{name: "no key", txID: "tx-nokey", wantIDs: []string{"INV-tx-nokey-MERCHANT1", "INV-tx-nokey-MERCHANT2"}, wantSpent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(l.user1, tt.txID)
+			invoices, err := c.PurchaseCart(l.ctx, "USER1", lines, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := invoiceIDs(invoices); !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("invoices = %v, want %v", got, tt.wantIDs)
+			}
+
+			user, err := c.GetUserByID(l.ctx, "USER1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spent := user.Balance != balance; spent != tt.wantSpent {
+				t.Errorf("balance %v -> %v, want charged = %v", balance, user.Balance, tt.wantSpent)
+			}
+			balance = user.Balance
+		})
+	}
+}
+
+func invoiceIDs(invoices []*models.Invoice) []string {
+	ids := make([]string, 0, len(invoices))
+	for _, invoice := range invoices {
+		ids = append(ids, invoice.ID)
+	}
+	return ids
+}
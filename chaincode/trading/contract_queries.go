@@ -2,6 +2,8 @@ package trading
 
 import (
 	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"chaincode/trading/services"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -9,18 +11,93 @@ import (
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
 
+// ProductFilter prices are decimal amounts such as "10" or "99.90"; JSON
+// numbers are accepted too.
 type ProductFilter struct {
-	ID           string   `json:"id,omitempty"`
-	Name         string   `json:"name,omitempty"`
-	MerchantType string   `json:"merchantType,omitempty"`
-	PriceMin     *float64 `json:"priceMin,omitempty"`
-	PriceMax     *float64 `json:"priceMax,omitempty"`
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	MerchantType string `json:"merchantType,omitempty"`
+	PriceMin     string `json:"priceMin,omitempty"`
+	PriceMax     string `json:"priceMax,omitempty"`
+	// Category matches products filed under the category or any of its
+	// subcategories.
+	Category string `json:"category,omitempty"`
+}
+
+// UnmarshalJSON accepts price bounds both as decimal strings and as the JSON
+// numbers clients sent before prices became money, e.g. 10 or 99.9.
+func (f *ProductFilter) UnmarshalJSON(data []byte) error {
+	type plain ProductFilter
+	doc := struct {
+		*plain
+		PriceMin json.RawMessage `json:"priceMin,omitempty"`
+		PriceMax json.RawMessage `json:"priceMax,omitempty"`
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	var err error
+	if f.PriceMin, err = priceBound(doc.PriceMin); err != nil {
+		return fmt.Errorf("priceMin: %v", err)
+	}
+	if f.PriceMax, err = priceBound(doc.PriceMax); err != nil {
+		return fmt.Errorf("priceMax: %v", err)
+	}
+	return nil
+}
+
+// priceBound returns a price bound given as a JSON string or number as the
+// decimal text money.Parse reads.
+func priceBound(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
 }
 
 func (t *TradingContract) RichQueryProducts(ctx contractapi.TransactionContextInterface, filterJSON string) ([]*models.Product, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	query, err := productFilterQuery(filterJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var products []*models.Product
+	for resultsIterator.HasNext() {
+		kv, _ := resultsIterator.Next()
+		var p models.Product
+		_ = json.Unmarshal(kv.Value, &p)
+		products = append(products, &p)
+	}
+
+	return products, nil
+}
+
+// productFilterQuery builds the CouchDB query of RichQueryProducts.
+func productFilterQuery(filterJSON string) (string, error) {
 	var filter ProductFilter
 	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
-		return nil, fmt.Errorf("cannot parse filter JSON: %v", err)
+		return "", fmt.Errorf("cannot parse filter JSON: %v", err)
 	}
 
 	selector := make(map[string]interface{})
@@ -35,15 +112,23 @@ func (t *TradingContract) RichQueryProducts(ctx contractapi.TransactionContextIn
 	if filter.MerchantType != "" {
 		selector["merchantType"] = filter.MerchantType
 	}
-	if filter.PriceMin != nil || filter.PriceMax != nil {
-		priceRange := make(map[string]float64)
-		if filter.PriceMin != nil {
-			priceRange["$gte"] = *filter.PriceMin
+	if filter.Category != "" {
+		selector["categoryPath"] = map[string]interface{}{"$elemMatch": map[string]string{"$eq": filter.Category}}
+	}
+	if filter.PriceMin != "" || filter.PriceMax != "" {
+		priceRange := make(map[string]int64)
+		for op, value := range map[string]string{"$gte": filter.PriceMin, "$lte": filter.PriceMax} {
+			if value == "" {
+				continue
+			}
+			price, err := money.Parse(value)
+			if err != nil {
+				return "", fmt.Errorf("cannot parse price filter: %v", err)
+			}
+			priceRange[op] = price.Amount
+			selector["price.currency"] = price.Currency
 		}
-		if filter.PriceMax != nil {
-			priceRange["$lte"] = *filter.PriceMax
-		}
-		selector["price"] = priceRange
+		selector["price.amount"] = priceRange
 	}
 
 	query := map[string]interface{}{
@@ -51,25 +136,18 @@ func (t *TradingContract) RichQueryProducts(ctx contractapi.TransactionContextIn
 	}
 
 	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
-	if err != nil {
-		return nil, fmt.Errorf("query failed: %v", err)
-	}
-	defer resultsIterator.Close()
-
-	var products []*models.Product
-	for resultsIterator.HasNext() {
-		kv, _ := resultsIterator.Next()
-		var p models.Product
-		_ = json.Unmarshal(kv.Value, &p)
-		products = append(products, &p)
-	}
-
-	return products, nil
+	return string(queryBytes), nil
 }
 
 func (s *TradingContract) GetMerchantByID(ctx contractapi.TransactionContextInterface, merchantID string) (*models.Merchant, error) {
-	merchantKey := "MERCHANT_" + merchantID
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	merchantKey, err := ledgerKey(ctx, models.DocTypeMerchant, merchantID)
+	if err != nil {
+		return nil, err
+	}
 	merchantBytes, err := ctx.GetStub().GetState(merchantKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read merchant: %v", err)
@@ -88,7 +166,11 @@ func (s *TradingContract) GetMerchantByID(ctx contractapi.TransactionContextInte
 }
 
 func (s *TradingContract) GetAllProducts(ctx contractapi.TransactionContextInterface) ([]*models.Product, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("PRODUCT_", "PRODUCT_~")
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeProduct), []string{})
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +195,90 @@ func (s *TradingContract) GetAllProducts(ctx contractapi.TransactionContextInter
 	return assets, nil
 }
 
+// GetProductsByMerchant returns the products a merchant sells, found through
+// the merchant~product index rather than a scan of all products.
+func (s *TradingContract) GetProductsByMerchant(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Product, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	return getMerchantProducts(ctx, merchantID)
+}
+
+// GetExpiredProducts returns a merchant's products that are past their
+// expiration at the transaction time and still have stock to write off.
+func (s *TradingContract) GetExpiredProducts(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Product, error) {
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := getMerchantProducts(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]*models.Product, 0)
+	for _, p := range products {
+		if p.Quantity > 0 && services.IsExpired(p, clock.Now()) {
+			expired = append(expired, p)
+		}
+	}
+
+	return expired, nil
+}
+
+func getMerchantProducts(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Product, error) {
+	productIDs, err := indexedIDs(ctx, indexMerchantProduct, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, 0, len(productIDs))
+	for _, id := range productIDs {
+		var p models.Product
+		if err := getEntity(ctx, &p, models.DocTypeProduct, id); err != nil {
+			return nil, err
+		}
+		products = append(products, &p)
+	}
+
+	return products, nil
+}
+
+// GetInvoicesByUser returns a user's invoices through the user~invoice index.
+func (s *TradingContract) GetInvoicesByUser(ctx contractapi.TransactionContextInterface, userID string) ([]*models.Invoice, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invoiceIDs, err := indexedIDs(ctx, indexUserInvoice, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getInvoices(ctx, invoiceIDs)
+}
+
+// GetInvoicesByMerchant returns a merchant's invoices through the
+// merchant~invoice index.
+func (s *TradingContract) GetInvoicesByMerchant(ctx contractapi.TransactionContextInterface, merchantID string) ([]*models.Invoice, error) {
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invoiceIDs, err := indexedIDs(ctx, indexMerchantInvoice, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getInvoices(ctx, invoiceIDs)
+}
+
 // -------------------------------------------------------------------------------
 // RICH QUERY 1 – Proizvodi kojima uskoro ističe rok trajanja
 //
@@ -121,7 +287,7 @@ func (s *TradingContract) GetAllProducts(ctx contractapi.TransactionContextInter
 // Vraća samo one proizvode čiji rok ističe pre navedenog datuma.
 //
 // LevelDB ekvivalent: ne postoji direktan ekvivalent. Moralo bi se:
-//   1. GetStateByRange("PRODUCT_", "PRODUCT_~") – dohvati SVE proizvode
+//   1. GetStateByPartialCompositeKey("product", {}) – dohvati SVE proizvode
 //   2. Deserijalizovati svaki dokument u Go strukturu
 //   3. Ručno u Go kodu porediti polje Expiration sa zadatim datumom
 //   To znači da se ceo skup podataka učitava u memoriju chaincode-a,
@@ -135,27 +301,16 @@ func (t *TradingContract) GetProductsExpiringSoon(
 	expiresBeforeDate string,
 ) ([]*models.Product, error) {
 
-	if expiresBeforeDate == "" {
-		return nil, fmt.Errorf("expiresBeforeDate ne sme biti prazan")
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant); err != nil {
+		return nil, err
 	}
 
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"docType": "product",
-			"expiration": map[string]interface{}{
-				"$lte": expiresBeforeDate,
-			},
-			"quantity": map[string]interface{}{
-				"$gt": 0,
-			},
-		},
-		"sort": []map[string]string{
-			{"expiration": "asc"},
-		},
+	query, err := expiringSoonQuery(expiresBeforeDate)
+	if err != nil {
+		return nil, err
 	}
 
-	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetProductsExpiringSoon query failed: %v", err)
 	}
@@ -173,6 +328,31 @@ func (t *TradingContract) GetProductsExpiringSoon(
 	return products, nil
 }
 
+// expiringSoonQuery builds the CouchDB query of GetProductsExpiringSoon.
+func expiringSoonQuery(expiresBeforeDate string) (string, error) {
+	if expiresBeforeDate == "" {
+		return "", fmt.Errorf("expiresBeforeDate ne sme biti prazan")
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType": "product",
+			"expiration": map[string]interface{}{
+				"$lte": expiresBeforeDate,
+			},
+			"quantity": map[string]interface{}{
+				"$gt": 0,
+			},
+		},
+		"sort": []map[string]string{
+			{"expiration": "asc"},
+		},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes), nil
+}
+
 // -------------------------------------------------------------------------------
 // RICH QUERY 2 – Korisnici sa visokim stanjem na računu
 //
@@ -181,7 +361,7 @@ func (t *TradingContract) GetProductsExpiringSoon(
 // i numeričkom opsegu u jednom prolazu kroz bazu.
 //
 // LevelDB ekvivalent:
-//   1. GetStateByRange("USER_", "USER_~") – dohvati sve korisnike
+//   1. GetStateByPartialCompositeKey("user", {}) – dohvati sve korisnike
 //   2. Iterirati i ručno filtrirati u Go-u: if user.Balance >= minBalance
 //   3. Nema sortiranja na nivou baze – moralo bi se sortirati u memoriji
 //      sort.Slice(users, func(i,j int) bool { return users[i].Balance > users[j].Balance })
@@ -191,27 +371,19 @@ func (t *TradingContract) GetProductsExpiringSoon(
 // sortirane po stanju opadajuće (najbogatiji prvi).
 func (t *TradingContract) GetUsersWithMinBalance(
 	ctx contractapi.TransactionContextInterface,
-	minBalance float64,
+	minBalance string,
 ) ([]*models.User, error) {
 
-	if minBalance < 0 {
-		return nil, fmt.Errorf("minBalance mora biti >= 0")
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return nil, err
 	}
 
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"docType": "user",
-			"balance": map[string]interface{}{
-				"$gte": minBalance,
-			},
-		},
-		"sort": []map[string]string{
-			{"balance": "desc"},
-		},
+	query, err := minBalanceQuery(minBalance)
+	if err != nil {
+		return nil, err
 	}
 
-	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetUsersWithMinBalance query failed: %v", err)
 	}
@@ -229,6 +401,30 @@ func (t *TradingContract) GetUsersWithMinBalance(
 	return users, nil
 }
 
+// minBalanceQuery builds the CouchDB query of GetUsersWithMinBalance.
+func minBalanceQuery(minBalance string) (string, error) {
+	min, err := money.Parse(minBalance)
+	if err != nil || min.Amount < 0 {
+		return "", fmt.Errorf("minBalance mora biti iznos >= 0")
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":          "user",
+			"balance.currency": min.Currency,
+			"balance.amount": map[string]interface{}{
+				"$gte": min.Amount,
+			},
+		},
+		"sort": []map[string]string{
+			{"balance.amount": "desc"},
+		},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes), nil
+}
+
 // -------------------------------------------------------------------------------
 // RICH QUERY 3 – Fakture u zadatom vremenskom periodu za određenog korisnika
 //
@@ -238,7 +434,7 @@ func (t *TradingContract) GetUsersWithMinBalance(
 // CouchDB to rešava jednim Mango selektorom bez potrebe za JOIN-om.
 //
 // LevelDB ekvivalent:
-//   1. GetStateByRange("INVOICE_", "INVOICE_~") – dohvati SVE fakture
+//   1. GetStateByPartialCompositeKey("invoice", {}) – dohvati SVE fakture
 //   2. Za svaku: unmarshaling + provera userID == zadati AND date u opsegu
 //   3. O(n) gde je n ukupan broj faktura u sistemu, bez obzira na filtar
 //   4. Posebno skupo ako ima hiljada faktura, a traži se samo jedna od 10.
@@ -253,26 +449,16 @@ func (t *TradingContract) GetInvoicesByUserAndDateRange(
 	toDate string,
 ) ([]*models.Invoice, error) {
 
-	if userID == "" || fromDate == "" || toDate == "" {
-		return nil, fmt.Errorf("userID, fromDate i toDate su obavezni")
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
 	}
 
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"docType": "invoice",
-			"userId":  userID,
-			"date": map[string]interface{}{
-				"$gte": fromDate,
-				"$lte": toDate,
-			},
-		},
-		"sort": []map[string]string{
-			{"date": "desc"},
-		},
+	query, err := userDateRangeQuery(userID, fromDate, toDate)
+	if err != nil {
+		return nil, err
 	}
 
-	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetInvoicesByUserAndDateRange query failed: %v", err)
 	}
@@ -290,6 +476,30 @@ func (t *TradingContract) GetInvoicesByUserAndDateRange(
 	return invoices, nil
 }
 
+// userDateRangeQuery builds the CouchDB query of GetInvoicesByUserAndDateRange.
+func userDateRangeQuery(userID, fromDate, toDate string) (string, error) {
+	if userID == "" || fromDate == "" || toDate == "" {
+		return "", fmt.Errorf("userID, fromDate i toDate su obavezni")
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType": "invoice",
+			"userId":  userID,
+			"date": map[string]interface{}{
+				"$gte": fromDate,
+				"$lte": toDate,
+			},
+		},
+		"sort": []map[string]string{
+			{"date": "desc"},
+		},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes), nil
+}
+
 // -------------------------------------------------------------------------------
 // RICH QUERY 4 – Merchants sa niskim stanjem zaliha (low stock alert)
 //
@@ -300,7 +510,7 @@ func (t *TradingContract) GetInvoicesByUserAndDateRange(
 // Posebno korisno za business logiku: "upozori me kad nešto nestaje".
 //
 // LevelDB ekvivalent:
-//   1. GetStateByRange("PRODUCT_", "PRODUCT_~") – skeniranje svih proizvoda
+//   1. GetStateByPartialCompositeKey("product", {}) – skeniranje svih proizvoda
 //   2. Ručna filtracija: if p.MerchantType == zadati AND p.Quantity <= maxQty
 //   3. Ista O(n) složenost bez obzira na broj pogodaka
 // -------------------------------------------------------------------------------
@@ -314,11 +524,40 @@ func (t *TradingContract) GetLowStockProducts(
 	maxQuantity int,
 ) ([]*models.Product, error) {
 
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant); err != nil {
+		return nil, err
+	}
+
+	query, err := lowStockQuery(merchantType, maxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetLowStockProducts query failed: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var products []*models.Product
+	for resultsIterator.HasNext() {
+		kv, _ := resultsIterator.Next()
+		var p models.Product
+		if err := json.Unmarshal(kv.Value, &p); err == nil {
+			products = append(products, &p)
+		}
+	}
+
+	return products, nil
+}
+
+// lowStockQuery builds the CouchDB query of GetLowStockProducts.
+func lowStockQuery(merchantType string, maxQuantity int) (string, error) {
 	if merchantType == "" {
-		return nil, fmt.Errorf("merchantType je obavezan")
+		return "", fmt.Errorf("merchantType je obavezan")
 	}
 	if maxQuantity < 0 {
-		return nil, fmt.Errorf("maxQuantity mora biti >= 0")
+		return "", fmt.Errorf("maxQuantity mora biti >= 0")
 	}
 
 	query := map[string]interface{}{
@@ -336,22 +575,7 @@ func (t *TradingContract) GetLowStockProducts(
 	}
 
 	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
-	if err != nil {
-		return nil, fmt.Errorf("GetLowStockProducts query failed: %v", err)
-	}
-	defer resultsIterator.Close()
-
-	var products []*models.Product
-	for resultsIterator.HasNext() {
-		kv, _ := resultsIterator.Next()
-		var p models.Product
-		if err := json.Unmarshal(kv.Value, &p); err == nil {
-			products = append(products, &p)
-		}
-	}
-
-	return products, nil
+	return string(queryBytes), nil
 }
 
 // -------------------------------------------------------------------------------
@@ -366,7 +590,7 @@ func (t *TradingContract) GetLowStockProducts(
 // koja se čuva u dokumentu – CouchDB može da indeksira i tu vrednost.
 //
 // LevelDB ekvivalent:
-//   1. GetStateByRange("INVOICE_", "INVOICE_~")
+//   1. GetStateByPartialCompositeKey("invoice", {})
 //   2. Ručno filtrirati: inv.MerchantID == zadati AND inv.TotalPrice >= min
 //   3. sort.Slice po TotalPrice desc
 //   4. Svaka nova faktura ne menja efikasnost – uvek O(n) skeniranje
@@ -377,31 +601,19 @@ func (t *TradingContract) GetLowStockProducts(
 func (t *TradingContract) GetMerchantHighValueInvoices(
 	ctx contractapi.TransactionContextInterface,
 	merchantID string,
-	minTotalPrice float64,
+	minTotalPrice string,
 ) ([]*models.Invoice, error) {
 
-	if merchantID == "" {
-		return nil, fmt.Errorf("merchantID je obavezan")
-	}
-	if minTotalPrice < 0 {
-		return nil, fmt.Errorf("minTotalPrice mora biti >= 0")
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
 	}
 
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"docType":    "invoice",
-			"merchantId": merchantID,
-			"totalPrice": map[string]interface{}{
-				"$gte": minTotalPrice,
-			},
-		},
-		"sort": []map[string]string{
-			{"totalPrice": "desc"},
-		},
+	query, err := highValueInvoicesQuery(merchantID, minTotalPrice)
+	if err != nil {
+		return nil, err
 	}
 
-	queryBytes, _ := json.Marshal(query)
-	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	resultsIterator, err := ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, fmt.Errorf("GetMerchantHighValueInvoices query failed: %v", err)
 	}
@@ -418,3 +630,32 @@ func (t *TradingContract) GetMerchantHighValueInvoices(
 
 	return invoices, nil
 }
+
+// highValueInvoicesQuery builds the CouchDB query of
+// GetMerchantHighValueInvoices.
+func highValueInvoicesQuery(merchantID, minTotalPrice string) (string, error) {
+	if merchantID == "" {
+		return "", fmt.Errorf("merchantID je obavezan")
+	}
+	min, err := money.Parse(minTotalPrice)
+	if err != nil || min.Amount < 0 {
+		return "", fmt.Errorf("minTotalPrice mora biti iznos >= 0")
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":             "invoice",
+			"merchantId":          merchantID,
+			"totalPrice.currency": min.Currency,
+			"totalPrice.amount": map[string]interface{}{
+				"$gte": min.Amount,
+			},
+		},
+		"sort": []map[string]string{
+			{"totalPrice.amount": "desc"},
+		},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes), nil
+}
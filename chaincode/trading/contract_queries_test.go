@@ -0,0 +1,98 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProductFilterPrices(t *testing.T) {
+	tests := []struct {
+		in           string
+		wantMin      string
+		wantMax      string
+		wantMerchant string
+		wantErr      bool
+	}{
+		{in: `{"priceMin":"10","priceMax":"99.90"}`, wantMin: "10", wantMax: "99.90"},
+		{in: `{"priceMin":10,"priceMax":99.9}`, wantMin: "10", wantMax: "99.9"},
+		{in: `{"priceMax":"120.50 RSD","merchantType":"supermarket"}`, wantMax: "120.50 RSD", wantMerchant: "supermarket"},
+		{in: `{"priceMin":null}`},
+		{in: `{"priceMin":true}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var filter ProductFilter
+		err := json.Unmarshal([]byte(tt.in), &filter)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got %+v, want an error", tt.in, filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if filter.PriceMin != tt.wantMin || filter.PriceMax != tt.wantMax || filter.MerchantType != tt.wantMerchant {
+			t.Errorf("%s: got %+v", tt.in, filter)
+		}
+	}
+}
+
+func TestExpiredProducts(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.admin, "add-bad-expirations")
+	for _, expiration := range []string{"2026-10-16T23:59:59Z", "31.12.2027"} {
+		err := c.AddProducts(l.ctx, "MERCHANT1", []models.ProductInput{{ID: "PROD7", Name: "Jogurt", Expiration: expiration, Price: "90", Quantity: 1}})
+		if !errors.Is(err, services.ErrInvalidInput) {
+			t.Errorf("expiration %q: got %v, want ErrInvalidInput", expiration, err)
+		}
+	}
+
+	// Products stored before expirations were validated may hold a bare
+	// date, which lasts until the end of that day.
+	for id, expiration := range map[string]string{"PROD8": "2026-10-28", "PROD9": "2026-10-29"} {
+		putRaw(t, l.stub, models.DocTypeProduct, id,
+			`{"docType":"product","id":"`+id+`","name":"Ulje","expiration":"`+expiration+`","price":{"amount":9000,"currency":"RSD"},"quantity":3,"merchantId":"MERCHANT2","merchantType":"auto_parts"}`)
+		if err := putIndex(l.ctx, indexMerchantProduct, "MERCHANT2", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// PROD3 expires 10 days and PROD4 14 days after InitLedger.
+	l.stub.ts = l.stub.ts.AddDate(0, 0, 12)
+
+	l.as(l.user1, "buy-expired")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD3", 1, models.PurchaseOptions{}); !errors.Is(err, services.ErrProductExpired) {
+		t.Errorf("buying PROD3: got %v, want ErrProductExpired", err)
+	}
+	l.as(l.user1, "buy-fresh")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD4", 1, models.PurchaseOptions{}); err != nil {
+		t.Errorf("buying PROD4: %v", err)
+	}
+
+	l.as(l.merchant2, "list-expired")
+	expired, err := c.GetExpiredProducts(l.ctx, "MERCHANT2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range expired {
+		ids = append(ids, p.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"PROD3", "PROD8"}; !slices.Equal(ids, want) {
+		t.Errorf("expired products = %v, want %v", ids, want)
+	}
+
+	l.as(l.merchant1, "list-other-expired")
+	if _, err := c.GetExpiredProducts(l.ctx, "MERCHANT2"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another merchant: got %v, want ErrAccessDenied", err)
+	}
+}
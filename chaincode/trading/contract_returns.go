@@ -0,0 +1,272 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// RequestReturn lets the buyer ask to return quantity units of productID from
+// one of their invoices. Nothing moves until the merchant approves it.
+func (t *TradingContract) RequestReturn(ctx contractapi.TransactionContextInterface,
+	returnID, invoiceID, productID string, quantity int) (*models.ReturnRequest, error) {
+
+	caller, err := requireRole(ctx, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeReturn, returnID); err != nil {
+		return nil, err
+	}
+
+	invoice, err := getInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, invoice.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := requireOwner(caller, user.Owner); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := services.RequestReturn(clock, invoice, returnID, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, invoice, models.DocTypeInvoice, invoice.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, ret, models.DocTypeReturn, ret.ID); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, models.EventReturnRequested, models.ReturnRequestedEvent{
+		ReturnID:   ret.ID,
+		InvoiceID:  ret.InvoiceID,
+		UserID:     ret.UserID,
+		MerchantID: ret.MerchantID,
+		ProductID:  ret.ProductID,
+		Quantity:   ret.Quantity,
+		Amount:     ret.Amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// ApproveReturn is called by the selling merchant. It restocks the product,
+// refunds the buyer from the merchant balance, or in loyalty points for the
+// part points paid, and issues a credit note.
+func (t *TradingContract) ApproveReturn(ctx contractapi.TransactionContextInterface, returnID string) (*models.CreditNote, error) {
+	caller, err := requireRole(ctx, RoleMerchant)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, ret.MerchantID); err != nil {
+		return nil, err
+	}
+
+	if err := requireOwner(caller, merchant.Owner); err != nil {
+		return nil, err
+	}
+
+	invoice, err := getInvoice(ctx, ret.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, ret.UserID); err != nil {
+		return nil, err
+	}
+
+	product, err := restockableProduct(ctx, ret.ProductID, ret.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeCreditNote, "CN-"+ret.ID); err != nil {
+		return nil, err
+	}
+
+	loyalty, err := invoiceLoyalty(ctx, invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := services.ApproveReturn(clock, ret, invoice, product, &merchant, &user, loyalty, "CN-"+ret.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product != nil {
+		if err := putEntity(ctx, product, models.DocTypeProduct, product.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, invoice, models.DocTypeInvoice, invoice.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, ret, models.DocTypeReturn, ret.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, note, models.DocTypeCreditNote, note.ID); err != nil {
+		return nil, err
+	}
+	if err := putLoyalty(ctx, loyalty); err != nil {
+		return nil, err
+	}
+	sales, err := services.ReturnedSales(invoice, note)
+	if err != nil {
+		return nil, err
+	}
+	if err := addSales(ctx, sales); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, models.EventReturnResolved, returnResolvedEvent(ret)); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// RejectReturn is called by the selling merchant to decline a return.
+func (t *TradingContract) RejectReturn(ctx contractapi.TransactionContextInterface, returnID string) error {
+	caller, err := requireRole(ctx, RoleMerchant)
+	if err != nil {
+		return err
+	}
+
+	ret, err := getReturn(ctx, returnID)
+	if err != nil {
+		return err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, ret.MerchantID); err != nil {
+		return err
+	}
+
+	if err := requireOwner(caller, merchant.Owner); err != nil {
+		return err
+	}
+
+	invoice, err := getInvoice(ctx, ret.InvoiceID)
+	if err != nil {
+		return err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := services.RejectReturn(clock, ret, invoice); err != nil {
+		return err
+	}
+
+	if err := putEntity(ctx, invoice, models.DocTypeInvoice, invoice.ID); err != nil {
+		return err
+	}
+	if err := putEntity(ctx, ret, models.DocTypeReturn, ret.ID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventReturnResolved, returnResolvedEvent(ret))
+}
+
+func returnResolvedEvent(ret *models.ReturnRequest) models.ReturnResolvedEvent {
+	return models.ReturnResolvedEvent{
+		ReturnID:     ret.ID,
+		Status:       ret.Status,
+		CreditNoteID: ret.CreditNoteID,
+		Amount:       ret.Amount,
+	}
+}
+
+// GetReturn returns a return request to its buyer, its merchant or an admin.
+func (t *TradingContract) GetReturn(ctx contractapi.TransactionContextInterface, returnID string) (*models.ReturnRequest, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !caller.IsAdmin() && !caller.actsAs(RoleUser, ret.UserID) && !caller.actsAs(RoleMerchant, ret.MerchantID) {
+		return nil, accessDenied(caller, "return %s belongs to another user or merchant", returnID)
+	}
+
+	return ret, nil
+}
+
+func getReturn(ctx contractapi.TransactionContextInterface, returnID string) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	if err := getEntity(ctx, &ret, models.DocTypeReturn, returnID); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func getInvoice(ctx contractapi.TransactionContextInterface, invoiceID string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := getEntity(ctx, &invoice, models.DocTypeInvoice, invoiceID); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// restockableProduct returns the product that units of productID sold by
+// merchantID go back to, or nil when the merchant has deleted it or the ID
+// now names another merchant's product. Such units are refunded but not
+// restocked.
+func restockableProduct(ctx contractapi.TransactionContextInterface, productID, merchantID string) (*models.Product, error) {
+	var product models.Product
+	err := getEntity(ctx, &product, models.DocTypeProduct, productID)
+	if err == services.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if product.MerchantID != merchantID {
+		return nil, nil
+	}
+
+	return &product, nil
+}
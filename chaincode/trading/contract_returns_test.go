@@ -0,0 +1,72 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"testing"
+)
+
+func TestApproveReturnRestocksOnlyTheSellersProduct(t *testing.T) {
+	tests := []struct {
+		name string
+		// replace, when set, is what PROD1 names by the time of the return.
+		replace   *models.Product
+		wantStock int
+	}{
+		{name: "same product", wantStock: 10},
+		{
+			name:      "ID taken over by another merchant",
+			replace:   &models.Product{DocType: models.DocTypeProduct, ID: "PROD1", Name: "Antifriz", Price: money.New(90000, "RSD"), Quantity: 4, MerchantID: "MERCHANT2"},
+			wantStock: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLedger(t)
+
+			l.as(l.user1, "buy")
+			if _, err := l.contract.Purchase(l.ctx, "USER1", "PROD1", 2, models.PurchaseOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			l.as(l.user1, "request-return")
+			if _, err := l.contract.RequestReturn(l.ctx, "RET1", "INV-buy", "PROD1", 2); err != nil {
+				t.Fatal(err)
+			}
+			if tt.replace != nil {
+				putRaw(t, l.stub, models.DocTypeProduct, "PROD1", string(mustMarshal(tt.replace)))
+			}
+
+			l.as(l.merchant1, "approve-return")
+			note, err := l.contract.ApproveReturn(l.ctx, "RET1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := money.New(10000, "RSD"); note.Amount != want {
+				t.Errorf("refund = %v, want %v", note.Amount, want)
+			}
+
+			var product models.Product
+			if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD1"); err != nil {
+				t.Fatal(err)
+			}
+			if product.Quantity != tt.wantStock {
+				t.Errorf("PROD1 of %s has %d units, want %d", product.MerchantID, product.Quantity, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestRequestReturnOfLegacyInvoice(t *testing.T) {
+	l := newTestLedger(t)
+	putRaw(t, l.stub, models.DocTypeInvoice, "INV0", `{"docType":"invoice","id":"INV0","merchantId":"MERCHANT1","userId":"USER1","productId":"PROD2","quantity":3,"totalPrice":60,"date":"2026-01-02T10:00:00Z"}`)
+
+	l.as(l.user1, "request-return")
+	ret, err := l.contract.RequestReturn(l.ctx, "RET1", "INV0", "PROD2", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := money.New(4000, "RSD"); ret.Amount != want {
+		t.Errorf("return amount = %v, want %v", ret.Amount, want)
+	}
+}
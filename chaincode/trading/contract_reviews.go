@@ -0,0 +1,165 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Sort orders of GetProductReviewsPaginated, each backed by a CouchDB index
+// in META-INF/statedb/couchdb/indexes.
+const (
+	reviewSortDate  = "date"
+	reviewSortScore = "score"
+)
+
+// ReviewPurchase lets the buyer rate productID from one of their invoices
+// with a score of 1 to 5 and an optional text. Each invoice can be reviewed
+// once; the score is added to the ratings of the product and its merchant.
+func (t *TradingContract) ReviewPurchase(ctx contractapi.TransactionContextInterface,
+	invoiceID, productID string, score int, text string) (*models.Review, error) {
+
+	caller, err := requireRole(ctx, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := getInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := getEntity(ctx, &user, models.DocTypeUser, invoice.UserID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, user.Owner); err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeReview, invoice.ID); err != nil {
+		return nil, err
+	}
+
+	var product models.Product
+	if err := getEntity(ctx, &product, models.DocTypeProduct, productID); err != nil {
+		return nil, err
+	}
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, invoice.MerchantID); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := services.ReviewPurchase(clock, invoice, &product, &merchant, score, text)
+	if err != nil {
+		return nil, err
+	}
+	review.TxID = ctx.GetStub().GetTxID()
+
+	if err := putEntity(ctx, &product, models.DocTypeProduct, product.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, &merchant, models.DocTypeMerchant, merchant.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, review, models.DocTypeReview, review.InvoiceID); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, models.EventReviewSubmitted, models.ReviewSubmittedEvent{
+		ReviewID:       review.ID,
+		ProductID:      review.ProductID,
+		MerchantID:     review.MerchantID,
+		Score:          review.Score,
+		ProductRating:  *product.Rating,
+		MerchantRating: *merchant.Rating,
+	}); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetProductReviewsPaginated lists the reviews of a product, newest first
+// (sortBy "date") or best first (sortBy "score", newest first among equal
+// scores).
+func (t *TradingContract) GetProductReviewsPaginated(ctx contractapi.TransactionContextInterface,
+	productID, sortBy string, pageSize int32, bookmark string) (*models.ReviewPage, error) {
+
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	query, err := productReviewsQuery(productID, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("review query failed: %v", err)
+	}
+
+	page := &models.ReviewPage{Records: []*models.Review{}}
+	page.PageInfo, err = readPage(resultsIterator, metadata, func(value []byte) error {
+		var r models.Review
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		page.Records = append(page.Records, &r)
+		return nil
+	})
+
+	return page, err
+}
+
+// productReviewsQuery builds the CouchDB query of GetProductReviewsPaginated.
+// CouchDB only sorts on an index whose fields match the sort, in one
+// direction, so the equality fields are sorted on too.
+func productReviewsQuery(productID, sortBy string) (string, error) {
+	var (
+		sortFields []string
+		index      string
+	)
+	switch sortBy {
+	case reviewSortDate:
+		sortFields, index = []string{"docType", "productId", "date"}, "indexReviewProductDate"
+	case reviewSortScore:
+		sortFields, index = []string{"docType", "productId", "score", "date"}, "indexReviewProductScore"
+	default:
+		return "", &services.ValidationError{Fields: []services.FieldError{
+			{Field: "sortBy", Message: fmt.Sprintf("must be %q or %q", reviewSortDate, reviewSortScore)},
+		}}
+	}
+	if productID == "" {
+		return "", &services.ValidationError{Fields: []services.FieldError{{Field: "productId", Message: "is required"}}}
+	}
+
+	sort := make([]map[string]string, 0, len(sortFields))
+	for _, field := range sortFields {
+		sort = append(sort, map[string]string{field: "desc"})
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":   models.DocTypeReview,
+			"productId": productID,
+		},
+		"sort":      sort,
+		"use_index": []string{"_design/" + index, index},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes), nil
+}
@@ -0,0 +1,118 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReviewOncePerInvoice(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	lines := []models.CartLine{{ProductID: "PROD1", Quantity: 1}, {ProductID: "PROD2", Quantity: 1}}
+
+	l.as(l.user1, "buy-groceries")
+	invoices, err := c.PurchaseCart(l.ctx, "USER1", lines, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoiceID := invoices[0].ID
+
+	l.as(l.merchant1, "merchant-reviews")
+	if _, err := c.ReviewPurchase(l.ctx, invoiceID, "PROD1", 5, ""); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("review by the merchant: %v, want ErrAccessDenied", err)
+	}
+
+	l.as(l.user1, "review-bad")
+	for _, tt := range []struct {
+		productID string
+		score     int
+	}{
+		{"PROD1", 0},
+		{"PROD1", 6},
+		{"PROD3", 4},
+	} {
+		if _, err := c.ReviewPurchase(l.ctx, invoiceID, tt.productID, tt.score, ""); !errors.Is(err, services.ErrInvalidInput) {
+			t.Errorf("review of %s with score %d: %v, want ErrInvalidInput", tt.productID, tt.score, err)
+		}
+	}
+
+	l.as(l.user1, "review")
+	review, err := c.ReviewPurchase(l.ctx, invoiceID, "PROD1", 4, "Sveže.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.ID != invoiceID || review.ProductID != "PROD1" || review.MerchantID != "MERCHANT1" || review.TxID != "review" {
+		t.Errorf("review = %+v", review)
+	}
+	if _, ok := l.stub.events[string(models.EventReviewSubmitted)]; !ok {
+		t.Errorf("no ReviewSubmitted event")
+	}
+
+	for _, productID := range []string{"PROD1", "PROD2"} {
+		l.as(l.user1, "review-again-"+productID)
+		if _, err := c.ReviewPurchase(l.ctx, invoiceID, productID, 5, ""); !errors.Is(err, services.ErrAlreadyExists) {
+			t.Errorf("second review of the invoice, for %s: %v, want ErrAlreadyExists", productID, err)
+		}
+	}
+}
+
+func TestReviewRatings(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	for i, score := range []int{4, 5, 4} {
+		l.as(l.user1, fmt.Sprintf("buy-%d", i))
+		invoice, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.as(l.user1, fmt.Sprintf("review-%d", i))
+		if _, err := c.ReviewPurchase(l.ctx, invoice.ID, "PROD1", score, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.as(l.user1, "buy-bread")
+	invoice, err := c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "review-bread")
+	if _, err := c.ReviewPurchase(l.ctx, invoice.ID, "PROD2", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var product models.Product
+	if err := getEntity(l.ctx, &product, models.DocTypeProduct, "PROD1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.Rating{Count: 3, Total: 13, Average: 4.33}); product.Rating == nil || *product.Rating != want {
+		t.Errorf("PROD1 rating = %+v, want %+v", product.Rating, want)
+	}
+	merchant, err := c.GetMerchantByID(l.ctx, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.Rating{Count: 4, Total: 14, Average: 3.5}); merchant.Rating == nil || *merchant.Rating != want {
+		t.Errorf("MERCHANT1 rating = %+v, want %+v", merchant.Rating, want)
+	}
+
+	l.as(l.user1, "buy-parts")
+	order, err := c.Purchase(l.ctx, "USER1", "PROD4", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "review-pending")
+	if _, err := c.ReviewPurchase(l.ctx, order.ID, "PROD4", 5, ""); !errors.Is(err, services.ErrInvalidState) {
+		t.Errorf("review of an undelivered order: %v, want ErrInvalidState", err)
+	}
+	var parts models.Product
+	if err := getEntity(l.ctx, &parts, models.DocTypeProduct, "PROD4"); err != nil {
+		t.Fatal(err)
+	}
+	if parts.Rating != nil {
+		t.Errorf("PROD4 rating = %+v, want none", parts.Rating)
+	}
+}
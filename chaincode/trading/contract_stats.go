@@ -0,0 +1,159 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// GetMerchantSalesStats returns a merchant's orders, units sold and revenue
+// over all time, to the merchant itself or an admin.
+func (t *TradingContract) GetMerchantSalesStats(ctx contractapi.TransactionContextInterface, merchantID string) (*models.SalesStats, error) {
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, merchantID); err != nil {
+		return nil, err
+	}
+
+	return getSalesStats(ctx, models.DocTypeMerchantStats, merchantID, "", "", merchant.Balance.Currency)
+}
+
+// GetTopSellingProducts returns the sales statistics of a merchant's best
+// selling products, by units sold ("units") or revenue ("revenue"), to the
+// merchant itself or an admin.
+func (t *TradingContract) GetTopSellingProducts(ctx contractapi.TransactionContextInterface,
+	merchantID, sortBy string, limit int) ([]*models.SalesStats, error) {
+
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeProductStats), []string{merchantID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	stats := make([]*models.SalesStats, 0)
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var s models.SalesStats
+		if err := json.Unmarshal(kv.Value, &s); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &s)
+	}
+
+	return services.TopSellers(stats, sortBy, limit)
+}
+
+// GetRevenueSeries returns the daily sales of a merchant, or of one of its
+// products when productID is set, for every UTC day from from to to
+// ("2026-10-17", inclusive). Days without sales are returned empty. Only the
+// merchant itself or an admin may read them.
+func (t *TradingContract) GetRevenueSeries(ctx contractapi.TransactionContextInterface,
+	merchantID, productID, from, to string) ([]*models.SalesStats, error) {
+
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	days, err := services.SeriesDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var merchant models.Merchant
+	if err := getEntity(ctx, &merchant, models.DocTypeMerchant, merchantID); err != nil {
+		return nil, err
+	}
+
+	docType := models.DocTypeMerchantDaily
+	if productID != "" {
+		docType = models.DocTypeProductDaily
+	}
+
+	series := make([]*models.SalesStats, 0, len(days))
+	for _, day := range days {
+		s, err := getSalesStats(ctx, docType, merchantID, productID, day, merchant.Balance.Currency)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+// recordSales adds the invoices of a purchase to the sales statistics of
+// their merchants and products. A cart holds each product once and splits
+// into one invoice per merchant, so every statistics key is updated at most
+// once per transaction.
+func recordSales(ctx contractapi.TransactionContextInterface, invoices []*models.Invoice) error {
+	for _, invoice := range invoices {
+		sales, err := services.InvoiceSales(invoice)
+		if err != nil {
+			return err
+		}
+		if err := addSales(ctx, sales); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addSales adds each of sales to the statistics under its key. No two of
+// sales may share a key, since a transaction does not read its own writes.
+func addSales(ctx contractapi.TransactionContextInterface, sales []*models.SalesStats) error {
+	for _, sale := range sales {
+		stats, err := getSalesStats(ctx, sale.DocType, sale.MerchantID, sale.ProductID, sale.Day, sale.Revenue.Currency)
+		if err != nil {
+			return err
+		}
+		if err := services.AddSales(stats, sale); err != nil {
+			return err
+		}
+		if err := putEntity(ctx, stats, stats.DocType, salesStatsKey(stats)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSalesStats reads the statistics of docType, or returns empty ones in
+// currency when nothing was sold yet.
+func getSalesStats(ctx contractapi.TransactionContextInterface, docType models.DocType, merchantID, productID, day, currency string) (*models.SalesStats, error) {
+	stats := services.NewSalesStats(docType, merchantID, productID, day, currency)
+	err := getEntity(ctx, stats, docType, salesStatsKey(stats)...)
+	if err != nil && err != services.ErrNotFound {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// salesStatsKey returns the key attributes of stats: the merchant, then the
+// product and day when set. Product keys start with the merchant so that its
+// products can be listed by partial key.
+func salesStatsKey(stats *models.SalesStats) []string {
+	attrs := []string{stats.MerchantID}
+	if stats.ProductID != "" {
+		attrs = append(attrs, stats.ProductID)
+	}
+	if stats.Day != "" {
+		attrs = append(attrs, stats.Day)
+	}
+
+	return attrs
+}
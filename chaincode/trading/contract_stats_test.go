@@ -0,0 +1,150 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"chaincode/trading/services"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// salesStatsKeys lists the keys of every sales statistics document.
+func salesStatsKeys(stub *fakeStub) []string {
+	var keys []string
+	for key := range stub.state {
+		objectType, _, err := stub.SplitCompositeKey(key)
+		if err != nil {
+			continue
+		}
+		switch models.DocType(objectType) {
+		case models.DocTypeMerchantStats, models.DocTypeMerchantDaily, models.DocTypeProductStats, models.DocTypeProductDaily:
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestSalesStats(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy")
+	lines := []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD2", Quantity: 1}}
+	if _, err := c.PurchaseCart(l.ctx, "USER1", lines, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "buy-more")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "request-return")
+	if _, err := c.RequestReturn(l.ctx, "RET1", "INV-buy", "PROD1", 2); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.merchant1, "approve-return")
+	if _, err := c.ApproveReturn(l.ctx, "RET1"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.as(l.user1, "user-reads")
+	if _, err := c.GetMerchantSalesStats(l.ctx, "MERCHANT1"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("stats read by a user: %v, want ErrAccessDenied", err)
+	}
+
+	l.as(l.merchant1, "stats")
+	stats, err := c.GetMerchantSalesStats(l.ctx, "MERCHANT1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Orders != 2 || stats.UnitsSold != 2 || stats.Revenue.Amount != 7000 {
+		t.Errorf("MERCHANT1 stats = %d orders, %d units, %v; want 2, 2 and 70.00", stats.Orders, stats.UnitsSold, stats.Revenue)
+	}
+
+	top, err := c.GetTopSellingProducts(l.ctx, "MERCHANT1", "revenue", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].ProductID != "PROD1" || top[0].UnitsSold != 1 || top[0].Revenue.Amount != 5000 {
+		t.Errorf("top seller = %+v, want PROD1 with 1 unit for 50.00", top)
+	}
+
+	day := l.stub.ts.Format("2006-01-02")
+	series, err := c.GetRevenueSeries(l.ctx, "MERCHANT1", "PROD2", "2026-10-16", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0].UnitsSold != 0 || series[1].UnitsSold != 1 || series[1].Revenue.Amount != 2000 {
+		t.Errorf("PROD2 series = %+v, want an empty day and 1 unit for 20.00", series)
+	}
+}
+
+func TestMigrateSalesStats(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy")
+	lines := []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD4", Quantity: 1}}
+	if _, err := c.PurchaseCart(l.ctx, "USER1", lines, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "buy-parts")
+	order, err := c.Purchase(l.ctx, "USER1", "PROD3", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.user1, "cancel")
+	if _, err := c.CancelOrder(l.ctx, order.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := salesStatsKeys(l.stub)
+	want := make(map[string]string, len(keys))
+	for _, key := range keys {
+		want[key] = string(l.stub.state[key])
+	}
+
+	// Statistics gone wrong, and one of a product nothing was sold of.
+	for _, key := range keys {
+		var s models.SalesStats
+		if err := json.Unmarshal(l.stub.state[key], &s); err != nil {
+			t.Fatal(err)
+		}
+		s.Orders, s.UnitsSold = s.Orders+5, s.UnitsSold+5
+		l.stub.state[key] = mustMarshal(s)
+	}
+	stale := models.SalesStats{DocType: models.DocTypeProductStats, MerchantID: "MERCHANT1", ProductID: "PROD2", Orders: 3, UnitsSold: 3, Revenue: money.New(6000, "RSD")}
+	staleKey, err := ledgerKey(l.ctx, models.DocTypeProductStats, "MERCHANT1", "PROD2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.stub.state[staleKey] = mustMarshal(stale)
+
+	l.as(l.admin, "migrate-stats")
+	migrated, err := c.MigrateSalesStats(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != len(keys)+1 {
+		t.Errorf("migrated %d statistics, want %d", migrated, len(keys)+1)
+	}
+	if event := migrationEvent(t, l.stub); event.Migration != "MigrateSalesStats" || event.Count != migrated {
+		t.Errorf("event = %+v, want MigrateSalesStats with count %d", event, migrated)
+	}
+
+	for _, key := range keys {
+		if got := string(l.stub.state[key]); got != want[key] {
+			t.Errorf("rebuilt %s, want %s as kept by purchases", got, want[key])
+		}
+	}
+
+	var reset models.SalesStats
+	if err := json.Unmarshal(l.stub.state[staleKey], &reset); err != nil {
+		t.Fatal(err)
+	}
+	if reset.Orders != 0 || reset.UnitsSold != 0 || reset.Revenue.Amount != 0 {
+		t.Errorf("stale PROD2 stats = %+v, want them reset", reset)
+	}
+}
@@ -0,0 +1,204 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AddMerchantType adds a merchant type CreateMerchant and SetVATRate accept.
+// id is a lower-case slug such as "auto_parts". Admin only.
+func (t *TradingContract) AddMerchantType(ctx contractapi.TransactionContextInterface, id, name string) (*models.MerchantType, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	merchantType, err := services.NewMerchantType(id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeMerchantType, merchantType.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, merchantType, models.DocTypeMerchantType, merchantType.ID); err != nil {
+		return nil, err
+	}
+
+	return merchantType, emitEvent(ctx, models.EventTaxonomyChanged, models.TaxonomyChangedEvent{
+		Kind: models.DocTypeMerchantType,
+		ID:   merchantType.ID,
+	})
+}
+
+// AddCategory adds a product category, under parentID or at the top level
+// when parentID is empty. Admin only.
+func (t *TradingContract) AddCategory(ctx contractapi.TransactionContextInterface, id, name, parentID string) (*models.Category, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var parent *models.Category
+	if parentID != "" {
+		var err error
+		if parent, err = getCategory(ctx, parentID); err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, &services.ValidationError{Fields: []services.FieldError{{Field: "parentId", Message: fmt.Sprintf("unknown category %q", parentID)}}}
+		}
+	}
+
+	category, err := services.NewCategory(id, name, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeCategory, category.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, category, models.DocTypeCategory, category.ID); err != nil {
+		return nil, err
+	}
+
+	return category, emitEvent(ctx, models.EventTaxonomyChanged, models.TaxonomyChangedEvent{
+		Kind:     models.DocTypeCategory,
+		ID:       category.ID,
+		ParentID: category.ParentID,
+	})
+}
+
+// GetMerchantTypes lists the merchant types on the ledger by ID.
+func (t *TradingContract) GetMerchantTypes(ctx contractapi.TransactionContextInterface) ([]*models.MerchantType, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	return getMerchantTypes(ctx)
+}
+
+// GetCategories lists the category tree depth first: every category is
+// followed by its subcategories.
+func (t *TradingContract) GetCategories(ctx contractapi.TransactionContextInterface) ([]*models.Category, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeCategory), []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	categories := make([]*models.Category, 0)
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var category models.Category
+		if err := json.Unmarshal(kv.Value, &category); err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+
+	// IDs cannot hold "/", so joined paths sort parents before children.
+	sort.Slice(categories, func(i, j int) bool {
+		return strings.Join(categories[i].Path, "/") < strings.Join(categories[j].Path, "/")
+	})
+
+	return categories, nil
+}
+
+func getMerchantTypes(ctx contractapi.TransactionContextInterface) ([]*models.MerchantType, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeMerchantType), []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	merchantTypes := make([]*models.MerchantType, 0)
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var merchantType models.MerchantType
+		if err := json.Unmarshal(kv.Value, &merchantType); err != nil {
+			return nil, err
+		}
+		merchantTypes = append(merchantTypes, &merchantType)
+	}
+
+	return merchantTypes, nil
+}
+
+// merchantTypeIDs returns the IDs of the merchant types on the ledger, for
+// services that validate a merchant type.
+func merchantTypeIDs(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	merchantTypes, err := getMerchantTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(merchantTypes))
+	for _, merchantType := range merchantTypes {
+		ids = append(ids, merchantType.ID)
+	}
+
+	return ids, nil
+}
+
+// getCategory returns the category with id, or nil if there is none.
+func getCategory(ctx contractapi.TransactionContextInterface, id string) (*models.Category, error) {
+	var category models.Category
+	err := getEntity(ctx, &category, models.DocTypeCategory, id)
+	if err == services.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// putMissingMerchantTypes writes the merchant types in ids that are not on
+// the ledger yet, named after models.MerchantTypeNames or their ID, and
+// returns how many it wrote.
+func putMissingMerchantTypes(ctx contractapi.TransactionContextInterface, ids []string) (int, error) {
+	written := 0
+	for _, id := range ids {
+		var existing models.MerchantType
+		err := getEntity(ctx, &existing, models.DocTypeMerchantType, id)
+		if err == nil {
+			continue
+		}
+		if err != services.ErrNotFound {
+			return written, err
+		}
+
+		name, ok := models.MerchantTypeNames[id]
+		if !ok {
+			name = id
+		}
+		merchantType, err := services.NewMerchantType(id, name)
+		if err != nil {
+			return written, err
+		}
+		if err := putEntity(ctx, merchantType, models.DocTypeMerchantType, merchantType.ID); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, nil
+}
@@ -0,0 +1,185 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAddMerchantType(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.admin, "create-bakery")
+	if err := c.CreateMerchant(l.ctx, "MERCHANT9", "bakery", "101234569"); !errors.Is(err, services.ErrInvalidInput) {
+		t.Fatalf("CreateMerchant with an unknown type: %v, want ErrInvalidInput", err)
+	}
+
+	l.as(l.merchant1, "add-type-merchant")
+	if _, err := c.AddMerchantType(l.ctx, "bakery", "Pekara"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("AddMerchantType by a merchant: %v, want ErrAccessDenied", err)
+	}
+
+	l.as(l.admin, "add-type")
+	if _, err := c.AddMerchantType(l.ctx, "Bakery!", "Pekara"); !errors.Is(err, services.ErrInvalidInput) {
+		t.Errorf("AddMerchantType with a bad ID: %v, want ErrInvalidInput", err)
+	}
+	if _, err := c.AddMerchantType(l.ctx, "bakery", "Pekara"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddMerchantType(l.ctx, "bakery", "Pekara"); !errors.Is(err, services.ErrAlreadyExists) {
+		t.Errorf("second AddMerchantType: %v, want ErrAlreadyExists", err)
+	}
+
+	l.as(l.admin, "create-bakery-again")
+	if err := c.CreateMerchant(l.ctx, "MERCHANT9", "bakery", "101234569"); err != nil {
+		t.Fatalf("CreateMerchant with the added type: %v", err)
+	}
+
+	merchantTypes, err := c.GetMerchantTypes(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, merchantType := range merchantTypes {
+		ids = append(ids, merchantType.ID)
+	}
+	if !slices.Contains(ids, "bakery") || !slices.Contains(ids, models.MerchantTypeAutoParts) {
+		t.Errorf("merchant types = %v, want the built-in ones and bakery", ids)
+	}
+}
+
+func TestCategorySubtree(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.admin, "add-categories")
+	if _, err := c.AddCategory(l.ctx, "cheese", "Sirevi", "nope"); !errors.Is(err, services.ErrInvalidInput) {
+		t.Errorf("AddCategory under an unknown parent: %v, want ErrInvalidInput", err)
+	}
+	cheese, err := c.AddCategory(l.ctx, "cheese", "Sirevi", "dairy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"food", "dairy", "cheese"}; !slices.Equal(cheese.Path, want) {
+		t.Errorf("cheese path = %v, want %v", cheese.Path, want)
+	}
+	if _, err := c.AddCategory(l.ctx, "hard_cheese", "Tvrdi sirevi", "cheese"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddCategory(l.ctx, "parmesan", "Parmezan", "hard_cheese"); !errors.Is(err, services.ErrInvalidInput) {
+		t.Errorf("AddCategory below depth %d: %v, want ErrInvalidInput", models.MaxCategoryDepth, err)
+	}
+
+	categories, err := c.GetCategories(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, category := range categories {
+		order = append(order, category.ID)
+	}
+	if want := []string{"car_parts", "brakes", "filters", "food", "bakery", "dairy", "cheese", "hard_cheese"}; !slices.Equal(order, want) {
+		t.Errorf("GetCategories = %v, want %v", order, want)
+	}
+
+	l.as(l.merchant1, "file-under-cheese")
+	if _, err := c.UpdateProductDetails(l.ctx, "PROD1", models.ProductDetailsInput{Category: "nope"}); !errors.Is(err, services.ErrInvalidInput) {
+		t.Errorf("UpdateProductDetails with an unknown category: %v, want ErrInvalidInput", err)
+	}
+	product, err := c.UpdateProductDetails(l.ctx, "PROD1", models.ProductDetailsInput{Category: "cheese"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"food", "dairy", "cheese"}; product.Category != "cheese" || !slices.Equal(product.CategoryPath, want) {
+		t.Errorf("PROD1 category = %s %v, want cheese %v", product.Category, product.CategoryPath, want)
+	}
+
+	// The fake stub cannot run CouchDB queries, so check the selector and
+	// apply its $elemMatch to the stored products by hand.
+	query, err := productFilterQuery(`{"category":"dairy"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Selector map[string]json.RawMessage `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(parsed.Selector["categoryPath"]); got != `{"$elemMatch":{"$eq":"dairy"}}` {
+		t.Errorf("categoryPath selector = %s", got)
+	}
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"food", []string{"PROD1", "PROD2"}},
+		{"dairy", []string{"PROD1"}},
+		{"cheese", []string{"PROD1"}},
+		{"car_parts", []string{"PROD3", "PROD4"}},
+		{"filters", []string{"PROD4"}},
+		{"hard_cheese", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, id := range []string{"PROD1", "PROD2", "PROD3", "PROD4"} {
+			var p models.Product
+			if err := getEntity(l.ctx, &p, models.DocTypeProduct, id); err != nil {
+				t.Fatal(err)
+			}
+			if slices.Contains(p.CategoryPath, tt.category) {
+				got = append(got, id)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("category %s matches %v, want %v", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestMigrateTaxonomy(t *testing.T) {
+	l := newTestLedger(t)
+
+	for _, id := range models.MerchantTypes {
+		key, err := ledgerKey(l.ctx, models.DocTypeMerchantType, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		delete(l.stub.state, key)
+	}
+	putRaw(t, l.stub, models.DocTypeMerchant, "MERCHANT8",
+		`{"docType":"merchant","id":"MERCHANT8","type":"florist","pib":"101234569","balance":{"amount":0,"currency":"RSD"}}`)
+	putRaw(t, l.stub, models.DocTypeMerchant, "MERCHANT9",
+		`{"docType":"merchant","id":"MERCHANT9","type":"Flower Shop","pib":"101234569","balance":{"amount":0,"currency":"RSD"}}`)
+
+	l.as(l.admin, "migrate-taxonomy")
+	migrated, err := l.contract.MigrateTaxonomy(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := len(models.MerchantTypes) + 1
+	if migrated != want {
+		t.Errorf("migrated %d merchant types, want %d", migrated, want)
+	}
+	if event := migrationEvent(t, l.stub); event.Migration != "MigrateTaxonomy" || event.Count != want {
+		t.Errorf("event = %+v, want MigrateTaxonomy with count %d", event, want)
+	}
+
+	ids, err := merchantTypeIDs(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(ids, "florist") || slices.Contains(ids, "Flower Shop") || len(ids) != want {
+		t.Errorf("merchant types = %v, want the built-in ones and florist", ids)
+	}
+
+	l.as(l.admin, "migrate-taxonomy-again")
+	if migrated, err := l.contract.MigrateTaxonomy(l.ctx); err != nil || migrated != 0 {
+		t.Errorf("second run migrated %d, %v; want 0", migrated, err)
+	}
+}
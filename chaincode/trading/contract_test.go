@@ -0,0 +1,179 @@
+package trading
+
+import (
+	"bytes"
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	// A new certificate of the owner of USER1 has the same attributes but
+	// another subject, so it is a different identity.
+	reissued := identity(t, "Org1MSP", "user1-laptop", "client", map[string]string{roleAttribute: "user", entityIDAttribute: "USER1"})
+
+	l.as(reissued, "buy-with-new-cert")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); !errors.Is(err, services.ErrAccessDenied) {
+		t.Fatalf("new certificate before the transfer: got %v, want ErrAccessDenied", err)
+	}
+
+	l.as(reissued, "whoami")
+	who, err := c.WhoAmI(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		creator    []byte
+		entityType string
+		mspID, id  string
+		wantErr    error
+	}{
+		{name: "by the owner", creator: l.user1, entityType: "user", mspID: who.MSPID, id: who.ID, wantErr: services.ErrAccessDenied},
+		{name: "unknown entity type", creator: l.admin, entityType: "product", mspID: who.MSPID, id: who.ID, wantErr: services.ErrInvalidInput},
+		{name: "empty owner", creator: l.admin, entityType: "user", mspID: who.MSPID, wantErr: services.ErrInvalidInput},
+		{name: "by an admin", creator: l.admin, entityType: "user", mspID: who.MSPID, id: who.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(tt.creator, "transfer")
+			err := c.TransferOwnership(l.ctx, tt.entityType, "USER1", tt.mspID, tt.id)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	l.as(reissued, "buy-with-new-cert-again")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); err != nil {
+		t.Errorf("new certificate after the transfer: %v", err)
+	}
+	l.as(l.user1, "buy-with-old-cert")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("old certificate after the transfer: got %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestCreateUserRecordsCallerAsOwner(t *testing.T) {
+	l := newTestLedger(t)
+	user3 := identity(t, "Org3MSP", "user3", "client", map[string]string{roleAttribute: "user", entityIDAttribute: "USER3"})
+
+	l.as(user3, "create-user3")
+	l.sendUserPII("Ana", "Anić", "ana@example.rs")
+	if err := l.contract.CreateUser(l.ctx, "USER3"); err != nil {
+		t.Fatal(err)
+	}
+	who, err := l.contract.WhoAmI(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := l.contract.GetUserByID(l.ctx, "USER3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Owner != *who {
+		t.Errorf("owner = %+v, want %+v", user.Owner, *who)
+	}
+}
+
+func TestTimestampsComeFromTheTransaction(t *testing.T) {
+	l := newTestLedger(t)
+	created := l.stub.ts
+	l.stub.ts = created.Add(90 * time.Minute)
+
+	l.as(l.user1, "buy")
+	invoice, err := l.contract.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := l.stub.ts.Format(time.RFC3339); invoice.Date != want || invoice.CreatedAt != want {
+		t.Errorf("invoice date %s, created %s; want %s", invoice.Date, invoice.CreatedAt, want)
+	}
+
+	user, err := l.contract.GetUserByID(l.ctx, "USER1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.CreatedAt != created.Format(time.RFC3339) || user.UpdatedAt != l.stub.ts.Format(time.RFC3339) {
+		t.Errorf("user created %s, updated %s; want %s and %s", user.CreatedAt, user.UpdatedAt, created.Format(time.RFC3339), l.stub.ts.Format(time.RFC3339))
+	}
+}
+
+func TestCreateRejectsExistingID(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	key := func(docType models.DocType, id string) string {
+		k, err := ledgerKey(l.ctx, docType, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	// Invoice IDs come from the tx ID, so only a replayed tx ID can collide.
+	l.as(l.user1, "duplicate")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 1, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		creator []byte
+		call    func() error
+		// The error names the record; key is the record that must be kept.
+		wantText string
+		key      string
+	}{
+		{"second InitLedger", l.admin, func() error {
+			l.stub.transient = map[string][]byte{models.PIISaltTransientKey: []byte(testSaltSecret)}
+			return c.InitLedger(l.ctx)
+		}, "ledger already initialized", key(models.DocTypeUser, "USER1")},
+		{"merchant", l.admin, func() error { return c.CreateMerchant(l.ctx, "MERCHANT1", "supermarket", "123456788") }, "merchant MERCHANT1", key(models.DocTypeMerchant, "MERCHANT1")},
+		{"user", l.admin, func() error {
+			l.sendUserPII("Ana", "Anić", "ana@example.rs")
+			return c.CreateUser(l.ctx, "USER1")
+		}, "user USER1", key(models.DocTypeUser, "USER1")},
+		{
+			name:    "product of another merchant",
+			creator: l.merchant2,
+			call: func() error {
+				return c.AddProducts(l.ctx, "MERCHANT2", []models.ProductInput{{ID: "PROD1", Name: "Antifriz", Price: "900.00", Quantity: 4}})
+			},
+			wantText: "already belongs to merchant MERCHANT1",
+			key:      key(models.DocTypeProduct, "PROD1"),
+		},
+		{
+			name:    "product twice in one batch",
+			creator: l.merchant2,
+			call: func() error {
+				return c.AddProducts(l.ctx, "MERCHANT2", []models.ProductInput{
+					{ID: "PROD9", Name: "Antifriz", Price: "900.00", Quantity: 4},
+					{ID: "PROD9", Name: "Ulje", Price: "1200.00", Quantity: 2},
+				})
+			},
+			wantText: "listed more than once",
+		},
+		{"invoice", l.user1, func() error { _, err := c.Purchase(l.ctx, "USER1", "PROD2", 1, models.PurchaseOptions{}); return err }, "invoice INV-duplicate", key(models.DocTypeInvoice, "INV-duplicate")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := l.stub.state[tt.key]
+			l.as(tt.creator, "duplicate")
+
+			err := tt.call()
+			if !errors.Is(err, services.ErrAlreadyExists) || !strings.Contains(err.Error(), tt.wantText) {
+				t.Fatalf("got %v, want ErrAlreadyExists naming %q", err, tt.wantText)
+			}
+			if !bytes.Equal(l.stub.state[tt.key], before) {
+				t.Errorf("a rejected create overwrote %s", tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Transfer sends amount from one user's balance to another's, with an
+// optional memo. Both balances change in the same transaction. Only the
+// owner of the sending user may initiate it.
+func (t *TradingContract) Transfer(ctx contractapi.TransactionContextInterface,
+	fromUserID, toUserID, amountStr, memo string) (*models.Transfer, error) {
+
+	caller, err := requireRole(ctx, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := services.ParseAmount(amountStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to models.User
+	if err := getEntity(ctx, &from, models.DocTypeUser, fromUserID); err != nil {
+		return nil, err
+	}
+	if err := requireOwner(caller, from.Owner); err != nil {
+		return nil, err
+	}
+	if err := getEntity(ctx, &to, models.DocTypeUser, toUserID); err != nil {
+		return nil, err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	transfer, err := services.Transfer(clock, &from, &to, amount, "TRF-"+txID, memo)
+	if err != nil {
+		return nil, err
+	}
+	transfer.TxID = txID
+
+	if err := requireAbsent(ctx, models.DocTypeTransfer, transfer.ID); err != nil {
+		return nil, err
+	}
+
+	if err := putEntity(ctx, &from, models.DocTypeUser, from.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, &to, models.DocTypeUser, to.ID); err != nil {
+		return nil, err
+	}
+	if err := putEntity(ctx, transfer, models.DocTypeTransfer, transfer.ID); err != nil {
+		return nil, err
+	}
+	for _, userID := range []string{from.ID, to.ID} {
+		if err := putIndex(ctx, indexUserTransfer, userID, transfer.Date, transfer.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := emitEvent(ctx, models.EventTransferCompleted, models.TransferCompletedEvent{
+		TransferID: transfer.ID,
+		FromUserID: transfer.FromUserID,
+		ToUserID:   transfer.ToUserID,
+		Amount:     transfer.Amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// GetTransfer returns a transfer to its sender, its recipient or an admin.
+func (t *TradingContract) GetTransfer(ctx contractapi.TransactionContextInterface, id string) (*models.Transfer, error) {
+	caller, err := requireRole(ctx, RoleAdmin, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer models.Transfer
+	if err := getEntity(ctx, &transfer, models.DocTypeTransfer, id); err != nil {
+		return nil, err
+	}
+
+	if !caller.IsAdmin() && !caller.actsAs(RoleUser, transfer.FromUserID) && !caller.actsAs(RoleUser, transfer.ToUserID) {
+		return nil, accessDenied(caller, "transfer %s is not yours", id)
+	}
+
+	return &transfer, nil
+}
+
+// GetTransfersByUser returns the transfers a user sent or received, oldest
+// first, to the user itself or an admin.
+func (t *TradingContract) GetTransfersByUser(ctx contractapi.TransactionContextInterface, userID string) ([]*models.Transfer, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	transferIDs, err := indexedIDs(ctx, indexUserTransfer, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*models.Transfer, 0, len(transferIDs))
+	for _, id := range transferIDs {
+		var transfer models.Transfer
+		if err := getEntity(ctx, &transfer, models.DocTypeTransfer, id); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &transfer)
+	}
+
+	return transfers, nil
+}
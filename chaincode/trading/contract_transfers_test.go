@@ -0,0 +1,94 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	balances := func() (int64, int64) {
+		t.Helper()
+		var user1, user2 models.User
+		if err := getEntity(l.ctx, &user1, models.DocTypeUser, "USER1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := getEntity(l.ctx, &user2, models.DocTypeUser, "USER2"); err != nil {
+			t.Fatal(err)
+		}
+		return user1.Balance.Amount, user2.Balance.Amount
+	}
+
+	tests := []struct {
+		name     string
+		creator  []byte
+		from, to string
+		amount   string
+		memo     string
+		wantErr  error
+	}{
+		{name: "someone else's balance", creator: l.user1, from: "USER2", to: "USER1", amount: "10", wantErr: services.ErrAccessDenied},
+		{name: "merchant", creator: l.merchant1, from: "USER1", to: "USER2", amount: "10", wantErr: services.ErrAccessDenied},
+		{name: "to self", creator: l.user1, from: "USER1", to: "USER1", amount: "10", wantErr: services.ErrInvalidInput},
+		{name: "unknown recipient", creator: l.user1, from: "USER1", to: "USER9", amount: "10", wantErr: services.ErrNotFound},
+		{name: "zero amount", creator: l.user1, from: "USER1", to: "USER2", amount: "0", wantErr: services.ErrInvalidAmount},
+		{name: "more than the balance", creator: l.user1, from: "USER1", to: "USER2", amount: "500.01", wantErr: services.ErrInsufficientFunds},
+		{name: "memo too long", creator: l.user1, from: "USER1", to: "USER2", amount: "10", memo: strings.Repeat("ž", 141), wantErr: services.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(tt.creator, "transfer-"+tt.name)
+			if _, err := c.Transfer(l.ctx, tt.from, tt.to, tt.amount, tt.memo); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if user1, user2 := balances(); user1 != 50000 || user2 != 30000 {
+				t.Errorf("balances = %d, %d after a refused transfer", user1, user2)
+			}
+		})
+	}
+
+	l.as(l.user1, "rent")
+	transfer, err := c.Transfer(l.ctx, "USER1", "USER2", "120.50", "kirija")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.ID != "TRF-rent" || transfer.TxID != "rent" || transfer.Amount.Amount != 12050 || transfer.Memo != "kirija" {
+		t.Errorf("transfer = %+v", transfer)
+	}
+	if user1, user2 := balances(); user1 != 37950 || user2 != 42050 {
+		t.Errorf("balances = %d, %d; want 37950, 42050", user1, user2)
+	}
+	var event struct {
+		Payload models.TransferCompletedEvent `json:"payload"`
+	}
+	onlyEvent(t, l.stub, models.EventTransferCompleted, &event)
+	if event.Payload.TransferID != "TRF-rent" || event.Payload.ToUserID != "USER2" {
+		t.Errorf("event = %+v", event.Payload)
+	}
+
+	l.as(l.admin, "list-transfers")
+	for _, userID := range []string{"USER1", "USER2"} {
+		transfers, err := c.GetTransfersByUser(l.ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transfers) != 1 || transfers[0].ID != "TRF-rent" {
+			t.Errorf("transfers of %s = %+v, want TRF-rent", userID, transfers)
+		}
+	}
+
+	l.as(l.user1, "sender-reads")
+	if _, err := c.GetTransfer(l.ctx, "TRF-rent"); err != nil {
+		t.Errorf("sender: %v", err)
+	}
+	l.as(l.merchant1, "merchant-reads")
+	if _, err := c.GetTransfer(l.ctx, "TRF-rent"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("merchant: got %v, want ErrAccessDenied", err)
+	}
+}
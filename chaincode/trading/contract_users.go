@@ -0,0 +1,172 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// CreateUser registers a user. The personal data is read from the transient
+// map under models.UserPIITransientKey, so it never appears in the proposal
+// arguments or in blocks; it is stored in the private data collection and
+// the public record keeps only its salted hash.
+func (t *TradingContract) CreateUser(ctx contractapi.TransactionContextInterface, id string) error {
+	caller, err := requireSelf(ctx, RoleUser, id, RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	pii, err := transientUserPII(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, userPII, err := services.CreateUser(id, pii, caller.Owner())
+	if err != nil {
+		return err
+	}
+
+	if err := requireAbsent(ctx, models.DocTypeUser, user.ID); err != nil {
+		return err
+	}
+
+	if err := putPrivateEntity(ctx, models.UserPIICollection, userPII, models.DocTypeUserPII, user.ID); err != nil {
+		return err
+	}
+	if err := putEntity(ctx, user, models.DocTypeUser, user.ID); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventUserCreated, models.UserCreatedEvent{UserID: user.ID})
+}
+
+// GetUserPII returns a user's personal data to the user or an admin. It only
+// succeeds on peers of orgs that are members of the collection.
+func (t *TradingContract) GetUserPII(ctx contractapi.TransactionContextInterface, userID string) (*models.UserPII, error) {
+	if _, err := requireSelf(ctx, RoleUser, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var pii models.UserPII
+	if err := getPrivateEntity(ctx, models.UserPIICollection, &pii, models.DocTypeUserPII, userID); err != nil {
+		return nil, err
+	}
+
+	return &pii, nil
+}
+
+// legacyUserPII holds the personal fields that user records stored publicly
+// before they moved to the private data collection.
+type legacyUserPII struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+}
+
+// MigrateUserPII moves the personal data still stored in public user records
+// into the private data collection and replaces it with the salted hash.
+// Users without public personal data are skipped, so running it again is
+// harmless. Earlier versions stay in the key history. Like MigrateMoney it
+// only reads composite keys, so run MigrateKeys first; the two may run in
+// either order. The salts are derived from the secret in the transient key
+// models.PIISaltTransientKey. Admin only. Returns the number of migrated
+// users.
+func (t *TradingContract) MigrateUserPII(ctx contractapi.TransactionContextInterface) (int, error) {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	secret, err := transientSaltSecret(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeUser), []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer resultsIterator.Close()
+
+	migrated := 0
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return migrated, err
+		}
+
+		var legacy legacyUserPII
+		if err := json.Unmarshal(kv.Value, &legacy); err != nil {
+			return migrated, fmt.Errorf("cannot migrate %s: %v", kv.Key, err)
+		}
+		if legacy.FirstName == "" && legacy.LastName == "" && legacy.Email == "" {
+			continue
+		}
+
+		var user models.User
+		_ = json.Unmarshal(kv.Value, &user)
+
+		salt, err := services.DeriveSalt(secret, user.ID)
+		if err != nil {
+			return migrated, err
+		}
+		pii := models.UserPII{
+			DocType:   models.DocTypeUserPII,
+			UserID:    user.ID,
+			FirstName: legacy.FirstName,
+			LastName:  legacy.LastName,
+			Email:     legacy.Email,
+			Salt:      salt,
+		}
+		user.PIIHash = services.HashPII(pii)
+
+		if err := putPrivateEntity(ctx, models.UserPIICollection, &pii, models.DocTypeUserPII, user.ID); err != nil {
+			return migrated, err
+		}
+		if err := putEntity(ctx, &user, models.DocTypeUser, user.ID); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrationCompleted(ctx, "MigrateUserPII", migrated)
+}
+
+// transientUserPII reads the personal data submitted with the proposal.
+func transientUserPII(ctx contractapi.TransactionContextInterface) (models.UserPII, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return models.UserPII{}, fmt.Errorf("failed to read transient data: %v", err)
+	}
+
+	data, ok := transient[models.UserPIITransientKey]
+	if !ok {
+		return models.UserPII{}, fmt.Errorf("%w: personal data must be sent as transient key %q", services.ErrInvalidInput, models.UserPIITransientKey)
+	}
+
+	var pii models.UserPII
+	if err := json.Unmarshal(data, &pii); err != nil {
+		return models.UserPII{}, fmt.Errorf("%w: cannot parse transient personal data: %v", services.ErrInvalidInput, err)
+	}
+
+	return pii, nil
+}
+
+// transientSaltSecret reads the secret that salts of seeded and migrated
+// users are derived from. It comes from the transient map, so it never
+// reaches a block and every endorser receives the same one.
+func transientSaltSecret(ctx contractapi.TransactionContextInterface) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient data: %v", err)
+	}
+
+	secret, ok := transient[models.PIISaltTransientKey]
+	if !ok {
+		return "", fmt.Errorf("%w: the salt secret must be sent as transient key %q", services.ErrInvalidInput, models.PIISaltTransientKey)
+	}
+
+	return string(secret), nil
+}
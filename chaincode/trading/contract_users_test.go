@@ -0,0 +1,162 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// sendUserPII sets the personal data the next CreateUser reads from the
+// transient map.
+func (l *testLedger) sendUserPII(firstName, lastName, email string) {
+	l.stub.transient = map[string][]byte{models.UserPIITransientKey: mustMarshal(models.UserPII{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		Salt:      "salt-of-ana-anic-1",
+	})}
+}
+
+func TestCreateUserKeepsPIIPrivate(t *testing.T) {
+	l := newTestLedger(t)
+	user3 := identity(t, "Org3MSP", "user3", "client", map[string]string{roleAttribute: "user", entityIDAttribute: "USER3"})
+
+	l.as(user3, "create-without-pii")
+	if err := l.contract.CreateUser(l.ctx, "USER3"); !errors.Is(err, services.ErrInvalidInput) {
+		t.Fatalf("without transient data got %v, want ErrInvalidInput", err)
+	}
+
+	l.as(user3, "create-user3")
+	l.sendUserPII("Ana", "Anić", "ana@example.rs")
+	if err := l.contract.CreateUser(l.ctx, "USER3"); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ledgerKey(l.ctx, models.DocTypeUser, "USER3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public := string(l.stub.state[key]); strings.Contains(public, "ana@example.rs") || strings.Contains(public, "Anić") {
+		t.Errorf("public record holds personal data: %s", public)
+	}
+
+	l.as(user3, "read-pii")
+	pii, err := l.contract.GetUserPII(l.ctx, "USER3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := l.contract.GetUserByID(l.ctx, "USER3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pii.Email != "ana@example.rs" || user.PIIHash != services.HashPII(*pii) {
+		t.Errorf("pii = %+v, hash %s", pii, user.PIIHash)
+	}
+
+	l.as(l.user1, "read-other-pii")
+	if _, err := l.contract.GetUserPII(l.ctx, "USER3"); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("another user: got %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestInitLedgerSaltSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		transient map[string][]byte
+		wantErr   bool
+	}{
+		{name: "missing", wantErr: true},
+		{name: "too short", transient: map[string][]byte{models.PIISaltTransientKey: []byte("short")}, wantErr: true},
+		{name: "sent", transient: map[string][]byte{models.PIISaltTransientKey: []byte(testSaltSecret)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newFakeStub()
+			stub.creator = identity(t, "Org1MSP", "admin", "admin", nil)
+			stub.transient = tt.transient
+			ctx := &contractapi.TransactionContext{}
+			ctx.SetStub(stub)
+
+			err := (&TradingContract{}).InitLedger(ctx)
+			if tt.wantErr {
+				if !errors.Is(err, services.ErrInvalidInput) {
+					t.Errorf("got %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var pii models.UserPII
+			if err := getPrivateEntity(ctx, models.UserPIICollection, &pii, models.DocTypeUserPII, "USER1"); err != nil {
+				t.Fatal(err)
+			}
+			salt, _ := services.DeriveSalt(testSaltSecret, "USER1")
+			if pii.Salt != salt {
+				t.Errorf("salt = %q, want the one derived from the secret", pii.Salt)
+			}
+		})
+	}
+}
+
+func TestMigrateUserPII(t *testing.T) {
+	l := newTestLedger(t)
+	key := putRaw(t, l.stub, models.DocTypeUser, "USER9",
+		`{"docType":"user","id":"USER9","firstName":"Ana","lastName":"Anić","email":"ana@example.rs","balance":{"amount":0,"currency":"RSD"}}`)
+
+	l.as(l.admin, "migrate-pii-without-secret")
+	if _, err := l.contract.MigrateUserPII(l.ctx); !errors.Is(err, services.ErrInvalidInput) {
+		t.Fatalf("without a salt secret got %v, want ErrInvalidInput", err)
+	}
+
+	l.as(l.admin, "migrate-pii")
+	l.stub.transient = map[string][]byte{models.PIISaltTransientKey: []byte(testSaltSecret)}
+	migrated, err := l.contract.MigrateUserPII(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 1 {
+		t.Errorf("migrated %d users, want 1", migrated)
+	}
+	if event := migrationEvent(t, l.stub); event.Migration != "MigrateUserPII" || event.Count != 1 {
+		t.Errorf("event = %+v, want MigrateUserPII with count 1", event)
+	}
+
+	var public map[string]json.RawMessage
+	if err := json.Unmarshal(l.stub.state[key], &public); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"firstName", "lastName", "email"} {
+		if _, ok := public[field]; ok {
+			t.Errorf("public record still holds %s", field)
+		}
+	}
+
+	var pii models.UserPII
+	if err := getPrivateEntity(l.ctx, models.UserPIICollection, &pii, models.DocTypeUserPII, "USER9"); err != nil {
+		t.Fatal(err)
+	}
+	if pii.Email != "ana@example.rs" || pii.Salt == "" {
+		t.Errorf("private record = %+v", pii)
+	}
+	var user models.User
+	if err := getEntity(l.ctx, &user, models.DocTypeUser, "USER9"); err != nil {
+		t.Fatal(err)
+	}
+	if user.PIIHash != services.HashPII(pii) {
+		t.Errorf("piiHash does not match the private record")
+	}
+
+	l.as(l.admin, "migrate-pii-again")
+	l.stub.transient = map[string][]byte{models.PIISaltTransientKey: []byte(testSaltSecret)}
+	if migrated, err := l.contract.MigrateUserPII(l.ctx); err != nil || migrated != 0 {
+		t.Errorf("second run migrated %d, %v; want 0", migrated, err)
+	}
+}
@@ -0,0 +1,213 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// SetVATRate sets the PDV rate, in whole percent, of a product (scope
+// "product") or of every product of a merchant type (scope "merchantType").
+// It applies to purchases made from then on. Admin only.
+func (t *TradingContract) SetVATRate(ctx contractapi.TransactionContextInterface, scope, target string, percent int) error {
+	if _, err := requireRole(ctx, RoleAdmin); err != nil {
+		return err
+	}
+
+	merchantTypes, err := merchantTypeIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	rate, err := services.NewVATRate(models.VATScope(scope), target, percent, merchantTypes)
+	if err != nil {
+		return err
+	}
+
+	if rate.Scope == models.VATScopeProduct {
+		var product models.Product
+		if err := getEntity(ctx, &product, models.DocTypeProduct, rate.Target); err != nil {
+			return err
+		}
+	}
+
+	if err := putEntity(ctx, rate, models.DocTypeVATRate, string(rate.Scope), rate.Target); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, models.EventVATRateChanged, models.VATRateChangedEvent{
+		Scope:   rate.Scope,
+		Target:  rate.Target,
+		Percent: rate.Percent,
+	})
+}
+
+// GetVATRates lists every PDV rate set on the ledger. Products without a
+// rate of their own or of their merchant type use models.DefaultVATPercent.
+func (t *TradingContract) GetVATRates(ctx contractapi.TransactionContextInterface) ([]*models.VATRate, error) {
+	if _, err := requireRole(ctx, RoleAdmin, RoleMerchant, RoleUser); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(string(models.DocTypeVATRate), []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	rates := make([]*models.VATRate, 0)
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var rate models.VATRate
+		if err := json.Unmarshal(kv.Value, &rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+
+	return rates, nil
+}
+
+// GetVATSummary sums the VAT a merchant collected between from and to
+// (RFC 3339, inclusive), per rate, net of credit notes issued in that range.
+// Only the invoices and credit notes dated within the range are read.
+func (t *TradingContract) GetVATSummary(ctx contractapi.TransactionContextInterface,
+	merchantID, from, to string) (*models.VATSummary, error) {
+
+	if _, err := requireSelf(ctx, RoleMerchant, merchantID, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	fromTime, fromErr := time.Parse(time.RFC3339, from)
+	toTime, toErr := time.Parse(time.RFC3339, to)
+	if fromErr != nil || toErr != nil {
+		var fields []services.FieldError
+		if fromErr != nil {
+			fields = append(fields, services.FieldError{Field: "from", Message: "must be an RFC 3339 timestamp"})
+		}
+		if toErr != nil {
+			fields = append(fields, services.FieldError{Field: "to", Message: "must be an RFC 3339 timestamp"})
+		}
+		return nil, &services.ValidationError{Fields: fields}
+	}
+
+	var invoices []*models.Invoice
+	if err := queryMerchantPeriod(ctx, models.DocTypeInvoice, merchantID, fromTime, toTime, func(value []byte) error {
+		var invoice models.Invoice
+		if err := json.Unmarshal(value, &invoice); err != nil {
+			return err
+		}
+		invoices = append(invoices, &invoice)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	noteInvoices := make(map[string]*models.Invoice, len(invoices))
+	for _, invoice := range invoices {
+		noteInvoices[invoice.ID] = invoice
+	}
+
+	var creditNotes []*models.CreditNote
+	if err := queryMerchantPeriod(ctx, models.DocTypeCreditNote, merchantID, fromTime, toTime, func(value []byte) error {
+		var note models.CreditNote
+		if err := json.Unmarshal(value, &note); err != nil {
+			return err
+		}
+		if _, ok := noteInvoices[note.InvoiceID]; !ok {
+			invoice, err := getInvoice(ctx, note.InvoiceID)
+			if err != nil {
+				return err
+			}
+			noteInvoices[invoice.ID] = invoice
+		}
+		creditNotes = append(creditNotes, &note)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return services.SummarizeVAT(merchantID, fromTime, toTime, invoices, creditNotes, noteInvoices)
+}
+
+// queryMerchantPeriod passes each record of docType belonging to merchantID
+// and dated within [from, to] to fn, using the indexMerchantDate index.
+// Dates are compared as UTC RFC 3339 strings, the form the transaction clock
+// stores them in.
+func queryMerchantPeriod(ctx contractapi.TransactionContextInterface, docType models.DocType,
+	merchantID string, from, to time.Time, fn func(value []byte) error) error {
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(merchantPeriodQuery(docType, merchantID, from, to))
+	if err != nil {
+		return fmt.Errorf("%s query failed: %v", docType, err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if err := fn(kv.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// merchantPeriodQuery builds the CouchDB query of queryMerchantPeriod.
+func merchantPeriodQuery(docType models.DocType, merchantID string, from, to time.Time) string {
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":    docType,
+			"merchantId": merchantID,
+			"date": map[string]interface{}{
+				"$gte": from.UTC().Format(time.RFC3339),
+				"$lte": to.UTC().Format(time.RFC3339),
+			},
+		},
+		"use_index": []string{"_design/indexMerchantDate", "indexMerchantDate"},
+	}
+
+	queryBytes, _ := json.Marshal(query)
+	return string(queryBytes)
+}
+
+// loadVATPercents resolves the PDV rate of every product: its own rate if
+// set, else the rate of its merchant type. Products with neither are left
+// out and taxed at models.DefaultVATPercent.
+func loadVATPercents(ctx contractapi.TransactionContextInterface, products map[string]*models.Product) (map[string]int, error) {
+	percents := make(map[string]int, len(products))
+	for id, product := range products {
+		for _, scope := range []struct {
+			scope  models.VATScope
+			target string
+		}{
+			{models.VATScopeProduct, product.ID},
+			{models.VATScopeMerchantType, product.MerchantType},
+		} {
+			var rate models.VATRate
+			err := getEntity(ctx, &rate, models.DocTypeVATRate, string(scope.scope), scope.target)
+			if err == services.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			percents[id] = rate.Percent
+			break
+		}
+	}
+
+	return percents, nil
+}
@@ -0,0 +1,68 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"chaincode/trading/services"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPurchaseSplitsVATByRate(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+	rsd := func(amount int64) money.Money { return money.New(amount, money.DefaultCurrency) }
+
+	l.as(l.merchant1, "merchant-sets-rate")
+	if err := c.SetVATRate(l.ctx, "product", "PROD2", 0); !errors.Is(err, services.ErrAccessDenied) {
+		t.Errorf("merchant: got %v, want ErrAccessDenied", err)
+	}
+	l.as(l.admin, "rate-unknown-product")
+	if err := c.SetVATRate(l.ctx, "product", "PROD9", 0); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("unknown product: got %v, want ErrNotFound", err)
+	}
+	l.as(l.admin, "rate-prod2")
+	if err := c.SetVATRate(l.ctx, "product", "PROD2", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		lines []models.CartLine
+		want  []models.VATLine
+	}{
+		{
+			// PROD1 takes the 10% supermarket rate, PROD2 its own 0%.
+			name:  "product and merchant type rates",
+			lines: []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD2", Quantity: 1}},
+			want: []models.VATLine{
+				{Percent: 10, Net: rsd(9091), VAT: rsd(909), Gross: rsd(10000)},
+				{Percent: 0, Net: rsd(2000), VAT: rsd(0), Gross: rsd(2000)},
+			},
+		},
+		{
+			name:  "default rate",
+			lines: []models.CartLine{{ProductID: "PROD4", Quantity: 1}},
+			want:  []models.VATLine{{Percent: models.DefaultVATPercent, Net: rsd(6667), VAT: rsd(1333), Gross: rsd(8000)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l.as(l.user1, "buy-"+tt.name)
+			invoices, err := c.PurchaseCart(l.ctx, "USER1", tt.lines, models.PurchaseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			invoice := invoices[0]
+			if !reflect.DeepEqual(invoice.VATBreakdown, tt.want) {
+				t.Errorf("breakdown = %+v, want %+v", invoice.VATBreakdown, tt.want)
+			}
+			if sum, _ := invoice.NetAmount.Add(invoice.VATAmount); sum != invoice.TotalPrice {
+				t.Errorf("net %v + VAT %v != total %v", invoice.NetAmount, invoice.VATAmount, invoice.TotalPrice)
+			}
+		})
+	}
+}
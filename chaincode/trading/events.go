@@ -0,0 +1,28 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// emitEvent sets the transaction's chaincode event. Fabric keeps only one
+// event per transaction, so each transaction calls it once, after all its
+// writes succeeded.
+func emitEvent(ctx contractapi.TransactionContextInterface, name models.EventName, payload interface{}) error {
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	event := models.Event{
+		Version:   models.EventSchemaVersion,
+		Name:      name,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: clock.Now().Format(time.RFC3339),
+		Payload:   payload,
+	}
+
+	return ctx.GetStub().SetEvent(string(name), mustMarshal(event))
+}
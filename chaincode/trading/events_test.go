@@ -0,0 +1,59 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	l := newTestLedger(t)
+	c := l.contract
+
+	l.as(l.user1, "buy")
+	if _, err := c.Purchase(l.ctx, "USER1", "PROD1", 2, models.PurchaseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var purchase struct {
+		models.Event
+		Payload models.PurchaseCompletedEvent `json:"payload"`
+	}
+	onlyEvent(t, l.stub, models.EventPurchaseCompleted, &purchase)
+	if purchase.Version != models.EventSchemaVersion || purchase.TxID != "buy" || purchase.Timestamp != l.stub.ts.Format(time.RFC3339) {
+		t.Errorf("envelope = %+v", purchase.Event)
+	}
+	if p := purchase.Payload; p.UserID != "USER1" || len(p.Invoices) != 1 || p.Invoices[0].InvoiceID != "INV-buy" || p.Total.Amount != 10000 {
+		t.Errorf("payload = %+v", p)
+	}
+
+	l.as(l.user1, "whoami")
+	before, err := c.WhoAmI(l.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.as(l.admin, "transfer")
+	if err := c.TransferOwnership(l.ctx, "user", "USER1", "Org1MSP", "someone-else"); err != nil {
+		t.Fatal(err)
+	}
+	var transfer struct {
+		Payload models.OwnershipTransferredEvent `json:"payload"`
+	}
+	onlyEvent(t, l.stub, models.EventOwnershipTransferred, &transfer)
+	if p := transfer.Payload; p.EntityID != "USER1" || p.PreviousOwner != *before || p.NewOwner.ID != "someone-else" {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+// onlyEvent checks that the transaction set exactly one event, named name,
+// and decodes it into out.
+func onlyEvent(t *testing.T, stub *fakeStub, name models.EventName, out interface{}) {
+	t.Helper()
+
+	if len(stub.events) != 1 || stub.events[string(name)] == nil {
+		t.Fatalf("events = %d, want only %s", len(stub.events), name)
+	}
+	if err := json.Unmarshal(stub.events[string(name)], out); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Audit holds the creation and last-update times of a ledger document,
+// taken from the transaction timestamp (RFC 3339, UTC).
+type Audit struct {
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// Touch records now as the update time, and as the creation time of records
+// that do not have one yet.
+func (a *Audit) Touch(now time.Time) {
+	ts := now.UTC().Format(time.RFC3339)
+	if a.CreatedAt == "" {
+		a.CreatedAt = ts
+	}
+	a.UpdatedAt = ts
+}
@@ -0,0 +1,7 @@
+package models
+
+// CartLine is one product/quantity pair requested in a PurchaseCart call.
+type CartLine struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
@@ -3,8 +3,30 @@ package models
 type DocType string
 
 const (
-	DocTypeMerchant DocType = "merchant"
-	DocTypeProduct  DocType = "product"
-	DocTypeUser     DocType = "user"
-	DocTypeInvoice  DocType = "invoice"
+	DocTypeMerchant       DocType = "merchant"
+	DocTypeProduct        DocType = "product"
+	DocTypeDeletedProduct DocType = "deletedProduct"
+	DocTypeUser           DocType = "user"
+	DocTypeInvoice        DocType = "invoice"
+	DocTypeReturn         DocType = "return"
+	DocTypeCreditNote     DocType = "creditNote"
+	DocTypePurchase       DocType = "purchase"
+	DocTypeUserPII        DocType = "userPII"
+	DocTypePromotion      DocType = "promotion"
+	DocTypeVATRate        DocType = "vatRate"
+	DocTypePayout         DocType = "payout"
+	DocTypePayoutPolicy   DocType = "payoutPolicy"
+	DocTypeTransfer       DocType = "transfer"
+	DocTypeOrder          DocType = "order"
+	DocTypeEscrowPolicy   DocType = "escrowPolicy"
+	DocTypeMerchantType   DocType = "merchantType"
+	DocTypeCategory       DocType = "category"
+	DocTypeReview         DocType = "review"
+	DocTypeLoyaltyProgram DocType = "loyaltyProgram"
+	DocTypeLoyaltyAccount DocType = "loyaltyAccount"
+	DocTypeLoyaltyEntry   DocType = "loyaltyEntry"
+	DocTypeMerchantStats  DocType = "merchantStats"
+	DocTypeMerchantDaily  DocType = "merchantDailyStats"
+	DocTypeProductStats   DocType = "productStats"
+	DocTypeProductDaily   DocType = "productDailyStats"
 )
@@ -0,0 +1,201 @@
+package models
+
+import "chaincode/trading/money"
+
+// EventSchemaVersion is bumped whenever an event payload changes in a way
+// consumers must handle, e.g. a renamed or removed field. Adding a field does
+// not bump it.
+const EventSchemaVersion = 1
+
+// EventName is the chaincode event name consumers subscribe to.
+type EventName string
+
+const (
+	EventPurchaseCompleted     EventName = "PurchaseCompleted"
+	EventFundsDeposited        EventName = "FundsDeposited"
+	EventProductsAdded         EventName = "ProductsAdded"
+	EventMerchantCreated       EventName = "MerchantCreated"
+	EventUserCreated           EventName = "UserCreated"
+	EventReturnRequested       EventName = "ReturnRequested"
+	EventReturnResolved        EventName = "ReturnResolved"
+	EventOwnershipTransferred  EventName = "OwnershipTransferred"
+	EventMigrationCompleted    EventName = "MigrationCompleted"
+	EventPromotionChanged      EventName = "PromotionChanged"
+	EventVATRateChanged        EventName = "VATRateChanged"
+	EventFundsWithdrawn        EventName = "FundsWithdrawn"
+	EventPayoutPolicyChanged   EventName = "PayoutPolicyChanged"
+	EventTransferCompleted     EventName = "TransferCompleted"
+	EventOrderStatusChanged    EventName = "OrderStatusChanged"
+	EventEscrowPolicyChanged   EventName = "EscrowPolicyChanged"
+	EventProductChanged        EventName = "ProductChanged"
+	EventTaxonomyChanged       EventName = "TaxonomyChanged"
+	EventReviewSubmitted       EventName = "ReviewSubmitted"
+	EventLoyaltyProgramChanged EventName = "LoyaltyProgramChanged"
+)
+
+// Event is the envelope of every chaincode event. Payload holds one of the
+// *Event payload types below, selected by Name.
+type Event struct {
+	Version   int         `json:"version"`
+	Name      EventName   `json:"name"`
+	TxID      string      `json:"txId"`
+	Timestamp string      `json:"timestamp"`
+	Payload   interface{} `json:"payload"`
+}
+
+type PurchaseCompletedEvent struct {
+	UserID   string                `json:"userId"`
+	Total    money.Money           `json:"total"`
+	Invoices []PurchasedInvoiceRef `json:"invoices"`
+}
+
+// PurchasedInvoiceRef summarizes one invoice of a purchase.
+type PurchasedInvoiceRef struct {
+	InvoiceID  string      `json:"invoiceId"`
+	MerchantID string      `json:"merchantId"`
+	ProductIDs []string    `json:"productIds"`
+	Total      money.Money `json:"total"`
+	// Loyalty points paid towards and earned on the invoice.
+	PointsRedeemed int `json:"pointsRedeemed,omitempty"`
+	PointsEarned   int `json:"pointsEarned,omitempty"`
+}
+
+type FundsDepositedEvent struct {
+	EntityType string      `json:"entityType"`
+	EntityID   string      `json:"entityId"`
+	Amount     money.Money `json:"amount"`
+	Balance    money.Money `json:"balance"`
+}
+
+type FundsWithdrawnEvent struct {
+	EntityType string      `json:"entityType"`
+	EntityID   string      `json:"entityId"`
+	PayoutID   string      `json:"payoutId"`
+	Amount     money.Money `json:"amount"`
+	Balance    money.Money `json:"balance"`
+}
+
+type PayoutPolicyChangedEvent struct {
+	MinPayout  money.Money `json:"minPayout"`
+	DailyLimit money.Money `json:"dailyLimit"`
+}
+
+// TransferCompletedEvent leaves out the memo, which only the two parties
+// read through GetTransfer.
+type TransferCompletedEvent struct {
+	TransferID string      `json:"transferId"`
+	FromUserID string      `json:"fromUserId"`
+	ToUserID   string      `json:"toUserId"`
+	Amount     money.Money `json:"amount"`
+}
+
+type OrderStatusChangedEvent struct {
+	OrderID      string      `json:"orderId"`
+	UserID       string      `json:"userId"`
+	MerchantID   string      `json:"merchantId"`
+	Status       OrderStatus `json:"status"`
+	Amount       money.Money `json:"amount"`
+	AutoReleased bool        `json:"autoReleased"`
+}
+
+type EscrowPolicyChangedEvent struct {
+	ReleaseAfterDays int `json:"releaseAfterDays"`
+}
+
+type ProductChangedEvent struct {
+	ProductID  string        `json:"productId"`
+	MerchantID string        `json:"merchantId"`
+	Change     ProductChange `json:"change"`
+}
+
+// TaxonomyChangedEvent names the merchant type or category an admin added;
+// Kind is "merchantType" or "category".
+type TaxonomyChangedEvent struct {
+	Kind     DocType `json:"kind"`
+	ID       string  `json:"id"`
+	ParentID string  `json:"parentId,omitempty"`
+}
+
+// ReviewSubmittedEvent carries the new ratings of the reviewed product and
+// merchant; the review text is left out.
+type ReviewSubmittedEvent struct {
+	ReviewID       string `json:"reviewId"`
+	ProductID      string `json:"productId"`
+	MerchantID     string `json:"merchantId"`
+	Score          int    `json:"score"`
+	ProductRating  Rating `json:"productRating"`
+	MerchantRating Rating `json:"merchantRating"`
+}
+
+type LoyaltyProgramChangedEvent struct {
+	MerchantID   string `json:"merchantId"`
+	EarnPercent  int    `json:"earnPercent"`
+	ExpiryDays   int    `json:"expiryDays"`
+	PlatformWide bool   `json:"platformWide"`
+}
+
+type ProductsAddedEvent struct {
+	MerchantID string   `json:"merchantId"`
+	ProductIDs []string `json:"productIds"`
+}
+
+type MerchantCreatedEvent struct {
+	MerchantID   string `json:"merchantId"`
+	MerchantType string `json:"merchantType"`
+}
+
+type UserCreatedEvent struct {
+	UserID string `json:"userId"`
+}
+
+type ReturnRequestedEvent struct {
+	ReturnID   string      `json:"returnId"`
+	InvoiceID  string      `json:"invoiceId"`
+	UserID     string      `json:"userId"`
+	MerchantID string      `json:"merchantId"`
+	ProductID  string      `json:"productId"`
+	Quantity   int         `json:"quantity"`
+	Amount     money.Money `json:"amount"`
+}
+
+// PromotionChangedEvent is emitted when a promotion is created or
+// deactivated. Promo codes are left out so subscribers cannot learn them.
+type PromotionChangedEvent struct {
+	PromotionID string       `json:"promotionId"`
+	MerchantID  string       `json:"merchantId"`
+	ProductID   string       `json:"productId,omitempty"`
+	Kind        DiscountKind `json:"kind"`
+	StartsAt    string       `json:"startsAt"`
+	EndsAt      string       `json:"endsAt"`
+	Active      bool         `json:"active"`
+	HasCode     bool         `json:"hasCode"`
+}
+
+type VATRateChangedEvent struct {
+	Scope   VATScope `json:"scope"`
+	Target  string   `json:"target"`
+	Percent int      `json:"percent"`
+}
+
+// ReturnResolvedEvent is emitted when a merchant approves or rejects a
+// return. CreditNoteID is empty for rejected returns.
+type ReturnResolvedEvent struct {
+	ReturnID     string       `json:"returnId"`
+	Status       ReturnStatus `json:"status"`
+	CreditNoteID string       `json:"creditNoteId,omitempty"`
+	Amount       money.Money  `json:"amount"`
+}
+
+type OwnershipTransferredEvent struct {
+	EntityType    string `json:"entityType"`
+	EntityID      string `json:"entityId"`
+	PreviousOwner Owner  `json:"previousOwner"`
+	NewOwner      Owner  `json:"newOwner"`
+}
+
+// MigrationCompletedEvent names the Migrate* transaction that ran and how
+// many records it wrote.
+type MigrationCompletedEvent struct {
+	Migration string `json:"migration"`
+	Count     int    `json:"count"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// HistoryEntry is one committed version of a ledger record. Value is absent
+// when the version is a deletion.
+type HistoryEntry struct {
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+}
+
+type UserHistoryEntry struct {
+	HistoryEntry
+	Value *User `json:"value,omitempty" metadata:",optional"`
+}
+
+type ProductHistoryEntry struct {
+	HistoryEntry
+	Value *Product `json:"value,omitempty" metadata:",optional"`
+}
+
+type MerchantHistoryEntry struct {
+	HistoryEntry
+	Value *Merchant `json:"value,omitempty" metadata:",optional"`
+}
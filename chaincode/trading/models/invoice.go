@@ -1,12 +1,92 @@
 package models
 
+import (
+	"chaincode/trading/money"
+	"encoding/json"
+)
+
 type Invoice struct {
-	DocType    DocType `json:"docType"`
-	ID         string  `json:"id"`
-	MerchantID string  `json:"merchantId"`
-	UserID     string  `json:"userId"`
-	ProductID  string  `json:"productId"`
-	Quantity   int     `json:"quantity"`
-	TotalPrice float64 `json:"totalPrice"`
-	Date       string  `json:"date"`
+	DocType     DocType       `json:"docType"`
+	ID          string        `json:"id"`
+	MerchantID  string        `json:"merchantId"`
+	UserID      string        `json:"userId"`
+	Items       []InvoiceItem `json:"items"`
+	TotalPrice  money.Money   `json:"totalPrice"`
+	Date        string        `json:"date"`
+	CreditNotes []string      `json:"creditNotes"`
+	// TotalPrice is the gross total; NetAmount and VATAmount split it and
+	// VATBreakdown groups the split by rate.
+	NetAmount    money.Money `json:"netAmount"`
+	VATAmount    money.Money `json:"vatAmount"`
+	VATBreakdown []VATLine   `json:"vatBreakdown,omitempty" metadata:",optional"`
+	// PromoCode and Discount are set when promotions lowered the total.
+	PromoCode string       `json:"promoCode,omitempty" metadata:",optional"`
+	Discount  *money.Money `json:"discount,omitempty" metadata:",optional"`
+	// OrderStatus is set on invoices paid into escrow and mirrors the status
+	// of the order with the invoice's ID.
+	OrderStatus OrderStatus `json:"orderStatus,omitempty" metadata:",optional"`
+	// PointsRedeemed loyalty points, worth one minor unit each, paid part of
+	// TotalPrice; the rest was paid from the balance and earned PointsEarned.
+	// PointsRefunded (pending returns included) and PointsReversed count how
+	// many of each returns and cancellation gave back and took back.
+	// PointsWithheld counts the minor units kept back from their cash refunds
+	// for earned points the buyer had already spent.
+	PointsRedeemed int `json:"pointsRedeemed,omitempty" metadata:",optional"`
+	PointsEarned   int `json:"pointsEarned,omitempty" metadata:",optional"`
+	PointsRefunded int `json:"pointsRefunded,omitempty" metadata:",optional"`
+	PointsReversed int `json:"pointsReversed,omitempty" metadata:",optional"`
+	PointsWithheld int `json:"pointsWithheld,omitempty" metadata:",optional"`
+	Audit
+}
+
+// UnmarshalJSON also reads invoices stored before line items, which named a
+// single productId and quantity at the top level, as an invoice of one item.
+func (inv *Invoice) UnmarshalJSON(data []byte) error {
+	type plain Invoice
+	var doc struct {
+		plain
+		ProductID string `json:"productId"`
+		Quantity  int    `json:"quantity"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	*inv = Invoice(doc.plain)
+	if len(inv.Items) == 0 && doc.ProductID != "" {
+		unitPrice := inv.TotalPrice
+		if doc.Quantity > 0 {
+			unitPrice.Amount /= int64(doc.Quantity)
+		}
+		inv.Items = []InvoiceItem{{
+			ProductID:  doc.ProductID,
+			Quantity:   doc.Quantity,
+			UnitPrice:  unitPrice,
+			TotalPrice: inv.TotalPrice,
+		}}
+	}
+	if inv.CreditNotes == nil {
+		inv.CreditNotes = []string{}
+	}
+
+	return nil
+}
+
+// InvoiceItem is a single product line on an invoice.
+type InvoiceItem struct {
+	ProductID  string      `json:"productId"`
+	Quantity   int         `json:"quantity"`
+	UnitPrice  money.Money `json:"unitPrice"`
+	TotalPrice money.Money `json:"totalPrice"`
+	VATPercent int         `json:"vatPercent"`
+	NetAmount  money.Money `json:"netAmount"`
+	VATAmount  money.Money `json:"vatAmount"`
+	// On discounted lines UnitPrice is the price paid; ListPrice is the
+	// catalog unit price and Discount the reduction over the whole line.
+	ListPrice   *money.Money `json:"listPrice,omitempty" metadata:",optional"`
+	Discount    *money.Money `json:"discount,omitempty" metadata:",optional"`
+	PromotionID string       `json:"promotionId,omitempty" metadata:",optional"`
+	// Units already refunded and units awaiting a merchant decision.
+	ReturnedQuantity      int `json:"returnedQuantity"`
+	PendingReturnQuantity int `json:"pendingReturnQuantity"`
 }
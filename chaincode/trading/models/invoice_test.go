@@ -0,0 +1,38 @@
+package models
+
+import (
+	"chaincode/trading/money"
+	"encoding/json"
+	"testing"
+)
+
+func TestInvoiceUnmarshalLegacy(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want InvoiceItem
+	}{
+		{
+			name: "single product",
+			in:   `{"docType":"invoice","id":"INV1","productId":"PROD1","quantity":2,"totalPrice":100}`,
+			want: InvoiceItem{ProductID: "PROD1", Quantity: 2, UnitPrice: money.New(5000, "RSD"), TotalPrice: money.New(10000, "RSD")},
+		},
+		{
+			name: "line items",
+			in:   `{"docType":"invoice","id":"INV1","items":[{"productId":"PROD2","quantity":1,"unitPrice":20,"totalPrice":20}],"totalPrice":20}`,
+			want: InvoiceItem{ProductID: "PROD2", Quantity: 1, UnitPrice: money.New(2000, "RSD"), TotalPrice: money.New(2000, "RSD")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice Invoice
+			if err := json.Unmarshal([]byte(tt.in), &invoice); err != nil {
+				t.Fatal(err)
+			}
+			if len(invoice.Items) != 1 || invoice.Items[0] != tt.want {
+				t.Errorf("items = %+v, want [%+v]", invoice.Items, tt.want)
+			}
+		})
+	}
+}
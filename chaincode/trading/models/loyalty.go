@@ -0,0 +1,72 @@
+package models
+
+// DefaultPointsExpiryDays is how long points refunded at a merchant without
+// a loyalty program stay valid.
+const DefaultPointsExpiryDays = 365
+
+// LoyaltyProgram is a merchant's points scheme. Every purchase from the
+// merchant earns EarnPercent of the amount paid from the balance as points,
+// one point being worth one minor currency unit (0.01 RSD). Points expire
+// ExpiryDays after they were earned and can be redeemed at the merchant, or
+// at any merchant when PlatformWide is set.
+type LoyaltyProgram struct {
+	DocType      DocType `json:"docType"`
+	MerchantID   string  `json:"merchantId"`
+	EarnPercent  int     `json:"earnPercent"`
+	ExpiryDays   int     `json:"expiryDays"`
+	PlatformWide bool    `json:"platformWide"`
+	Audit
+}
+
+// LoyaltyAccount holds a user's points. Points is the sum of the lots, which
+// are spent soonest-expiring first.
+type LoyaltyAccount struct {
+	DocType DocType    `json:"docType"`
+	UserID  string     `json:"userId"`
+	Points  int        `json:"points"`
+	Lots    []PointLot `json:"lots"`
+	Audit
+}
+
+// PointLot is what is left of the points one entry credited, and where they
+// can be redeemed.
+type PointLot struct {
+	EntryID      string `json:"entryId"`
+	MerchantID   string `json:"merchantId"`
+	PlatformWide bool   `json:"platformWide"`
+	Points       int    `json:"points"`
+	ExpiresAt    string `json:"expiresAt"`
+}
+
+type LoyaltyEntryKind string
+
+const (
+	// Points earned on a purchase.
+	LoyaltyEarned LoyaltyEntryKind = "earned"
+	// Points paid towards a purchase.
+	LoyaltyRedeemed LoyaltyEntryKind = "redeemed"
+	// Points that passed their expiry date.
+	LoyaltyExpired LoyaltyEntryKind = "expired"
+	// Redeemed points given back by a return or a cancelled order.
+	LoyaltyRefunded LoyaltyEntryKind = "refunded"
+	// Earned points taken back by a return or a cancelled order.
+	LoyaltyReversed LoyaltyEntryKind = "reversed"
+)
+
+// LoyaltyEntry is one line of a user's points statement. Points is positive
+// for credits and negative for debits; Balance is the account's points after
+// the entry.
+type LoyaltyEntry struct {
+	DocType    DocType          `json:"docType"`
+	ID         string           `json:"id"`
+	UserID     string           `json:"userId"`
+	Kind       LoyaltyEntryKind `json:"kind"`
+	Points     int              `json:"points"`
+	Balance    int              `json:"balance"`
+	MerchantID string           `json:"merchantId,omitempty" metadata:",optional"`
+	InvoiceID  string           `json:"invoiceId,omitempty" metadata:",optional"`
+	ExpiresAt  string           `json:"expiresAt,omitempty" metadata:",optional"`
+	TxID       string           `json:"txId"`
+	Date       string           `json:"date"`
+	Audit
+}
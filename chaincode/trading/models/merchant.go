@@ -1,11 +1,46 @@
 package models
 
+import "chaincode/trading/money"
+
 type Merchant struct {
-	DocType         DocType  `json:"docType"`
-	ID              string   `json:"id"`
-	Type            string   `json:"type"`
-	PIB             string   `json:"pib"`
-	ProductsForSale []string `json:"products"`
-	Invoices        []string `json:"invoices"`
-	Balance         float64  `json:"balance"`
+	DocType         DocType     `json:"docType"`
+	ID              string      `json:"id"`
+	Type            string      `json:"type"`
+	PIB             string      `json:"pib"`
+	ProductsForSale []string    `json:"products"`
+	Invoices        []string    `json:"invoices"`
+	Balance         money.Money `json:"balance"`
+	Owner           Owner       `json:"owner"`
+	Rating          *Rating     `json:"rating,omitempty" metadata:",optional"`
+	Audit
+}
+
+// Built-in merchant types, written to the ledger by InitLedger and
+// MigrateTaxonomy. Admins add more with AddMerchantType.
+const (
+	MerchantTypeSupermarket = "supermarket"
+	MerchantTypeAutoParts   = "auto_parts"
+	MerchantTypePharmacy    = "pharmacy"
+	MerchantTypeRetail      = "retail"
+	MerchantTypeElectronics = "electronics"
+	MerchantTypeClothing    = "clothing"
+)
+
+var MerchantTypes = []string{
+	MerchantTypeSupermarket,
+	MerchantTypeAutoParts,
+	MerchantTypePharmacy,
+	MerchantTypeRetail,
+	MerchantTypeElectronics,
+	MerchantTypeClothing,
+}
+
+// MerchantTypeNames are the display names of the built-in merchant types.
+var MerchantTypeNames = map[string]string{
+	MerchantTypeSupermarket: "Supermarket",
+	MerchantTypeAutoParts:   "Auto delovi",
+	MerchantTypePharmacy:    "Apoteka",
+	MerchantTypeRetail:      "Maloprodaja",
+	MerchantTypeElectronics: "Elektronika",
+	MerchantTypeClothing:    "Odeća",
 }
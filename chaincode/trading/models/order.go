@@ -0,0 +1,41 @@
+package models
+
+import "chaincode/trading/money"
+
+// DefaultEscrowReleaseDays applies until an admin sets an escrow policy.
+const DefaultEscrowReleaseDays = 14
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+// Order holds the payment of an invoice in escrow until the buyer confirms
+// delivery; only then is the merchant credited. An order has the ID of its
+// invoice. AutoReleased is set when the merchant was credited because the
+// buyer did not confirm delivery within the escrow policy's timeout.
+type Order struct {
+	DocType      DocType     `json:"docType"`
+	ID           string      `json:"id"`
+	UserID       string      `json:"userId"`
+	MerchantID   string      `json:"merchantId"`
+	Amount       money.Money `json:"amount"`
+	Status       OrderStatus `json:"status"`
+	ShippedAt    string      `json:"shippedAt,omitempty" metadata:",optional"`
+	DeliveredAt  string      `json:"deliveredAt,omitempty" metadata:",optional"`
+	CancelledAt  string      `json:"cancelledAt,omitempty" metadata:",optional"`
+	AutoReleased bool        `json:"autoReleased,omitempty" metadata:",optional"`
+	Audit
+}
+
+// EscrowPolicy sets how many days after shipping the merchant may release
+// an order the buyer has not confirmed.
+type EscrowPolicy struct {
+	DocType          DocType `json:"docType"`
+	ReleaseAfterDays int     `json:"releaseAfterDays"`
+	Audit
+}
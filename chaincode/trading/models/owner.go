@@ -0,0 +1,8 @@
+package models
+
+// Owner identifies the X.509 identity that controls a ledger record.
+// ID is the value returned by cid.GetID (base64 of subject and issuer DN).
+type Owner struct {
+	MSPID string `json:"mspId"`
+	ID    string `json:"id"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// PageInfo is returned with every page of a paginated query. Pass Bookmark
+// back to fetch the next page; an empty Bookmark or a page with fewer
+// records than requested means there are no more results.
+type PageInfo struct {
+	Bookmark     string `json:"bookmark"`
+	RecordsCount int32  `json:"recordsCount"`
+}
+
+type ProductPage struct {
+	Records []*Product `json:"records"`
+	PageInfo
+}
+
+type UserPage struct {
+	Records []*User `json:"records"`
+	PageInfo
+}
+
+type InvoicePage struct {
+	Records []*Invoice `json:"records"`
+	PageInfo
+}
+
+type ReviewPage struct {
+	Records []*Review `json:"records"`
+	PageInfo
+}
@@ -0,0 +1,30 @@
+package models
+
+import "chaincode/trading/money"
+
+// PayoutPolicy bounds withdrawals: each payout must be at least MinPayout,
+// and the payouts of one user or merchant on one UTC day may not exceed
+// DailyLimit.
+type PayoutPolicy struct {
+	DocType    DocType     `json:"docType"`
+	MinPayout  money.Money `json:"minPayout"`
+	DailyLimit money.Money `json:"dailyLimit"`
+	Audit
+}
+
+// Payout records money leaving the platform to a user's or merchant's bank
+// account. Reference is the bank account or transfer reference given by the
+// payee, to match the payout against the bank statement.
+type Payout struct {
+	DocType      DocType     `json:"docType"`
+	ID           string      `json:"id"`
+	EntityType   string      `json:"entityType"`
+	EntityID     string      `json:"entityId"`
+	Amount       money.Money `json:"amount"`
+	BalanceAfter money.Money `json:"balanceAfter"`
+	Reference    string      `json:"reference"`
+	TxID         string      `json:"txId"`
+	Date         string      `json:"date"`
+	Day          string      `json:"day"`
+	Audit
+}
@@ -1,12 +1,82 @@
 package models
 
+import "chaincode/trading/money"
+
 type Product struct {
-	DocType      DocType `json:"docType"`
-	ID           string  `json:"id"`
-	Name         string  `json:"name"`
-	Expiration   string  `json:"expiration,omitempty"`
-	Price        float64 `json:"price"`
-	Quantity     int     `json:"quantity"`
-	MerchantID   string  `json:"merchantId"`
-	MerchantType string  `json:"merchantType"`
+	DocType      DocType     `json:"docType"`
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	Expiration   string      `json:"expiration,omitempty"`
+	Price        money.Money `json:"price"`
+	Quantity     int         `json:"quantity"`
+	MerchantID   string      `json:"merchantId"`
+	MerchantType string      `json:"merchantType"`
+	// Category is the ID of the product's category and CategoryPath the
+	// category's Path, copied so queries can match any ancestor.
+	Category     string   `json:"category,omitempty" metadata:",optional"`
+	CategoryPath []string `json:"categoryPath,omitempty" metadata:",optional"`
+	// Delisted products stay on the ledger for invoices and returns but are
+	// no longer sold.
+	Delisted   bool           `json:"delisted,omitempty" metadata:",optional"`
+	LastChange *ProductChange `json:"lastChange,omitempty" metadata:",optional"`
+	Rating     *Rating        `json:"rating,omitempty" metadata:",optional"`
+	Audit
+}
+
+// DeletedProduct is the tombstone DeleteProduct leaves in place of a
+// product, so that its ID is never given to another product.
+type DeletedProduct struct {
+	DocType    DocType `json:"docType"`
+	ID         string  `json:"id"`
+	MerchantID string  `json:"merchantId"`
+	DeletedBy  Owner   `json:"deletedBy"`
+	TxID       string  `json:"txId"`
+	Audit
+}
+
+type ProductAction string
+
+const (
+	ProductRestocked      ProductAction = "restocked"
+	ProductRepriced       ProductAction = "repriced"
+	ProductDetailsUpdated ProductAction = "detailsUpdated"
+	ProductDelisted       ProductAction = "delisted"
+	ProductDeleted        ProductAction = "deleted"
+)
+
+// ProductChange records who changed a product after AddProducts, and how.
+// Each product version keeps its last change, so GetProductHistory lists
+// all of them.
+type ProductChange struct {
+	Action    ProductAction `json:"action"`
+	Fields    []FieldChange `json:"fields"`
+	ChangedBy Owner         `json:"changedBy"`
+	TxID      string        `json:"txId"`
+	Date      string        `json:"date"`
+}
+
+// FieldChange holds the old and new value of one field, as text.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ProductDetailsInput is submitted to UpdateProductDetails; empty fields are
+// left unchanged.
+type ProductDetailsInput struct {
+	Name       string `json:"name,omitempty" metadata:",optional"`
+	Expiration string `json:"expiration,omitempty" metadata:",optional"`
+	Category   string `json:"category,omitempty" metadata:",optional"`
+}
+
+// ProductInput is the catalog entry a merchant submits to AddProducts.
+// Price is a decimal amount such as "120.00" or "120.00 RSD".
+type ProductInput struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Expiration string `json:"expiration,omitempty" metadata:",optional"`
+	Price      string `json:"price"`
+	Quantity   int    `json:"quantity"`
+	Category   string `json:"category,omitempty" metadata:",optional"`
 }
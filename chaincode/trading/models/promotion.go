@@ -0,0 +1,47 @@
+package models
+
+import "chaincode/trading/money"
+
+type DiscountKind string
+
+const (
+	DiscountPercent DiscountKind = "percent"
+	DiscountFixed   DiscountKind = "fixed"
+)
+
+// Promotion is a merchant discount on one product, or on the whole catalog
+// when ProductID is empty, valid from StartsAt until EndsAt. A promotion with
+// a Code only applies to purchases that quote it. MaxUses of zero means
+// unlimited; Uses counts the purchases that applied the promotion.
+type Promotion struct {
+	DocType    DocType      `json:"docType"`
+	ID         string       `json:"id"`
+	MerchantID string       `json:"merchantId"`
+	ProductID  string       `json:"productId,omitempty" metadata:",optional"`
+	Kind       DiscountKind `json:"kind"`
+	// PercentOff is set for percent discounts, AmountOff (per unit) for
+	// fixed ones.
+	PercentOff int          `json:"percentOff,omitempty" metadata:",optional"`
+	AmountOff  *money.Money `json:"amountOff,omitempty" metadata:",optional"`
+	StartsAt   string       `json:"startsAt"`
+	EndsAt     string       `json:"endsAt"`
+	Code       string       `json:"code,omitempty" metadata:",optional"`
+	MaxUses    int          `json:"maxUses"`
+	Uses       int          `json:"uses"`
+	Active     bool         `json:"active"`
+	Audit
+}
+
+// PromotionInput is what a merchant submits to CreatePromotion. AmountOff is
+// a decimal amount such as "50.00"; StartsAt and EndsAt are RFC 3339.
+type PromotionInput struct {
+	ID         string       `json:"id"`
+	ProductID  string       `json:"productId,omitempty" metadata:",optional"`
+	Kind       DiscountKind `json:"kind"`
+	PercentOff int          `json:"percentOff,omitempty" metadata:",optional"`
+	AmountOff  string       `json:"amountOff,omitempty" metadata:",optional"`
+	StartsAt   string       `json:"startsAt"`
+	EndsAt     string       `json:"endsAt"`
+	Code       string       `json:"code,omitempty" metadata:",optional"`
+	MaxUses    int          `json:"maxUses,omitempty" metadata:",optional"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// PurchaseOptions carries the optional settings of Purchase and PurchaseCart.
+type PurchaseOptions struct {
+	// IdempotencyKey is chosen by the client. Submitting the same key again
+	// for the same user returns the original invoices instead of charging
+	// twice.
+	IdempotencyKey string `json:"idempotencyKey,omitempty" metadata:",optional"`
+	// PromoCode applies a merchant promotion that requires a code.
+	PromoCode string `json:"promoCode,omitempty" metadata:",optional"`
+	// RedeemPoints pays that many loyalty points towards the purchase.
+	RedeemPoints int `json:"redeemPoints,omitempty" metadata:",optional"`
+}
+
+// PurchaseRecord remembers which invoices a purchase submitted under an
+// idempotency key produced.
+type PurchaseRecord struct {
+	DocType        DocType  `json:"docType"`
+	UserID         string   `json:"userId"`
+	IdempotencyKey string   `json:"idempotencyKey"`
+	TxID           string   `json:"txId"`
+	InvoiceIDs     []string `json:"invoiceIds"`
+	Audit
+}
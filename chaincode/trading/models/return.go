@@ -0,0 +1,54 @@
+package models
+
+import "chaincode/trading/money"
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnRejected  ReturnStatus = "rejected"
+)
+
+// ReturnRequest is a user's request to return units of one invoice line.
+type ReturnRequest struct {
+	DocType      DocType      `json:"docType"`
+	ID           string       `json:"id"`
+	InvoiceID    string       `json:"invoiceId"`
+	UserID       string       `json:"userId"`
+	MerchantID   string       `json:"merchantId"`
+	ProductID    string       `json:"productId"`
+	Quantity     int          `json:"quantity"`
+	Amount       money.Money  `json:"amount"`
+	Status       ReturnStatus `json:"status"`
+	CreditNoteID string       `json:"creditNoteId"`
+	RequestedAt  string       `json:"requestedAt"`
+	ResolvedAt   string       `json:"resolvedAt"`
+	// Points is the part of Amount refunded as loyalty points, in proportion
+	// to how much of the invoice they paid.
+	Points int `json:"points,omitempty" metadata:",optional"`
+	Audit
+}
+
+// CreditNote records the refund issued for an approved return.
+type CreditNote struct {
+	DocType    DocType     `json:"docType"`
+	ID         string      `json:"id"`
+	InvoiceID  string      `json:"invoiceId"`
+	ReturnID   string      `json:"returnId"`
+	UserID     string      `json:"userId"`
+	MerchantID string      `json:"merchantId"`
+	ProductID  string      `json:"productId"`
+	Quantity   int         `json:"quantity"`
+	Amount     money.Money `json:"amount"`
+	Points     int         `json:"points,omitempty" metadata:",optional"`
+	// PointsWithheld minor units of the cash refund were kept back for
+	// points earned on the invoice that the buyer had already spent.
+	PointsWithheld int `json:"pointsWithheld,omitempty" metadata:",optional"`
+	// VAT refunded with Amount, at the rate of the invoice line.
+	VATPercent int         `json:"vatPercent"`
+	NetAmount  money.Money `json:"netAmount"`
+	VATAmount  money.Money `json:"vatAmount"`
+	Date       string      `json:"date"`
+	Audit
+}
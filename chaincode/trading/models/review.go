@@ -0,0 +1,33 @@
+package models
+
+// Review scores are whole stars from MinReviewScore to MaxReviewScore.
+const (
+	MinReviewScore = 1
+	MaxReviewScore = 5
+)
+
+// Review is a buyer's verified rating of one product on an invoice. It is
+// stored under the invoice ID, which is also its ID, so each invoice is
+// reviewed at most once. Score counts toward the rating of both the product
+// and the merchant.
+type Review struct {
+	DocType    DocType `json:"docType"`
+	ID         string  `json:"id"`
+	InvoiceID  string  `json:"invoiceId"`
+	UserID     string  `json:"userId"`
+	MerchantID string  `json:"merchantId"`
+	ProductID  string  `json:"productId"`
+	Score      int     `json:"score"`
+	Text       string  `json:"text,omitempty" metadata:",optional"`
+	TxID       string  `json:"txId"`
+	Date       string  `json:"date"`
+	Audit
+}
+
+// Rating aggregates the review scores of a product or merchant. Average is
+// Total / Count rounded to two decimals.
+type Rating struct {
+	Count   int     `json:"count"`
+	Total   int     `json:"total"`
+	Average float64 `json:"average"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "chaincode/trading/money"
+
+// SalesStats counts the sales of a merchant, or of one of its products when
+// ProductID is set, over all time or on one UTC Day. Purchases update them
+// under keys of their own, apart from the merchant and product records.
+// Revenue is the gross invoiced amount in the invoices' currency. Approved
+// returns and cancelled orders are subtracted on the day of their invoice.
+type SalesStats struct {
+	DocType    DocType     `json:"docType"`
+	MerchantID string      `json:"merchantId"`
+	ProductID  string      `json:"productId,omitempty" metadata:",optional"`
+	Day        string      `json:"day,omitempty" metadata:",optional"`
+	Orders     int         `json:"orders"`
+	UnitsSold  int         `json:"unitsSold"`
+	Revenue    money.Money `json:"revenue"`
+	Audit
+}
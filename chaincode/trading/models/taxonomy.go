@@ -0,0 +1,26 @@
+package models
+
+// MaxCategoryDepth limits the category tree to top-level categories and
+// three levels of subcategories.
+const MaxCategoryDepth = 4
+
+// MerchantType is one entry of the merchant type taxonomy. Its ID is the
+// value stored in Merchant.Type and Product.MerchantType.
+type MerchantType struct {
+	DocType DocType `json:"docType"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Audit
+}
+
+// Category is a node of the product category tree. Path holds the IDs from
+// the top-level category down to this one, so a product filed under it
+// carries every ancestor and a query for any of them finds it.
+type Category struct {
+	DocType  DocType  `json:"docType"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	ParentID string   `json:"parentId,omitempty" metadata:",optional"`
+	Path     []string `json:"path"`
+	Audit
+}
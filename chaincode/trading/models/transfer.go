@@ -0,0 +1,16 @@
+package models
+
+import "chaincode/trading/money"
+
+// Transfer records balance sent from one user to another.
+type Transfer struct {
+	DocType    DocType     `json:"docType"`
+	ID         string      `json:"id"`
+	FromUserID string      `json:"fromUserId"`
+	ToUserID   string      `json:"toUserId"`
+	Amount     money.Money `json:"amount"`
+	Memo       string      `json:"memo,omitempty" metadata:",optional"`
+	TxID       string      `json:"txId"`
+	Date       string      `json:"date"`
+	Audit
+}
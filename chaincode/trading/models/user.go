@@ -1,11 +1,37 @@
 package models
 
+import "chaincode/trading/money"
+
+// User is the public user record. Personal data lives in UserPII in the
+// private data collection; PIIHash lets any org check a copy of it.
 type User struct {
-	DocType   DocType  `json:"docType"`
-	ID        string   `json:"id"`
-	FirstName string   `json:"firstName"`
-	LastName  string   `json:"lastName"`
-	Email     string   `json:"email"`
-	Invoices  []string `json:"invoices"`
-	Balance   float64  `json:"balance"`
+	DocType  DocType     `json:"docType"`
+	ID       string      `json:"id"`
+	PIIHash  string      `json:"piiHash"`
+	Invoices []string    `json:"invoices"`
+	Balance  money.Money `json:"balance"`
+	Owner    Owner       `json:"owner"`
+	Audit
+}
+
+// UserPII is the personal data of a user, kept in the UserPIICollection
+// private data collection. It is submitted through the transient map under
+// UserPIITransientKey as {"firstName","lastName","email","salt"}.
+type UserPII struct {
+	DocType   DocType `json:"docType"`
+	UserID    string  `json:"userId"`
+	FirstName string  `json:"firstName"`
+	LastName  string  `json:"lastName"`
+	Email     string  `json:"email"`
+	Salt      string  `json:"salt"`
+	Audit
 }
+
+const (
+	// UserPIICollection must match the name in collections_config.json.
+	UserPIICollection   = "userPII"
+	UserPIITransientKey = "user"
+	// PIISaltTransientKey carries the secret InitLedger and MigrateUserPII
+	// derive the salts of the users they write from.
+	PIISaltTransientKey = "piiSalt"
+)
@@ -0,0 +1,49 @@
+package models
+
+import "chaincode/trading/money"
+
+// DefaultVATPercent is the general Serbian PDV rate, used for products that
+// have no rate of their own or of their merchant type.
+const DefaultVATPercent = 20
+
+type VATScope string
+
+const (
+	VATScopeProduct      VATScope = "product"
+	VATScopeMerchantType VATScope = "merchantType"
+)
+
+// VATRate sets the PDV rate of one product or of every product of a merchant
+// type. A product rate takes precedence over its merchant type rate.
+type VATRate struct {
+	DocType DocType  `json:"docType"`
+	Scope   VATScope `json:"scope"`
+	Target  string   `json:"target"`
+	Percent int      `json:"percent"`
+	Audit
+}
+
+// VATLine totals the amounts taxed at one rate. Prices include VAT, so
+// Gross is what was paid and Net plus VAT always equals Gross.
+type VATLine struct {
+	Percent int         `json:"percent"`
+	Net     money.Money `json:"net"`
+	VAT     money.Money `json:"vat"`
+	Gross   money.Money `json:"gross"`
+}
+
+// VATSummary is the VAT a merchant collected in a date range: invoices
+// issued in it minus credit notes issued in it. Invoices created before VAT
+// was recorded are only counted in UntrackedInvoices.
+type VATSummary struct {
+	MerchantID        string      `json:"merchantId"`
+	From              string      `json:"from"`
+	To                string      `json:"to"`
+	Lines             []VATLine   `json:"lines"`
+	Net               money.Money `json:"net"`
+	VAT               money.Money `json:"vat"`
+	Gross             money.Money `json:"gross"`
+	Invoices          int         `json:"invoices"`
+	CreditNotes       int         `json:"creditNotes"`
+	UntrackedInvoices int         `json:"untrackedInvoices"`
+}
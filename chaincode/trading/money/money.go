@@ -0,0 +1,195 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when an amount is given without a currency code.
+const DefaultCurrency = "RSD"
+
+// Scale is the number of minor units (para, cents) in one major unit.
+const Scale = 100
+
+var (
+	ErrInvalidFormat    = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("money amount out of range")
+)
+
+// Money is an exact amount stored as integer minor units of Currency.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func Zero(currency string) Money {
+	return Money{Amount: 0, Currency: currency}
+}
+
+// Parse reads a decimal amount in major units with at most two fractional
+// digits and an optional currency code, e.g. "120", "120.5" or "120.50 EUR".
+func Parse(s string) (Money, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+
+	currency := DefaultCurrency
+	if len(fields) == 2 {
+		currency = strings.ToUpper(fields[1])
+	}
+
+	number := fields[0]
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(number, "-")
+
+	whole, frac, hasFrac := strings.Cut(number, ".")
+	if !isDigits(whole) || len(frac) > 2 || (hasFrac && !isDigits(frac)) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > math.MaxInt64/Scale-1 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+	minor, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+
+	amount := major*Scale + minor
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// String formats the amount in major units, e.g. "120.50 RSD".
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/Scale, amount%Scale, m.Currency)
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := commonCurrency(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	currency, err := commonCurrency(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (o.Amount < 0 && m.Amount > math.MaxInt64+o.Amount) || (o.Amount > 0 && m.Amount < math.MinInt64+o.Amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+
+	return Money{Amount: m.Amount - o.Amount, Currency: currency}, nil
+}
+
+// Mul multiplies the amount by a whole number, e.g. a unit price by quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	product := m.Amount * n
+	if m.Amount != 0 && (product/m.Amount != n || (m.Amount == -1 && n == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s × %d", ErrOverflow, m, n)
+	}
+
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or +1 depending on whether m is less than, equal to or
+// greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := commonCurrency(m, o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// UnmarshalJSON also accepts the bare float amounts in major units that were
+// stored before amounts became Money, so legacy ledger documents still load.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '-' || (trimmed[0] >= '0' && trimmed[0] <= '9')) {
+		f, err := strconv.ParseFloat(string(trimmed), 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidFormat, trimmed)
+		}
+
+		*m = Money{Amount: int64(math.Round(f * Scale)), Currency: DefaultCurrency}
+		return nil
+	}
+
+	type plain Money
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// commonCurrency returns the currency shared by a and b. A zero amount
+// without a currency (the zero value) takes the other operand's currency.
+func commonCurrency(a, b Money) (string, error) {
+	switch {
+	case a.Currency == b.Currency:
+		return a.Currency, nil
+	case a.Currency == "" && a.Amount == 0:
+		return b.Currency, nil
+	case b.Currency == "" && b.Amount == 0:
+		return a.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
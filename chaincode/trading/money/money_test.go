@@ -0,0 +1,161 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "120", want: New(12000, "RSD")},
+		{in: "120.5", want: New(12050, "RSD")},
+		{in: "120.50 eur", want: New(12050, "EUR")},
+		{in: "-0.05", want: New(-5, "RSD")},
+		{in: "0.00", want: New(0, "RSD")},
+		{in: "", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "1,50", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: ".50", wantErr: true},
+		{in: "1. RSD", wantErr: true},
+		{in: "1 RSD extra", wantErr: true},
+		{in: "92233720368547758", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidFormat) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidFormat", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{New(12050, "RSD"), "120.50 RSD"},
+		{New(5, "EUR"), "0.05 EUR"},
+		{New(-12050, "RSD"), "-120.50 RSD"},
+		{Zero("RSD"), "0.00 RSD"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		sum     Money
+		diff    Money
+		sumErr  error
+		diffErr error
+	}{
+		{name: "same currency", a: New(150, "RSD"), b: New(50, "RSD"), sum: New(200, "RSD"), diff: New(100, "RSD")},
+		{name: "zero value takes currency", a: Money{}, b: New(50, "EUR"), sum: New(50, "EUR"), diff: New(-50, "EUR")},
+		{name: "currency mismatch", a: New(1, "RSD"), b: New(1, "EUR"), sumErr: ErrCurrencyMismatch, diffErr: ErrCurrencyMismatch},
+		{name: "overflow up", a: New(math.MaxInt64, "RSD"), b: New(1, "RSD"), sumErr: ErrOverflow, diff: New(math.MaxInt64-1, "RSD")},
+		{name: "overflow down", a: New(math.MinInt64, "RSD"), b: New(1, "RSD"), sum: New(math.MinInt64+1, "RSD"), diffErr: ErrOverflow},
+		{name: "negative operand", a: New(math.MaxInt64, "RSD"), b: New(-1, "RSD"), sum: New(math.MaxInt64-1, "RSD"), diffErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.sumErr) || (tt.sumErr == nil && sum != tt.sum) {
+				t.Errorf("Add = %v, %v; want %v, %v", sum, err, tt.sum, tt.sumErr)
+			}
+			diff, err := tt.a.Sub(tt.b)
+			if !errors.Is(err, tt.diffErr) || (tt.diffErr == nil && diff != tt.diff) {
+				t.Errorf("Sub = %v, %v; want %v, %v", diff, err, tt.diff, tt.diffErr)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		m       Money
+		n       int64
+		want    Money
+		wantErr bool
+	}{
+		{m: New(1999, "RSD"), n: 3, want: New(5997, "RSD")},
+		{m: New(1999, "RSD"), n: -1, want: New(-1999, "RSD")},
+		{m: New(0, "RSD"), n: math.MaxInt64, want: New(0, "RSD")},
+		{m: New(math.MaxInt64/2+1, "RSD"), n: 2, wantErr: true},
+		{m: New(math.MinInt64, "RSD"), n: -1, wantErr: true},
+		{m: New(-1, "RSD"), n: math.MinInt64, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.m.Mul(tt.n)
+		if tt.wantErr {
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("%v.Mul(%d) error = %v, want ErrOverflow", tt.m, tt.n, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%v.Mul(%d) = %v, %v; want %v", tt.m, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		a, b Money
+		want int
+	}{
+		{New(1, "RSD"), New(2, "RSD"), -1},
+		{New(2, "RSD"), New(2, "RSD"), 0},
+		{New(3, "RSD"), New(2, "RSD"), 1},
+	}
+
+	for _, tt := range tests {
+		if got, err := tt.a.Cmp(tt.b); err != nil || got != tt.want {
+			t.Errorf("%v.Cmp(%v) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	if _, err := New(1, "RSD").Cmp(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp across currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{`{"amount":12050,"currency":"EUR"}`, New(12050, "EUR")},
+		{`120.5`, New(12050, DefaultCurrency)},
+		{`0.1`, New(10, DefaultCurrency)},
+		{`-3.99`, New(-399, DefaultCurrency)},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil || got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
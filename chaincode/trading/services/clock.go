@@ -0,0 +1,17 @@
+package services
+
+import "time"
+
+// Clock supplies the current time to the services. On the ledger it must be
+// backed by the transaction timestamp so that every endorsing peer computes
+// the same result; time.Now differs between peers.
+type Clock interface {
+	Now() time.Time
+}
+
+// FixedClock always reports the same instant.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
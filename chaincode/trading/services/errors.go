@@ -3,11 +3,22 @@ package services
 import "errors"
 
 var (
-	ErrInvalidInput      = errors.New("invalid input data")
-	ErrAlreadyExists     = errors.New("entity already exists")
-	ErrNotFound          = errors.New("entity not found")
-	ErrInsufficientFunds = errors.New("insufficient funds")
-	ErrInsufficientStock = errors.New("insufficient product quantity")
-	ErrInvalidAmount     = errors.New("amount must be positive")
-	ErrInvalidQuantity   = errors.New("quantity must be positive")
+	ErrInvalidInput       = errors.New("invalid input data")
+	ErrAlreadyExists      = errors.New("entity already exists")
+	ErrNotFound           = errors.New("entity not found")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInsufficientStock  = errors.New("insufficient product quantity")
+	ErrInvalidAmount      = errors.New("amount must be positive")
+	ErrInvalidQuantity    = errors.New("quantity must be positive")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrEmptyCart          = errors.New("cart has no items")
+	ErrReturnExceeds      = errors.New("return exceeds purchased quantity")
+	ErrInvalidState       = errors.New("operation not allowed in current state")
+	ErrProductExpired     = errors.New("product has expired")
+	ErrPromoUnavailable   = errors.New("promotion is not available")
+	ErrBelowMinPayout     = errors.New("amount is below the minimum payout")
+	ErrDailyLimit         = errors.New("daily payout limit exceeded")
+	ErrReleaseNotDue      = errors.New("escrow release is not due yet")
+	ErrProductDelisted    = errors.New("product is no longer for sale")
+	ErrInsufficientPoints = errors.New("insufficient loyalty points")
 )
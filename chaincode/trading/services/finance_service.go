@@ -1,8 +1,11 @@
 package services
 
-import "chaincode/trading/models"
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+)
 
-func DepositToEntity(entity interface{}, amount float64) error {
+func DepositToEntity(entity interface{}, amount money.Money) error {
 	switch e := entity.(type) {
 	case *models.User:
 		return DepositToUser(e, amount)
@@ -12,3 +15,39 @@ func DepositToEntity(entity interface{}, amount float64) error {
 		return ErrInvalidInput
 	}
 }
+
+// ParseAmount reads a positive decimal amount such as "150.00" or "150 RSD".
+func ParseAmount(s string) (money.Money, error) {
+	amount, err := money.Parse(s)
+	if err != nil || !amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+
+	return amount, nil
+}
+
+// credit returns balance increased by a positive amount.
+func credit(balance, amount money.Money) (money.Money, error) {
+	if !amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+
+	return balance.Add(amount)
+}
+
+// debit returns balance reduced by a positive amount it fully covers.
+func debit(balance, amount money.Money) (money.Money, error) {
+	if !amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+
+	cmp, err := balance.Cmp(amount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if cmp < 0 {
+		return money.Money{}, ErrInsufficientFunds
+	}
+
+	return balance.Sub(amount)
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NewLoyaltyProgram validates a merchant's points scheme. An earn percent of
+// zero stops the merchant's purchases from earning points.
+func NewLoyaltyProgram(merchantID string, earnPercent, expiryDays int, platformWide bool) (*models.LoyaltyProgram, error) {
+	var v validator
+	v.required(merchantID, "merchantId")
+	v.check(earnPercent >= 0 && earnPercent <= 100, "earnPercent", "must be between 0 and 100")
+	v.check(expiryDays >= 1 && expiryDays <= 3650, "expiryDays", "must be between 1 and 3650")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return &models.LoyaltyProgram{
+		DocType:      models.DocTypeLoyaltyProgram,
+		MerchantID:   merchantID,
+		EarnPercent:  earnPercent,
+		ExpiryDays:   expiryDays,
+		PlatformWide: platformWide,
+	}, nil
+}
+
+// NewLoyaltyAccount returns the account of a user who has no points yet.
+func NewLoyaltyAccount(userID string) *models.LoyaltyAccount {
+	return &models.LoyaltyAccount{
+		DocType: models.DocTypeLoyaltyAccount,
+		UserID:  userID,
+		Lots:    []models.PointLot{},
+	}
+}
+
+// Loyalty carries a user's loyalty account through one transaction.
+// Programs holds the programs of the merchants involved; merchants without
+// one award no points. Redeem is the number of points a purchase pays with.
+// Every change to the account is recorded in Entries, whose IDs are EntryID
+// followed by a sequence number.
+type Loyalty struct {
+	Account  *models.LoyaltyAccount
+	Programs map[string]*models.LoyaltyProgram
+	Redeem   int
+	EntryID  string
+	Entries  []*models.LoyaltyEntry
+}
+
+// PaidAmount is the part of an invoice's total paid from the buyer's
+// balance rather than with points.
+func PaidAmount(invoice *models.Invoice) money.Money {
+	paid, _ := invoice.TotalPrice.Sub(money.New(int64(invoice.PointsRedeemed), invoice.TotalPrice.Currency))
+	return paid
+}
+
+// ExpirePoints drops the lots that expired by clock.Now() and records them
+// in a single expired entry.
+func ExpirePoints(clock Clock, l *Loyalty) {
+	now := clock.Now()
+
+	lots := make([]models.PointLot, 0, len(l.Account.Lots))
+	expired := 0
+	for _, lot := range l.Account.Lots {
+		if expiresAt, err := time.Parse(time.RFC3339, lot.ExpiresAt); err == nil && !now.Before(expiresAt) {
+			expired += lot.Points
+			continue
+		}
+		lots = append(lots, lot)
+	}
+	if expired == 0 {
+		return
+	}
+
+	l.Account.Lots = lots
+	recordEntry(clock, l, models.LoyaltyExpired, -expired, nil, "")
+}
+
+// planRedemption splits l.Redeem over the invoices of merchantOrder. Each
+// invoice takes at most its total, from the lots usable at its merchant,
+// soonest-expiring first. The account is left untouched; the lots that
+// would remain are returned.
+func planRedemption(l *Loyalty, merchantOrder []string, totals map[string]money.Money) (map[string]int, []models.PointLot, error) {
+	if l.Redeem < 0 {
+		return nil, nil, &ValidationError{Fields: []FieldError{{Field: "redeemPoints", Message: "must not be negative"}}}
+	}
+
+	lots := append([]models.PointLot(nil), l.Account.Lots...)
+	redeemed := make(map[string]int, len(merchantOrder))
+	left := l.Redeem
+	for _, merchantID := range merchantOrder {
+		want := left
+		if total := totals[merchantID].Amount; int64(want) > total {
+			want = int(total)
+		}
+		taken := takePoints(lots, want, func(lot *models.PointLot) bool {
+			return lot.PlatformWide || lot.MerchantID == merchantID
+		})
+		redeemed[merchantID] = taken
+		left -= taken
+	}
+	if left > 0 {
+		return nil, nil, fmt.Errorf("%w: %d of %d points can be used at these merchants", ErrInsufficientPoints, l.Redeem-left, l.Redeem)
+	}
+
+	return redeemed, compactLots(lots), nil
+}
+
+// earnPoints credits the points the money paid on invoice earns under its
+// merchant's program.
+func earnPoints(clock Clock, l *Loyalty, invoice *models.Invoice) {
+	program := l.Programs[invoice.MerchantID]
+	if program == nil {
+		return
+	}
+
+	points := int(PaidAmount(invoice).Amount * int64(program.EarnPercent) / 100)
+	if points <= 0 {
+		return
+	}
+
+	expiresAt := clock.Now().UTC().AddDate(0, 0, program.ExpiryDays).Format(time.RFC3339)
+	entry := recordEntry(clock, l, models.LoyaltyEarned, points, invoice, expiresAt)
+	addLot(l.Account, models.PointLot{
+		EntryID:      entry.ID,
+		MerchantID:   invoice.MerchantID,
+		PlatformWide: program.PlatformWide,
+		Points:       points,
+		ExpiresAt:    expiresAt,
+	})
+	invoice.PointsEarned = points
+}
+
+// refundPoints gives back points redeemed on invoice. They can be used at
+// the invoice's merchant and are valid for its program's expiry period.
+func refundPoints(clock Clock, l *Loyalty, invoice *models.Invoice, points int) {
+	if points <= 0 {
+		return
+	}
+
+	days := models.DefaultPointsExpiryDays
+	if program := l.Programs[invoice.MerchantID]; program != nil {
+		days = program.ExpiryDays
+	}
+
+	expiresAt := clock.Now().UTC().AddDate(0, 0, days).Format(time.RFC3339)
+	entry := recordEntry(clock, l, models.LoyaltyRefunded, points, invoice, expiresAt)
+	addLot(l.Account, models.PointLot{
+		EntryID:    entry.ID,
+		MerchantID: invoice.MerchantID,
+		Points:     points,
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// reversePoints takes back points earned on invoice and returns how many of
+// them the user had already spent, which cannot be taken back.
+func reversePoints(clock Clock, l *Loyalty, invoice *models.Invoice, points int) int {
+	if points <= 0 {
+		return 0
+	}
+
+	invoice.PointsReversed += points
+	taken := takePoints(l.Account.Lots, points, func(*models.PointLot) bool { return true })
+	if taken > 0 {
+		l.Account.Lots = compactLots(l.Account.Lots)
+		recordEntry(clock, l, models.LoyaltyReversed, -taken, invoice, "")
+	}
+
+	return points - taken
+}
+
+// withholdPoints lowers the cash refund of invoice by the earned points the
+// user spent before giving the purchase back, one minor unit per point, so
+// that those points are paid for. The refund never goes below zero. It
+// returns the lowered refund and the minor units withheld.
+func withholdPoints(invoice *models.Invoice, refund money.Money, spent int) (money.Money, int) {
+	withheld := min(int64(spent), max(refund.Amount, 0))
+	refund.Amount -= withheld
+	invoice.PointsWithheld += int(withheld)
+
+	return refund, int(withheld)
+}
+
+// pointsShare is the part of points that amount of invoice's total accounts
+// for, rounded up so the shares of all units add up to at least points.
+func pointsShare(points int, amount money.Money, invoice *models.Invoice) int {
+	if points <= 0 || !invoice.TotalPrice.IsPositive() {
+		return 0
+	}
+
+	return int((amount.Amount*int64(points) + invoice.TotalPrice.Amount - 1) / invoice.TotalPrice.Amount)
+}
+
+// recordEntry applies points to the account balance and appends the
+// statement entry. invoice may be nil.
+func recordEntry(clock Clock, l *Loyalty, kind models.LoyaltyEntryKind, points int, invoice *models.Invoice, expiresAt string) *models.LoyaltyEntry {
+	l.Account.Points += points
+
+	entry := &models.LoyaltyEntry{
+		DocType:   models.DocTypeLoyaltyEntry,
+		ID:        fmt.Sprintf("%s-%03d", l.EntryID, len(l.Entries)+1),
+		UserID:    l.Account.UserID,
+		Kind:      kind,
+		Points:    points,
+		Balance:   l.Account.Points,
+		ExpiresAt: expiresAt,
+		Date:      clock.Now().Format(time.RFC3339),
+	}
+	if invoice != nil {
+		entry.MerchantID = invoice.MerchantID
+		entry.InvoiceID = invoice.ID
+	}
+	l.Entries = append(l.Entries, entry)
+
+	return entry
+}
+
+// takePoints removes up to want points from the usable lots, in order, and
+// returns how many it took. Emptied lots stay until compactLots.
+func takePoints(lots []models.PointLot, want int, usable func(*models.PointLot) bool) int {
+	taken := 0
+	for i := range lots {
+		if taken == want {
+			break
+		}
+		if !usable(&lots[i]) {
+			continue
+		}
+
+		n := min(lots[i].Points, want-taken)
+		lots[i].Points -= n
+		taken += n
+	}
+
+	return taken
+}
+
+func compactLots(lots []models.PointLot) []models.PointLot {
+	kept := make([]models.PointLot, 0, len(lots))
+	for _, lot := range lots {
+		if lot.Points > 0 {
+			kept = append(kept, lot)
+		}
+	}
+
+	return kept
+}
+
+// addLot keeps the account's lots ordered soonest-expiring first.
+func addLot(account *models.LoyaltyAccount, lot models.PointLot) {
+	account.Lots = append(account.Lots, lot)
+	sort.SliceStable(account.Lots, func(i, j int) bool {
+		return account.Lots[i].ExpiresAt < account.Lots[j].ExpiresAt
+	})
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newLoyalty(programs ...*models.LoyaltyProgram) *Loyalty {
+	l := &Loyalty{
+		Account:  NewLoyaltyAccount("USER1"),
+		Programs: map[string]*models.LoyaltyProgram{},
+		EntryID:  "LE1",
+	}
+	for _, p := range programs {
+		l.Programs[p.MerchantID] = p
+	}
+	return l
+}
+
+func lot(merchantID string, points int, platformWide bool) models.PointLot {
+	return models.PointLot{MerchantID: merchantID, PlatformWide: platformWide, Points: points, ExpiresAt: "2026-12-01T00:00:00Z"}
+}
+
+func TestNewLoyaltyProgram(t *testing.T) {
+	tests := []struct {
+		merchantID  string
+		earnPercent int
+		expiryDays  int
+		wantErr     bool
+	}{
+		{merchantID: "MERCHANT1", earnPercent: 5, expiryDays: 30},
+		{merchantID: "MERCHANT1", earnPercent: 0, expiryDays: 1},
+		{merchantID: "", earnPercent: 5, expiryDays: 30, wantErr: true},
+		{merchantID: "MERCHANT1", earnPercent: 101, expiryDays: 30, wantErr: true},
+		{merchantID: "MERCHANT1", earnPercent: 5, expiryDays: 0, wantErr: true},
+		{merchantID: "MERCHANT1", earnPercent: 5, expiryDays: 3651, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewLoyaltyProgram(tt.merchantID, tt.earnPercent, tt.expiryDays, false)
+		if tt.wantErr != errors.Is(err, ErrInvalidInput) || (!tt.wantErr && err != nil) {
+			t.Errorf("NewLoyaltyProgram(%q, %d, %d) error = %v", tt.merchantID, tt.earnPercent, tt.expiryDays, err)
+		}
+	}
+}
+
+func TestPurchaseEarnsAndRedeems(t *testing.T) {
+	program := &models.LoyaltyProgram{MerchantID: "MERCHANT1", EarnPercent: 5, ExpiryDays: 30}
+
+	tests := []struct {
+		name     string
+		lots     []models.PointLot
+		redeem   int
+		wantErr  error
+		wantPaid int64
+		// Points earned and redeemed on the invoice, and left on the account.
+		wantEarned   int
+		wantRedeemed int
+		wantPoints   int
+	}{
+		{name: "earns on the amount paid", wantPaid: 12000, wantEarned: 600, wantPoints: 600},
+		{
+			name: "redeems points of the merchant", lots: []models.PointLot{lot("MERCHANT1", 1500, false)}, redeem: 1000,
+			wantPaid: 11000, wantEarned: 550, wantRedeemed: 1000, wantPoints: 1050,
+		},
+		{
+			name: "redeems platform-wide points", lots: []models.PointLot{lot("MERCHANT2", 1000, true)}, redeem: 1000,
+			wantPaid: 11000, wantEarned: 550, wantRedeemed: 1000, wantPoints: 550,
+		},
+		{
+			name: "redeems no more than the total", lots: []models.PointLot{lot("MERCHANT1", 20000, false)}, redeem: 15000,
+			wantErr: ErrInsufficientPoints,
+		},
+		{
+			name: "other merchant's points", lots: []models.PointLot{lot("MERCHANT2", 1000, false)}, redeem: 500,
+			wantErr: ErrInsufficientPoints,
+		},
+		{name: "negative redeem", redeem: -1, wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShop()
+			l := newLoyalty(program)
+			for _, lot := range tt.lots {
+				l.Account.Lots = append(l.Account.Lots, lot)
+				l.Account.Points += lot.Points
+			}
+			l.Redeem = tt.redeem
+			lines := []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD2", Quantity: 1}}
+
+			invoices, err := PurchaseCart(FixedClock(testNow), s.user, lines, s.products, s.merchants, Pricing{}, l, "INV1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if s.user.Balance != rsd(50000) || len(l.Entries) != 0 {
+					t.Errorf("a failed purchase changed the balance or the account")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			invoice := invoices[0]
+			if PaidAmount(invoice) != rsd(tt.wantPaid) || s.user.Balance != rsd(50000-tt.wantPaid) {
+				t.Errorf("paid %v, balance %v; want %v paid", PaidAmount(invoice), s.user.Balance, rsd(tt.wantPaid))
+			}
+			if s.merchants["MERCHANT1"].Balance != rsd(tt.wantPaid) {
+				t.Errorf("merchant balance = %v, want only the amount paid", s.merchants["MERCHANT1"].Balance)
+			}
+			if invoice.PointsEarned != tt.wantEarned || invoice.PointsRedeemed != tt.wantRedeemed {
+				t.Errorf("earned %d, redeemed %d; want %d and %d", invoice.PointsEarned, invoice.PointsRedeemed, tt.wantEarned, tt.wantRedeemed)
+			}
+			sum := 0
+			for _, lot := range l.Account.Lots {
+				sum += lot.Points
+			}
+			if l.Account.Points != tt.wantPoints || sum != tt.wantPoints {
+				t.Errorf("account has %d points in lots of %d, want %d", l.Account.Points, sum, tt.wantPoints)
+			}
+		})
+	}
+}
+
+func TestExpirePoints(t *testing.T) {
+	l := newLoyalty()
+	l.Account.Lots = []models.PointLot{
+		{Points: 300, ExpiresAt: testNow.Format(time.RFC3339)},
+		{Points: 200, ExpiresAt: testNow.Add(time.Hour).Format(time.RFC3339)},
+	}
+	l.Account.Points = 500
+
+	ExpirePoints(FixedClock(testNow), l)
+	if l.Account.Points != 200 || len(l.Account.Lots) != 1 {
+		t.Errorf("account = %d points in %d lots, want 200 in 1", l.Account.Points, len(l.Account.Lots))
+	}
+	if len(l.Entries) != 1 || l.Entries[0].Kind != models.LoyaltyExpired || l.Entries[0].Points != -300 {
+		t.Errorf("entries = %+v, want one expired entry of -300", l.Entries)
+	}
+
+	ExpirePoints(FixedClock(testNow), l)
+	if len(l.Entries) != 1 {
+		t.Errorf("expiring nothing recorded an entry")
+	}
+}
+
+// Points earned on a purchase and spent before giving it back must not be
+// kept for free: the part that cannot be reversed is withheld from the
+// refund.
+func TestReturnWithholdsSpentPoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		spent        int
+		wantRefund   int64
+		wantWithheld int
+	}{
+		{name: "points unspent", spent: 0, wantRefund: 10000},
+		{name: "points partly spent", spent: 200, wantRefund: 9800, wantWithheld: 200},
+		{name: "points all spent", spent: 500, wantRefund: 9500, wantWithheld: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShop()
+			l := newLoyalty(&models.LoyaltyProgram{MerchantID: "MERCHANT1", EarnPercent: 5, ExpiryDays: 30})
+			invoices, err := PurchaseCart(FixedClock(testNow), s.user, []models.CartLine{{ProductID: "PROD1", Quantity: 2}}, s.products, s.merchants, Pricing{}, l, "INV1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			invoice := invoices[0]
+			takePoints(l.Account.Lots, tt.spent, func(*models.PointLot) bool { return true })
+			l.Account.Lots = compactLots(l.Account.Lots)
+			l.Account.Points -= tt.spent
+
+			ret, err := RequestReturn(FixedClock(testNow), invoice, "RET1", "PROD1", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			note, err := ApproveReturn(FixedClock(testNow), ret, invoice, s.products["PROD1"], s.merchants["MERCHANT1"], s.user, l, "CN1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.user.Balance != rsd(40000+tt.wantRefund) {
+				t.Errorf("user balance = %v, want %v refunded", s.user.Balance, rsd(tt.wantRefund))
+			}
+			if s.merchants["MERCHANT1"].Balance != rsd(10000-tt.wantRefund) {
+				t.Errorf("merchant balance = %v, want the withheld part kept", s.merchants["MERCHANT1"].Balance)
+			}
+			if note.PointsWithheld != tt.wantWithheld || invoice.PointsWithheld != tt.wantWithheld {
+				t.Errorf("withheld %d on the note, %d on the invoice; want %d", note.PointsWithheld, invoice.PointsWithheld, tt.wantWithheld)
+			}
+			if l.Account.Points != 0 || invoice.PointsReversed != 500 {
+				t.Errorf("account has %d points, %d reversed; want 0 and 500", l.Account.Points, invoice.PointsReversed)
+			}
+		})
+	}
+}
+
+func TestCancelOrderWithholdsSpentPoints(t *testing.T) {
+	s := newShop()
+	l := newLoyalty(&models.LoyaltyProgram{MerchantID: "MERCHANT2", EarnPercent: 5, ExpiryDays: 30})
+	invoices, err := PurchaseCart(FixedClock(testNow), s.user, []models.CartLine{{ProductID: "PROD3", Quantity: 1}}, s.products, s.merchants, Pricing{}, l, "INV1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice := invoices[0]
+	order, err := OpenOrder(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Account.Lots, l.Account.Points = []models.PointLot{}, 0
+
+	if err := CancelOrder(FixedClock(testNow), order, invoice, s.user, s.merchants["MERCHANT2"], s.products, l); err != nil {
+		t.Fatal(err)
+	}
+	if s.user.Balance != rsd(49250) || s.merchants["MERCHANT2"].Balance != rsd(750) {
+		t.Errorf("balances = user %v, merchant %v; want 492.50 and 7.50", s.user.Balance, s.merchants["MERCHANT2"].Balance)
+	}
+	if invoice.PointsWithheld != 750 {
+		t.Errorf("withheld = %d, want 750", invoice.PointsWithheld)
+	}
+}
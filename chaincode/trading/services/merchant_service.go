@@ -2,11 +2,19 @@ package services
 
 import (
 	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"strings"
 )
 
-func CreateMerchant(id, merchantType, pib string) (*models.Merchant, error) {
-	if id == "" || merchantType == "" || pib == "" {
-		return nil, ErrInvalidInput
+// CreateMerchant validates every field and returns a *ValidationError
+// listing all of the invalid ones. merchantTypes are the types on the ledger.
+func CreateMerchant(id, merchantType, pib string, owner models.Owner, merchantTypes []string) (*models.Merchant, error) {
+	var v validator
+	v.required(id, "id")
+	v.check(ValidMerchantType(merchantType, merchantTypes), "type", "must be one of "+strings.Join(merchantTypes, ", "))
+	v.check(ValidPIB(pib), "pib", "must be 9 digits ending in a valid MOD 11,10 check digit")
+	if err := v.err(); err != nil {
+		return nil, err
 	}
 
 	merchant := &models.Merchant{
@@ -16,7 +24,8 @@ func CreateMerchant(id, merchantType, pib string) (*models.Merchant, error) {
 		PIB:             pib,
 		ProductsForSale: []string{},
 		Invoices:        []string{},
-		Balance:         0,
+		Balance:         money.Zero(money.DefaultCurrency),
+		Owner:           owner,
 	}
 
 	return merchant, nil
@@ -38,11 +47,22 @@ func AddProductsToMerchant(merchant *models.Merchant, products ...*models.Produc
 	return nil
 }
 
-func DepositToMerchant(m *models.Merchant, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
+func DepositToMerchant(m *models.Merchant, amount money.Money) error {
+	balance, err := credit(m.Balance, amount)
+	if err != nil {
+		return err
 	}
 
-	m.Balance += amount
+	m.Balance = balance
+	return nil
+}
+
+func WithdrawFromMerchant(m *models.Merchant, amount money.Money) error {
+	balance, err := debit(m.Balance, amount)
+	if err != nil {
+		return err
+	}
+
+	m.Balance = balance
 	return nil
 }
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"time"
+)
+
+// RequiresEscrow reports whether purchases from merchant are held in escrow
+// until delivery instead of crediting the merchant at once. Auto parts are
+// shipped days after the purchase, so they are.
+func RequiresEscrow(merchant *models.Merchant) bool {
+	return merchant.Type == models.MerchantTypeAutoParts
+}
+
+// DefaultEscrowPolicy returns the policy used when none is on the ledger.
+func DefaultEscrowPolicy() *models.EscrowPolicy {
+	return &models.EscrowPolicy{
+		DocType:          models.DocTypeEscrowPolicy,
+		ReleaseAfterDays: models.DefaultEscrowReleaseDays,
+	}
+}
+
+// NewEscrowPolicy validates the number of days after shipping an order may
+// be released without the buyer's confirmation.
+func NewEscrowPolicy(releaseAfterDays int) (*models.EscrowPolicy, error) {
+	var v validator
+	v.check(releaseAfterDays >= 1 && releaseAfterDays <= 365, "releaseAfterDays", "must be between 1 and 365")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return &models.EscrowPolicy{
+		DocType:          models.DocTypeEscrowPolicy,
+		ReleaseAfterDays: releaseAfterDays,
+	}, nil
+}
+
+// OpenOrder puts the payment of an escrowed invoice on hold. PurchaseCart
+// has already debited the buyer without crediting the merchant. Points
+// redeemed on the invoice are not part of the held amount.
+func OpenOrder(invoice *models.Invoice) (*models.Order, error) {
+	if invoice == nil || invoice.OrderStatus != models.OrderPending {
+		return nil, ErrInvalidInput
+	}
+
+	return &models.Order{
+		DocType:    models.DocTypeOrder,
+		ID:         invoice.ID,
+		UserID:     invoice.UserID,
+		MerchantID: invoice.MerchantID,
+		Amount:     PaidAmount(invoice),
+		Status:     models.OrderPending,
+	}, nil
+}
+
+// ShipOrder marks a pending order as shipped, which starts the escrow
+// timeout and rules out cancellation.
+func ShipOrder(clock Clock, order *models.Order, invoice *models.Invoice) error {
+	if err := checkOrderStatus(order, invoice, models.OrderPending); err != nil {
+		return err
+	}
+
+	order.ShippedAt = clock.Now().UTC().Format(time.RFC3339)
+	setOrderStatus(order, invoice, models.OrderShipped)
+
+	return nil
+}
+
+// ConfirmDelivery completes a shipped order and credits the merchant with
+// the escrowed amount.
+func ConfirmDelivery(clock Clock, order *models.Order, invoice *models.Invoice, merchant *models.Merchant) error {
+	if err := checkOrderStatus(order, invoice, models.OrderShipped); err != nil {
+		return err
+	}
+
+	return deliverOrder(clock, order, invoice, merchant)
+}
+
+// ReleaseOrder completes a shipped order the buyer has not confirmed once
+// policy.ReleaseAfterDays have passed since shipping, and credits the
+// merchant.
+func ReleaseOrder(clock Clock, policy *models.EscrowPolicy, order *models.Order, invoice *models.Invoice, merchant *models.Merchant) error {
+	if policy == nil {
+		return ErrInvalidInput
+	}
+	if err := checkOrderStatus(order, invoice, models.OrderShipped); err != nil {
+		return err
+	}
+
+	shippedAt, err := time.Parse(time.RFC3339, order.ShippedAt)
+	if err != nil {
+		return ErrInvalidState
+	}
+	due := shippedAt.AddDate(0, 0, policy.ReleaseAfterDays)
+	if clock.Now().Before(due) {
+		return fmt.Errorf("%w: order %s can be released from %s", ErrReleaseNotDue, order.ID, due.Format(time.RFC3339))
+	}
+
+	order.AutoReleased = true
+	return deliverOrder(clock, order, invoice, merchant)
+}
+
+// CancelOrder cancels an order that has not shipped yet. The buyer gets the
+// escrowed amount and the points redeemed back, loses the points earned,
+// and the invoice's units go back into stock. Earned points the buyer has
+// already spent are withheld from the escrowed amount and paid to the
+// merchant (see withholdPoints). products holds the invoice's products;
+// those the merchant has deleted since, or whose ID now names another
+// merchant's product, are missing and not restocked. loyalty is only needed
+// when the invoice redeemed or earned points.
+func CancelOrder(
+	clock Clock,
+	order *models.Order,
+	invoice *models.Invoice,
+	user *models.User,
+	merchant *models.Merchant,
+	products map[string]*models.Product,
+	loyalty *Loyalty,
+) error {
+	if user == nil || merchant == nil {
+		return ErrInvalidInput
+	}
+	if err := checkOrderStatus(order, invoice, models.OrderPending); err != nil {
+		return err
+	}
+	if loyalty == nil && (invoice.PointsRedeemed > 0 || invoice.PointsEarned > 0) {
+		return ErrInvalidInput
+	}
+	refund, withheld := order.Amount, 0
+	if loyalty != nil {
+		refundPoints(clock, loyalty, invoice, invoice.PointsRedeemed-invoice.PointsRefunded)
+		invoice.PointsRefunded = invoice.PointsRedeemed
+		spent := reversePoints(clock, loyalty, invoice, invoice.PointsEarned-invoice.PointsReversed)
+		refund, withheld = withholdPoints(invoice, refund, spent)
+	}
+	if refund.IsPositive() {
+		if err := DepositToUser(user, refund); err != nil {
+			return err
+		}
+	}
+	if withheld > 0 {
+		if err := DepositToMerchant(merchant, money.New(int64(withheld), refund.Currency)); err != nil {
+			return err
+		}
+	}
+	for _, item := range invoice.Items {
+		if product := products[item.ProductID]; product != nil {
+			product.Quantity += item.Quantity
+		}
+	}
+
+	order.CancelledAt = clock.Now().UTC().Format(time.RFC3339)
+	setOrderStatus(order, invoice, models.OrderCancelled)
+
+	return nil
+}
+
+func deliverOrder(clock Clock, order *models.Order, invoice *models.Invoice, merchant *models.Merchant) error {
+	if merchant == nil {
+		return ErrInvalidInput
+	}
+
+	if order.Amount.IsPositive() {
+		if err := DepositToMerchant(merchant, order.Amount); err != nil {
+			return err
+		}
+	}
+
+	order.DeliveredAt = clock.Now().UTC().Format(time.RFC3339)
+	setOrderStatus(order, invoice, models.OrderDelivered)
+
+	return nil
+}
+
+func checkOrderStatus(order *models.Order, invoice *models.Invoice, want models.OrderStatus) error {
+	if order == nil || invoice == nil || invoice.ID != order.ID {
+		return ErrInvalidInput
+	}
+	if order.Status != want {
+		return fmt.Errorf("%w: order %s is %s, not %s", ErrInvalidState, order.ID, order.Status, want)
+	}
+
+	return nil
+}
+
+func setOrderStatus(order *models.Order, invoice *models.Invoice, status models.OrderStatus) {
+	order.Status = status
+	invoice.OrderStatus = status
+}
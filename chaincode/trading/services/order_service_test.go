@@ -0,0 +1,103 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEscrowOrder(t *testing.T) {
+	policy := &models.EscrowPolicy{ReleaseAfterDays: 7}
+	at := func(days int) Clock { return FixedClock(testNow.AddDate(0, 0, days)) }
+
+	type step func(s *shop, order *models.Order, invoice *models.Invoice) error
+	ship := func(days int) step {
+		return func(s *shop, order *models.Order, invoice *models.Invoice) error {
+			return ShipOrder(at(days), order, invoice)
+		}
+	}
+	confirm := func(s *shop, order *models.Order, invoice *models.Invoice) error {
+		return ConfirmDelivery(at(2), order, invoice, s.merchants["MERCHANT2"])
+	}
+	release := func(days int) step {
+		return func(s *shop, order *models.Order, invoice *models.Invoice) error {
+			return ReleaseOrder(at(days), policy, order, invoice, s.merchants["MERCHANT2"])
+		}
+	}
+	cancel := func(s *shop, order *models.Order, invoice *models.Invoice) error {
+		return CancelOrder(at(1), order, invoice, s.user, s.merchants["MERCHANT2"], s.products, nil)
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		// wantErr is the error of the last step; the ones before succeed.
+		wantErr      error
+		wantStatus   models.OrderStatus
+		wantUser     int64
+		wantMerchant int64
+		wantStock    int
+	}{
+		{name: "pending", wantStatus: models.OrderPending, wantUser: 35000, wantStock: 1},
+		{name: "shipped", steps: []step{ship(1)}, wantStatus: models.OrderShipped, wantUser: 35000, wantStock: 1},
+		{name: "confirmed", steps: []step{ship(1), confirm}, wantStatus: models.OrderDelivered, wantUser: 35000, wantMerchant: 15000, wantStock: 1},
+		{name: "released when due", steps: []step{ship(1), release(8)}, wantStatus: models.OrderDelivered, wantUser: 35000, wantMerchant: 15000, wantStock: 1},
+		{name: "released early", steps: []step{ship(1), release(7)}, wantErr: ErrReleaseNotDue, wantStatus: models.OrderShipped, wantUser: 35000, wantStock: 1},
+		{name: "cancelled", steps: []step{cancel}, wantStatus: models.OrderCancelled, wantUser: 50000, wantStock: 2},
+		{name: "cancelled after shipping", steps: []step{ship(1), cancel}, wantErr: ErrInvalidState, wantStatus: models.OrderShipped, wantUser: 35000, wantStock: 1},
+		{name: "confirmed before shipping", steps: []step{confirm}, wantErr: ErrInvalidState, wantStatus: models.OrderPending, wantUser: 35000, wantStock: 1},
+		{name: "shipped twice", steps: []step{ship(1), ship(2)}, wantErr: ErrInvalidState, wantStatus: models.OrderShipped, wantUser: 35000, wantStock: 1},
+		{name: "confirmed twice", steps: []step{ship(1), confirm, confirm}, wantErr: ErrInvalidState, wantStatus: models.OrderDelivered, wantUser: 35000, wantMerchant: 15000, wantStock: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShop()
+			invoices, err := PurchaseCart(FixedClock(testNow), s.user, []models.CartLine{{ProductID: "PROD3", Quantity: 1}}, s.products, s.merchants, Pricing{}, nil, "INV1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			invoice := invoices[0]
+			order, err := OpenOrder(invoice)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, step := range tt.steps {
+				err := step(s, order, invoice)
+				if i < len(tt.steps)-1 || tt.wantErr == nil {
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+				} else if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			if order.Status != tt.wantStatus || invoice.OrderStatus != tt.wantStatus {
+				t.Errorf("status = %s (invoice %s), want %s", order.Status, invoice.OrderStatus, tt.wantStatus)
+			}
+			if s.user.Balance != rsd(tt.wantUser) || s.merchants["MERCHANT2"].Balance != rsd(tt.wantMerchant) {
+				t.Errorf("balances = user %v, merchant %v; want %v and %v",
+					s.user.Balance, s.merchants["MERCHANT2"].Balance, rsd(tt.wantUser), rsd(tt.wantMerchant))
+			}
+			if got := s.products["PROD3"].Quantity; got != tt.wantStock {
+				t.Errorf("stock = %d, want %d", got, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestReleaseOrderMarksAutoRelease(t *testing.T) {
+	order := &models.Order{ID: "INV1", Amount: rsd(100), Status: models.OrderShipped, ShippedAt: testNow.Format(time.RFC3339)}
+	invoice := &models.Invoice{ID: "INV1", OrderStatus: models.OrderShipped}
+	merchant := &models.Merchant{ID: "MERCHANT2", Balance: rsd(0)}
+
+	if err := ReleaseOrder(FixedClock(testNow.AddDate(0, 0, 3)), &models.EscrowPolicy{ReleaseAfterDays: 3}, order, invoice, merchant); err != nil {
+		t.Fatal(err)
+	}
+	if !order.AutoReleased || order.DeliveredAt == "" {
+		t.Errorf("order = %+v, want it auto-released with a delivery time", order)
+	}
+}
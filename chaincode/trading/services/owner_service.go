@@ -0,0 +1,42 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"fmt"
+)
+
+func NewOwner(mspID, id string) (models.Owner, error) {
+	if mspID == "" || id == "" {
+		return models.Owner{}, ErrInvalidInput
+	}
+
+	return models.Owner{MSPID: mspID, ID: id}, nil
+}
+
+// CheckOwner fails with ErrAccessDenied unless mspID and id match owner.
+// Records created before ownership was tracked have an empty owner and
+// match nobody until an admin assigns one.
+func CheckOwner(owner models.Owner, mspID, id string) error {
+	if owner.ID == "" || owner.MSPID != mspID || owner.ID != id {
+		return fmt.Errorf("%w: caller does not own this record", ErrAccessDenied)
+	}
+
+	return nil
+}
+
+func TransferOwnership(entity interface{}, newOwner models.Owner) error {
+	if newOwner.MSPID == "" || newOwner.ID == "" {
+		return ErrInvalidInput
+	}
+
+	switch e := entity.(type) {
+	case *models.User:
+		e.Owner = newOwner
+	case *models.Merchant:
+		e.Owner = newOwner
+	default:
+		return ErrInvalidInput
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"time"
+)
+
+// Limits that apply until an admin sets a payout policy.
+var (
+	DefaultMinPayout  = money.New(100*money.Scale, money.DefaultCurrency)
+	DefaultDailyLimit = money.New(100000*money.Scale, money.DefaultCurrency)
+)
+
+// DefaultPayoutPolicy returns the policy used when none is on the ledger.
+func DefaultPayoutPolicy() *models.PayoutPolicy {
+	return &models.PayoutPolicy{
+		DocType:    models.DocTypePayoutPolicy,
+		MinPayout:  DefaultMinPayout,
+		DailyLimit: DefaultDailyLimit,
+	}
+}
+
+// NewPayoutPolicy validates a minimum payout and a daily limit, which must
+// be positive amounts in the same currency with the limit not below the
+// minimum.
+func NewPayoutPolicy(minPayout, dailyLimit string) (*models.PayoutPolicy, error) {
+	var v validator
+	minAmount, minErr := ParseAmount(minPayout)
+	v.check(minErr == nil, "minPayout", "must be a positive amount such as 100.00")
+	limit, limitErr := ParseAmount(dailyLimit)
+	v.check(limitErr == nil, "dailyLimit", "must be a positive amount such as 100000.00")
+	if minErr == nil && limitErr == nil {
+		cmp, err := limit.Cmp(minAmount)
+		v.check(err == nil, "dailyLimit", "must be in the currency of minPayout")
+		v.check(err != nil || cmp >= 0, "dailyLimit", "must not be below minPayout")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return &models.PayoutPolicy{
+		DocType:    models.DocTypePayoutPolicy,
+		MinPayout:  minAmount,
+		DailyLimit: limit,
+	}, nil
+}
+
+// Payout debits amount from a *models.User or *models.Merchant and returns
+// the payout record. paidToday is what the entity has already been paid out
+// on the current UTC day; together with amount it must stay within the
+// policy's daily limit.
+func Payout(
+	clock Clock,
+	policy *models.PayoutPolicy,
+	entity interface{},
+	amount money.Money,
+	paidToday money.Money,
+	payoutID, reference string,
+) (*models.Payout, error) {
+	if policy == nil || payoutID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	var v validator
+	v.required(reference, "reference")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if cmp, err := amount.Cmp(policy.MinPayout); err != nil {
+		return nil, err
+	} else if cmp < 0 {
+		return nil, fmt.Errorf("%w of %s", ErrBelowMinPayout, policy.MinPayout)
+	}
+
+	total, err := paidToday.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+	if cmp, err := total.Cmp(policy.DailyLimit); err != nil {
+		return nil, err
+	} else if cmp > 0 {
+		remaining, _ := policy.DailyLimit.Sub(paidToday)
+		return nil, fmt.Errorf("%w: %s left of %s today", ErrDailyLimit, remaining, policy.DailyLimit)
+	}
+
+	payout := &models.Payout{
+		DocType:   models.DocTypePayout,
+		ID:        payoutID,
+		Amount:    amount,
+		Reference: reference,
+	}
+
+	switch e := entity.(type) {
+	case *models.User:
+		if err := WithdrawFromUser(e, amount); err != nil {
+			return nil, err
+		}
+		payout.EntityType, payout.EntityID, payout.BalanceAfter = "user", e.ID, e.Balance
+	case *models.Merchant:
+		if err := WithdrawFromMerchant(e, amount); err != nil {
+			return nil, err
+		}
+		payout.EntityType, payout.EntityID, payout.BalanceAfter = "merchant", e.ID, e.Balance
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	now := clock.Now().UTC()
+	payout.Date = now.Format(time.RFC3339)
+	payout.Day = PayoutDay(now)
+
+	return payout, nil
+}
+
+// PayoutDay is the UTC calendar day daily limits are counted in.
+func PayoutDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
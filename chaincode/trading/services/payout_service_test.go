@@ -0,0 +1,79 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"errors"
+	"testing"
+)
+
+func TestNewPayoutPolicy(t *testing.T) {
+	tests := []struct {
+		minPayout, dailyLimit string
+		wantErr               bool
+	}{
+		{minPayout: "100.00", dailyLimit: "5000.00"},
+		{minPayout: "100.00", dailyLimit: "100.00"},
+		{minPayout: "100.00", dailyLimit: "99.99", wantErr: true},
+		{minPayout: "0", dailyLimit: "5000.00", wantErr: true},
+		{minPayout: "100.00", dailyLimit: "5000.00 EUR", wantErr: true},
+		{minPayout: "abc", dailyLimit: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewPayoutPolicy(tt.minPayout, tt.dailyLimit)
+		if tt.wantErr != errors.Is(err, ErrInvalidInput) || (!tt.wantErr && err != nil) {
+			t.Errorf("NewPayoutPolicy(%q, %q) error = %v", tt.minPayout, tt.dailyLimit, err)
+		}
+	}
+}
+
+func TestPayout(t *testing.T) {
+	policy := &models.PayoutPolicy{MinPayout: rsd(10000), DailyLimit: rsd(100000)}
+
+	tests := []struct {
+		name       string
+		merchant   bool
+		amount     money.Money
+		paidToday  int64
+		reference  string
+		wantErr    error
+		wantRemain int64
+	}{
+		{name: "user", amount: rsd(20000), reference: "RS35...", wantRemain: 30000},
+		{name: "merchant", merchant: true, amount: rsd(10000), reference: "RS35...", wantRemain: 40000},
+		{name: "up to the daily limit", amount: rsd(40000), paidToday: 60000, reference: "RS35...", wantRemain: 10000},
+		{name: "over the daily limit", amount: rsd(40000), paidToday: 60001, reference: "RS35...", wantErr: ErrDailyLimit},
+		{name: "below the minimum", amount: rsd(9999), reference: "RS35...", wantErr: ErrBelowMinPayout},
+		{name: "more than the balance", amount: rsd(60000), reference: "RS35...", wantErr: ErrInsufficientFunds},
+		{name: "other currency", amount: money.New(20000, "EUR"), reference: "RS35...", wantErr: money.ErrCurrencyMismatch},
+		{name: "no reference", amount: rsd(20000), wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entity interface{} = &models.User{ID: "USER1", Balance: rsd(50000)}
+			if tt.merchant {
+				entity = &models.Merchant{ID: "MERCHANT1", Balance: rsd(50000)}
+			}
+
+			payout, err := Payout(FixedClock(testNow), policy, entity, tt.amount, rsd(tt.paidToday), "PAY1", tt.reference)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if payout.BalanceAfter != rsd(tt.wantRemain) || payout.Amount != tt.amount {
+				t.Errorf("payout of %v leaves %v, want %v", payout.Amount, payout.BalanceAfter, rsd(tt.wantRemain))
+			}
+			if payout.Day != "2026-10-17" {
+				t.Errorf("day = %q, want 2026-10-17", payout.Day)
+			}
+		})
+	}
+}
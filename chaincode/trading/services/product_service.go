@@ -2,23 +2,43 @@ package services
 
 import (
 	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// CreateProduct builds a catalog entry. expiration must be an RFC 3339
+// timestamp after clock.Now(); it is stored in UTC so CouchDB can compare
+// expirations as strings. An empty expiration defaults to one year from now.
 func CreateProduct(
+	clock Clock,
 	id string,
 	name string,
 	expiration string,
-	price float64,
+	price money.Money,
 	quantity int,
 	merchantID string,
 	merchantType string,
 ) (*models.Product, error) {
-	if id == "" || name == "" || merchantID == "" {
-		return nil, ErrInvalidInput
+	now := clock.Now()
+
+	var v validator
+	v.required(id, "id")
+	v.required(name, "name")
+	v.required(merchantID, "merchantId")
+	if expiration == "" {
+		// default expiration date: +1 year
+		expiration = now.AddDate(1, 0, 0).UTC().Format(time.RFC3339)
+	} else {
+		expiration = v.expiration(expiration, now)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
 	}
 
-	if price <= 0 {
+	if !price.IsPositive() {
 		return nil, ErrInvalidAmount
 	}
 
@@ -26,11 +46,6 @@ func CreateProduct(
 		return nil, ErrInvalidQuantity
 	}
 
-	if expiration == "" {
-		// default expiration date: +1 year
-		expiration = time.Now().AddDate(1, 0, 0).Format(time.RFC3339)
-	}
-
 	return &models.Product{
 		DocType:      models.DocTypeProduct,
 		ID:           id,
@@ -43,18 +58,31 @@ func CreateProduct(
 	}, nil
 }
 
-func AddMultipleProducts(productsData []struct {
+// expiration checks that value is an RFC 3339 timestamp after now and
+// returns it in UTC.
+func (v *validator) expiration(value string, now time.Time) string {
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.check(false, "expiration", "must be an RFC 3339 timestamp such as 2027-12-31T23:59:59Z")
+		return value
+	}
+
+	v.check(expiresAt.After(now), "expiration", "must be in the future")
+	return expiresAt.UTC().Format(time.RFC3339)
+}
+
+func AddMultipleProducts(clock Clock, productsData []struct {
 	ID           string
 	Name         string
 	Expiration   string
-	Price        float64
+	Price        money.Money
 	Quantity     int
 	MerchantID   string
 	MerchantType string
 }) ([]*models.Product, error) {
 	products := make([]*models.Product, 0, len(productsData))
 	for _, pd := range productsData {
-		p, err := CreateProduct(pd.ID, pd.Name, pd.Expiration, pd.Price, pd.Quantity, pd.MerchantID, pd.MerchantType)
+		p, err := CreateProduct(clock, pd.ID, pd.Name, pd.Expiration, pd.Price, pd.Quantity, pd.MerchantID, pd.MerchantType)
 		if err != nil {
 			return nil, err
 		}
@@ -65,6 +93,25 @@ func AddMultipleProducts(productsData []struct {
 	return products, nil
 }
 
+// IsExpired reports whether p is past its expiration at now. Products
+// without an expiration never expire. Records written before expirations
+// were validated may hold a bare date, which lasts until the end of that day
+// in UTC; anything unreadable counts as expired so it is never sold.
+func IsExpired(p *models.Product, now time.Time) bool {
+	if p.Expiration == "" {
+		return false
+	}
+
+	if expiresAt, err := time.Parse(time.RFC3339, p.Expiration); err == nil {
+		return !now.Before(expiresAt)
+	}
+	if day, err := time.Parse(time.DateOnly, p.Expiration); err == nil {
+		return !now.Before(day.AddDate(0, 0, 1))
+	}
+
+	return true
+}
+
 func ReduceProductQuantity(p *models.Product, quantity int) error {
 	if quantity <= 0 {
 		return ErrInvalidQuantity
@@ -77,3 +124,120 @@ func ReduceProductQuantity(p *models.Product, quantity int) error {
 	p.Quantity -= quantity
 	return nil
 }
+
+// RestockProduct adds quantity units to p's stock.
+func RestockProduct(p *models.Product, quantity int) ([]models.FieldChange, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	from := p.Quantity
+	p.Quantity += quantity
+
+	return []models.FieldChange{{Field: "quantity", From: strconv.Itoa(from), To: strconv.Itoa(p.Quantity)}}, nil
+}
+
+// UpdateProductPrice sets p's catalog price. The price must be positive and
+// in the currency p is already sold in. Running promotions keep applying to
+// the new price.
+func UpdateProductPrice(p *models.Product, price money.Money) ([]models.FieldChange, error) {
+	if !price.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	if price.Currency != p.Price.Currency {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "price", Message: "must be in " + p.Price.Currency}}}
+	}
+
+	from := p.Price
+	p.Price = price
+
+	return []models.FieldChange{{Field: "price", From: from.String(), To: price.String()}}, nil
+}
+
+// UpdateProductDetails changes p's name, expiration and category; empty
+// input fields are left as they are, but at least one must be set. A new
+// expiration must be in the future. category is the category named by
+// input.Category, nil if there is no such category.
+func UpdateProductDetails(clock Clock, p *models.Product, input models.ProductDetailsInput, category *models.Category) ([]models.FieldChange, error) {
+	var v validator
+	v.check(strings.TrimSpace(input.Name) != "" || input.Expiration != "" || input.Category != "",
+		"name", "is required unless expiration or category is set")
+	expiration := ""
+	if input.Expiration != "" {
+		expiration = v.expiration(input.Expiration, clock.Now())
+	}
+	if input.Category != "" {
+		v.check(category != nil, "category", fmt.Sprintf("unknown category %q", input.Category))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	changes := []models.FieldChange{}
+	if name := strings.TrimSpace(input.Name); name != "" && name != p.Name {
+		changes = append(changes, models.FieldChange{Field: "name", From: p.Name, To: name})
+		p.Name = name
+	}
+	if expiration != "" && expiration != p.Expiration {
+		changes = append(changes, models.FieldChange{Field: "expiration", From: p.Expiration, To: expiration})
+		p.Expiration = expiration
+	}
+	if category != nil && category.ID != p.Category {
+		changes = append(changes, models.FieldChange{Field: "category", From: p.Category, To: category.ID})
+		_ = AssignCategory(p, category, category.ID)
+	}
+
+	return changes, nil
+}
+
+// DelistProduct takes p off sale and out of the merchant's ProductsForSale.
+// The product stays on the ledger for its invoices and returns.
+func DelistProduct(merchant *models.Merchant, p *models.Product) ([]models.FieldChange, error) {
+	if merchant == nil || p.MerchantID != merchant.ID {
+		return nil, ErrInvalidInput
+	}
+	if p.Delisted {
+		return nil, fmt.Errorf("%w: product %s is already delisted", ErrInvalidState, p.ID)
+	}
+
+	p.Delisted = true
+	removeProductFromMerchant(merchant, p.ID)
+
+	return []models.FieldChange{{Field: "delisted", From: "false", To: "true"}}, nil
+}
+
+// DeleteProduct removes p from the merchant's ProductsForSale before the
+// product record itself is deleted.
+func DeleteProduct(merchant *models.Merchant, p *models.Product) error {
+	if merchant == nil || p.MerchantID != merchant.ID {
+		return ErrInvalidInput
+	}
+
+	removeProductFromMerchant(merchant, p.ID)
+	return nil
+}
+
+// NewProductChange describes a change to a product made by owner in txID.
+func NewProductChange(clock Clock, action models.ProductAction, fields []models.FieldChange, owner models.Owner, txID string) *models.ProductChange {
+	if fields == nil {
+		fields = []models.FieldChange{}
+	}
+
+	return &models.ProductChange{
+		Action:    action,
+		Fields:    fields,
+		ChangedBy: owner,
+		TxID:      txID,
+		Date:      clock.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func removeProductFromMerchant(merchant *models.Merchant, productID string) {
+	kept := make([]string, 0, len(merchant.ProductsForSale))
+	for _, id := range merchant.ProductsForSale {
+		if id != productID {
+			kept = append(kept, id)
+		}
+	}
+	merchant.ProductsForSale = kept
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Pricing carries the promotions a purchase may apply and the VAT rate of
+// each product. Promotions with a code only apply when PromoCode matches it.
+// Products missing from VATPercents are taxed at models.DefaultVATPercent.
+type Pricing struct {
+	Promotions  []*models.Promotion
+	PromoCode   string
+	VATPercents map[string]int
+}
+
+// CreatePromotion validates input and builds an active promotion of
+// merchantID. Invalid fields are reported together in a *ValidationError.
+func CreatePromotion(clock Clock, merchantID string, input models.PromotionInput) (*models.Promotion, error) {
+	now := clock.Now()
+
+	var v validator
+	v.required(input.ID, "id")
+	v.required(merchantID, "merchantId")
+
+	promotion := &models.Promotion{
+		DocType:    models.DocTypePromotion,
+		ID:         input.ID,
+		MerchantID: merchantID,
+		ProductID:  input.ProductID,
+		Kind:       input.Kind,
+		Code:       NormalizePromoCode(input.Code),
+		MaxUses:    input.MaxUses,
+		Active:     true,
+	}
+
+	switch input.Kind {
+	case models.DiscountPercent:
+		v.check(input.PercentOff >= 1 && input.PercentOff <= 100, "percentOff", "must be between 1 and 100")
+		v.check(input.AmountOff == "", "amountOff", "is only allowed for fixed discounts")
+		promotion.PercentOff = input.PercentOff
+	case models.DiscountFixed:
+		amount, err := ParseAmount(input.AmountOff)
+		v.check(err == nil, "amountOff", "must be a positive amount such as 50.00")
+		v.check(input.PercentOff == 0, "percentOff", "is only allowed for percent discounts")
+		promotion.AmountOff = &amount
+	default:
+		v.check(false, "kind", fmt.Sprintf("must be %q or %q", models.DiscountPercent, models.DiscountFixed))
+	}
+
+	startsAt, startErr := time.Parse(time.RFC3339, input.StartsAt)
+	v.check(startErr == nil, "startsAt", "must be an RFC 3339 timestamp")
+	endsAt, endErr := time.Parse(time.RFC3339, input.EndsAt)
+	v.check(endErr == nil, "endsAt", "must be an RFC 3339 timestamp")
+	if startErr == nil && endErr == nil {
+		v.check(endsAt.After(startsAt), "endsAt", "must be after startsAt")
+		v.check(endsAt.After(now), "endsAt", "must be in the future")
+	}
+	promotion.StartsAt = startsAt.UTC().Format(time.RFC3339)
+	promotion.EndsAt = endsAt.UTC().Format(time.RFC3339)
+
+	v.check(input.Code == "" || validPromoCode(promotion.Code), "code", "must be 4 to 32 letters, digits, '-' or '_'")
+	v.check(input.MaxUses >= 0, "maxUses", "must not be negative")
+
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// DeactivatePromotion ends a promotion before its window closes.
+func DeactivatePromotion(p *models.Promotion) error {
+	if p == nil {
+		return ErrInvalidInput
+	}
+	if !p.Active {
+		return ErrInvalidState
+	}
+
+	p.Active = false
+	return nil
+}
+
+// NormalizePromoCode makes codes case-insensitive.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PromotionEnded reports whether p can no longer apply at now or later:
+// it was deactivated, its window has closed or its uses ran out.
+func PromotionEnded(p *models.Promotion, now time.Time) bool {
+	if !p.Active || (p.MaxUses > 0 && p.Uses >= p.MaxUses) {
+		return true
+	}
+
+	endsAt, err := time.Parse(time.RFC3339, p.EndsAt)
+	return err != nil || !now.Before(endsAt)
+}
+
+// checkPromoCode reports why the promotion quoted by code cannot be used, or
+// nil if it is active and has uses left. It does not check the products.
+func checkPromoCode(p *models.Promotion, code string, now time.Time) error {
+	switch {
+	case p == nil || p.Code == "" || p.Code != NormalizePromoCode(code):
+		return fmt.Errorf("%w: unknown promo code %s", ErrPromoUnavailable, code)
+	case !p.Active || !inWindow(p, now):
+		return fmt.Errorf("%w: promo code %s is not active", ErrPromoUnavailable, code)
+	case p.MaxUses > 0 && p.Uses >= p.MaxUses:
+		return fmt.Errorf("%w: promo code %s has been used up", ErrPromoUnavailable, code)
+	}
+
+	return nil
+}
+
+// bestPromotion returns the promotion giving the largest per-unit discount
+// on product at now, and that discount. A promotion with a code is only
+// considered when it matches code. On equal discounts the quoted code wins,
+// then the lower ID, so every endorser picks the same promotion.
+func bestPromotion(promotions []*models.Promotion, product *models.Product, code string, now time.Time) (*models.Promotion, money.Money) {
+	var best *models.Promotion
+	bestDiscount := money.Zero(product.Price.Currency)
+
+	for _, p := range promotions {
+		if !appliesTo(p, product, code, now) {
+			continue
+		}
+
+		discount := unitDiscount(p, product.Price)
+		if !discount.IsPositive() {
+			continue
+		}
+		if best == nil || discount.Amount > bestDiscount.Amount ||
+			(discount.Amount == bestDiscount.Amount && preferOnTie(p, best)) {
+			best, bestDiscount = p, discount
+		}
+	}
+
+	return best, bestDiscount
+}
+
+func preferOnTie(p, best *models.Promotion) bool {
+	if (p.Code != "") != (best.Code != "") {
+		return p.Code != ""
+	}
+
+	return p.ID < best.ID
+}
+
+func appliesTo(p *models.Promotion, product *models.Product, code string, now time.Time) bool {
+	if p == nil || !p.Active || !inWindow(p, now) {
+		return false
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return false
+	}
+	if p.Code != "" && p.Code != NormalizePromoCode(code) {
+		return false
+	}
+	if p.MerchantID != product.MerchantID {
+		return false
+	}
+
+	return p.ProductID == "" || p.ProductID == product.ID
+}
+
+func inWindow(p *models.Promotion, now time.Time) bool {
+	startsAt, err := time.Parse(time.RFC3339, p.StartsAt)
+	if err != nil {
+		return false
+	}
+	endsAt, err := time.Parse(time.RFC3339, p.EndsAt)
+	if err != nil {
+		return false
+	}
+
+	return !now.Before(startsAt) && now.Before(endsAt)
+}
+
+// unitDiscount is the reduction p gives on one unit at price, rounded down
+// to whole minor units and never more than the price itself.
+func unitDiscount(p *models.Promotion, price money.Money) money.Money {
+	discount := money.Zero(price.Currency)
+
+	switch p.Kind {
+	case models.DiscountPercent:
+		discount.Amount = price.Amount * int64(p.PercentOff) / 100
+	case models.DiscountFixed:
+		if p.AmountOff == nil || p.AmountOff.Currency != price.Currency {
+			return discount
+		}
+		discount.Amount = p.AmountOff.Amount
+	}
+
+	if discount.Amount > price.Amount {
+		discount.Amount = price.Amount
+	}
+
+	return discount
+}
+
+func validPromoCode(code string) bool {
+	if len(code) < 4 || len(code) > 32 {
+		return false
+	}
+
+	for _, r := range code {
+		if !isAlnum(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,41 +2,265 @@ package services
 
 import (
 	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
 	"time"
 )
 
-func Purchase(user *models.User, product *models.Product, merchant *models.Merchant, quantity int, invoiceID string) (*models.Invoice, error) {
+func Purchase(clock Clock, user *models.User, product *models.Product, merchant *models.Merchant, quantity int, invoiceID string) (*models.Invoice, error) {
 	if quantity <= 0 {
 		return nil, ErrInvalidQuantity
 	}
 
-	if err := ReduceProductQuantity(product, quantity); err != nil {
+	invoices, err := PurchaseCart(
+		clock,
+		user,
+		[]models.CartLine{{ProductID: product.ID, Quantity: quantity}},
+		map[string]*models.Product{product.ID: product},
+		map[string]*models.Merchant{merchant.ID: merchant},
+		Pricing{},
+		nil,
+		invoiceID,
+	)
+	if err != nil {
 		return nil, err
 	}
 
-	total := product.Price * float64(quantity)
+	return invoices[0], nil
+}
 
-	if err := WithdrawFromUser(user, total); err != nil {
-		return nil, err
+// PurchaseCart buys every line of the cart or nothing. Stock, expiration and
+// funds are validated for the whole cart before any balance or quantity
+// changes; clock.Now() decides whether a product has expired and which
+// promotions are running. Each line gets the single promotion in pricing
+// with the largest discount, and every promotion used has its Uses counted
+// once. A quoted promo code must be usable on at least one line.
+// One invoice is issued per merchant, in the order merchants first appear in
+// the cart. Merchants that RequiresEscrow are not credited; their invoices
+// get OrderStatus pending and the payment waits in an order (see
+// OpenOrder). A single-merchant cart gets invoiceID as is; otherwise each
+// invoice ID is invoiceID suffixed with the merchant ID.
+// With loyalty set, loyalty.Redeem points pay part of the invoices (see
+// planRedemption) and the rest, paid from the balance, earns points under
+// each merchant's program.
+func PurchaseCart(
+	clock Clock,
+	user *models.User,
+	lines []models.CartLine,
+	products map[string]*models.Product,
+	merchants map[string]*models.Merchant,
+	pricing Pricing,
+	loyalty *Loyalty,
+	invoiceID string,
+) ([]*models.Invoice, error) {
+	if user == nil || invoiceID == "" {
+		return nil, ErrInvalidInput
+	}
+	if len(lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	// Merge repeated products so stock is checked against the full amount.
+	quantities := make(map[string]int)
+	var productOrder []string
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if _, seen := quantities[line.ProductID]; !seen {
+			productOrder = append(productOrder, line.ProductID)
+		}
+		quantities[line.ProductID] += line.Quantity
+		if quantities[line.ProductID] <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+	}
+
+	now := clock.Now()
+
+	var codePromotion *models.Promotion
+	if pricing.PromoCode != "" {
+		for _, p := range pricing.Promotions {
+			if p.Code != "" && p.Code == NormalizePromoCode(pricing.PromoCode) {
+				codePromotion = p
+			}
+		}
+		if err := checkPromoCode(codePromotion, pricing.PromoCode, now); err != nil {
+			return nil, err
+		}
+	}
+	codeUsable := false
+	applied := make(map[string]*models.Promotion)
+
+	total := money.Zero(user.Balance.Currency)
+	itemsByMerchant := make(map[string][]models.InvoiceItem)
+	merchantTotals := make(map[string]money.Money)
+	var merchantOrder []string
+	for _, productID := range productOrder {
+		product, ok := products[productID]
+		if !ok || product == nil {
+			return nil, ErrNotFound
+		}
+		if _, ok := merchants[product.MerchantID]; !ok {
+			return nil, ErrNotFound
+		}
+
+		if product.Delisted {
+			return nil, fmt.Errorf("%w: %s", ErrProductDelisted, product.ID)
+		}
+		if IsExpired(product, now) {
+			return nil, fmt.Errorf("%w: %s (expired %s)", ErrProductExpired, product.ID, product.Expiration)
+		}
+
+		quantity := quantities[productID]
+		if product.Quantity < quantity {
+			return nil, ErrInsufficientStock
+		}
+
+		if codePromotion != nil && appliesTo(codePromotion, product, pricing.PromoCode, now) {
+			codeUsable = true
+		}
+
+		item := models.InvoiceItem{
+			ProductID: product.ID,
+			Quantity:  quantity,
+			UnitPrice: product.Price,
+		}
+		if promotion, discount := bestPromotion(pricing.Promotions, product, pricing.PromoCode, now); promotion != nil {
+			listPrice := product.Price
+			lineDiscount, err := discount.Mul(int64(quantity))
+			if err != nil {
+				return nil, err
+			}
+			item.UnitPrice, _ = product.Price.Sub(discount)
+			item.ListPrice = &listPrice
+			item.Discount = &lineDiscount
+			item.PromotionID = promotion.ID
+			applied[promotion.ID] = promotion
+		}
+		lineTotal, err := item.UnitPrice.Mul(int64(quantity))
+		if err != nil {
+			return nil, err
+		}
+		item.TotalPrice = lineTotal
+
+		sum, err := total.Add(lineTotal)
+		if err != nil {
+			return nil, err
+		}
+		total = sum
+
+		if _, seen := itemsByMerchant[product.MerchantID]; !seen {
+			merchantOrder = append(merchantOrder, product.MerchantID)
+			merchantTotals[product.MerchantID] = money.Zero(total.Currency)
+		}
+		itemsByMerchant[product.MerchantID] = append(itemsByMerchant[product.MerchantID], item)
+		merchantTotals[product.MerchantID], _ = merchantTotals[product.MerchantID].Add(lineTotal)
 	}
 
-	if err := DepositToMerchant(merchant, total); err != nil {
+	if codePromotion != nil && !codeUsable {
+		return nil, fmt.Errorf("%w: promo code %s does not apply to any product in the cart", ErrPromoUnavailable, pricing.PromoCode)
+	}
+
+	redeemed := make(map[string]int)
+	var remainingLots []models.PointLot
+	if loyalty != nil && loyalty.Redeem != 0 {
+		var err error
+		if redeemed, remainingLots, err = planRedemption(loyalty, merchantOrder, merchantTotals); err != nil {
+			return nil, err
+		}
+	}
+	charge := total
+	for _, points := range redeemed {
+		charge, _ = charge.Sub(money.New(int64(points), charge.Currency))
+	}
+
+	if cmp, err := user.Balance.Cmp(charge); err != nil {
 		return nil, err
+	} else if cmp < 0 {
+		return nil, ErrInsufficientFunds
 	}
 
-	invoice := &models.Invoice{
-		DocType:    models.DocTypeInvoice,
-		ID:         invoiceID,
-		UserID:     user.ID,
-		MerchantID: merchant.ID,
-		ProductID:  product.ID,
-		Quantity:   quantity,
-		TotalPrice: total,
-		Date:       time.Now().Format(time.RFC3339),
+	// Everything is validated. A failure below still returns an error, and
+	// the contract then aborts the transaction before anything is written.
+	// A cart discounted or paid with points to nothing moves no money.
+	if charge.IsPositive() {
+		if err := WithdrawFromUser(user, charge); err != nil {
+			return nil, err
+		}
+	}
+	if remainingLots != nil {
+		loyalty.Account.Lots = remainingLots
 	}
 
-	user.Invoices = append(user.Invoices, invoice.ID)
-	merchant.Invoices = append(merchant.Invoices, invoice.ID)
+	date := now.Format(time.RFC3339)
+	invoices := make([]*models.Invoice, 0, len(merchantOrder))
+	for _, merchantID := range merchantOrder {
+		merchant := merchants[merchantID]
+		items := itemsByMerchant[merchantID]
+
+		merchantTotal := money.Zero(total.Currency)
+		merchantDiscount := money.Zero(total.Currency)
+		promoCode := ""
+		for _, item := range items {
+			if err := ReduceProductQuantity(products[item.ProductID], item.Quantity); err != nil {
+				return nil, err
+			}
+			merchantTotal, _ = merchantTotal.Add(item.TotalPrice)
+			if item.Discount != nil {
+				merchantDiscount, _ = merchantDiscount.Add(*item.Discount)
+			}
+			if codePromotion != nil && item.PromotionID == codePromotion.ID {
+				promoCode = codePromotion.Code
+			}
+		}
+
+		id := invoiceID
+		if len(merchantOrder) > 1 {
+			id = invoiceID + "-" + merchantID
+		}
+
+		invoice := &models.Invoice{
+			DocType:        models.DocTypeInvoice,
+			ID:             id,
+			UserID:         user.ID,
+			MerchantID:     merchant.ID,
+			Items:          items,
+			TotalPrice:     merchantTotal,
+			Date:           date,
+			CreditNotes:    []string{},
+			PromoCode:      promoCode,
+			PointsRedeemed: redeemed[merchantID],
+		}
+		if merchantDiscount.IsPositive() {
+			invoice.Discount = &merchantDiscount
+		}
+		applyVAT(invoice, pricing.VATPercents)
+
+		escrow := RequiresEscrow(merchant)
+		if escrow {
+			invoice.OrderStatus = models.OrderPending
+		} else if paid := PaidAmount(invoice); paid.IsPositive() {
+			if err := DepositToMerchant(merchant, paid); err != nil {
+				return nil, err
+			}
+		}
+
+		if loyalty != nil {
+			if invoice.PointsRedeemed > 0 {
+				recordEntry(clock, loyalty, models.LoyaltyRedeemed, -invoice.PointsRedeemed, invoice, "")
+			}
+			earnPoints(clock, loyalty, invoice)
+		}
+
+		user.Invoices = append(user.Invoices, invoice.ID)
+		merchant.Invoices = append(merchant.Invoices, invoice.ID)
+		invoices = append(invoices, invoice)
+	}
+
+	for _, promotion := range applied {
+		promotion.Uses++
+	}
 
-	return invoice, nil
+	return invoices, nil
 }
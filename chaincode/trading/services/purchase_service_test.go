@@ -0,0 +1,183 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+func rsd(amount int64) money.Money {
+	return money.New(amount, "RSD")
+}
+
+// shop is a small catalog: MERCHANT1 sells PROD1 and PROD2, MERCHANT2 sells
+// PROD3.
+type shop struct {
+	user      *models.User
+	products  map[string]*models.Product
+	merchants map[string]*models.Merchant
+}
+
+func newShop() *shop {
+	return &shop{
+		user: &models.User{ID: "USER1", Balance: rsd(50000)},
+		products: map[string]*models.Product{
+			"PROD1": {ID: "PROD1", Price: rsd(5000), Quantity: 10, MerchantID: "MERCHANT1"},
+			"PROD2": {ID: "PROD2", Price: rsd(2000), Quantity: 5, MerchantID: "MERCHANT1"},
+			"PROD3": {ID: "PROD3", Price: rsd(15000), Quantity: 2, MerchantID: "MERCHANT2"},
+		},
+		merchants: map[string]*models.Merchant{
+			"MERCHANT1": {ID: "MERCHANT1", Type: "supermarket", Balance: rsd(0)},
+			"MERCHANT2": {ID: "MERCHANT2", Type: "auto_parts", Balance: rsd(0)},
+		},
+	}
+}
+
+func TestPurchaseCart(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []models.CartLine
+		edit    func(s *shop)
+		pricing Pricing
+		wantErr error
+		// Totals of the issued invoices by ID, and the balances after.
+		wantInvoices  map[string]int64
+		wantUser      int64
+		wantMerchant1 int64
+		wantMerchant2 int64
+	}{
+		{
+			name:          "one merchant",
+			lines:         []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD2", Quantity: 1}},
+			wantInvoices:  map[string]int64{"INV1": 12000},
+			wantUser:      38000,
+			wantMerchant1: 12000,
+		},
+		{
+			name:          "repeated product is merged",
+			lines:         []models.CartLine{{ProductID: "PROD2", Quantity: 3}, {ProductID: "PROD2", Quantity: 2}},
+			wantInvoices:  map[string]int64{"INV1": 10000},
+			wantUser:      40000,
+			wantMerchant1: 10000,
+		},
+		{
+			name:          "escrowed merchant is not credited",
+			lines:         []models.CartLine{{ProductID: "PROD1", Quantity: 1}, {ProductID: "PROD3", Quantity: 1}},
+			wantInvoices:  map[string]int64{"INV1-MERCHANT1": 5000, "INV1-MERCHANT2": 15000},
+			wantUser:      30000,
+			wantMerchant1: 5000,
+		},
+		{
+			name:  "percent promotion",
+			lines: []models.CartLine{{ProductID: "PROD1", Quantity: 2}},
+			pricing: Pricing{Promotions: []*models.Promotion{{
+				ID: "PROMO1", MerchantID: "MERCHANT1", Kind: models.DiscountPercent, PercentOff: 10,
+				StartsAt: "2026-10-01T00:00:00Z", EndsAt: "2026-11-01T00:00:00Z", Active: true,
+			}}},
+			wantInvoices:  map[string]int64{"INV1": 9000},
+			wantUser:      41000,
+			wantMerchant1: 9000,
+		},
+		{
+			name:    "unknown promo code",
+			lines:   []models.CartLine{{ProductID: "PROD1", Quantity: 1}},
+			pricing: Pricing{PromoCode: "NOPE1"},
+			wantErr: ErrPromoUnavailable,
+		},
+		{name: "empty cart", wantErr: ErrEmptyCart},
+		{name: "zero quantity", lines: []models.CartLine{{ProductID: "PROD1"}}, wantErr: ErrInvalidQuantity},
+		{name: "unknown product", lines: []models.CartLine{{ProductID: "PROD9", Quantity: 1}}, wantErr: ErrNotFound},
+		{
+			name:    "insufficient stock across lines",
+			lines:   []models.CartLine{{ProductID: "PROD3", Quantity: 1}, {ProductID: "PROD3", Quantity: 2}},
+			wantErr: ErrInsufficientStock,
+		},
+		{
+			name:    "insufficient funds",
+			lines:   []models.CartLine{{ProductID: "PROD1", Quantity: 10}, {ProductID: "PROD3", Quantity: 1}},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name:    "delisted product",
+			lines:   []models.CartLine{{ProductID: "PROD2", Quantity: 1}},
+			edit:    func(s *shop) { s.products["PROD2"].Delisted = true },
+			wantErr: ErrProductDelisted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShop()
+			if tt.edit != nil {
+				tt.edit(s)
+			}
+
+			invoices, err := PurchaseCart(FixedClock(testNow), s.user, tt.lines, s.products, s.merchants, tt.pricing, nil, "INV1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				fresh := newShop()
+				if s.user.Balance != fresh.user.Balance || s.products["PROD1"].Quantity != fresh.products["PROD1"].Quantity {
+					t.Errorf("a failed purchase changed the balance or stock")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(invoices) != len(tt.wantInvoices) {
+				t.Fatalf("got %d invoices, want %d", len(invoices), len(tt.wantInvoices))
+			}
+			for _, invoice := range invoices {
+				if want, ok := tt.wantInvoices[invoice.ID]; !ok || invoice.TotalPrice != rsd(want) {
+					t.Errorf("invoice %s total = %v, want %v", invoice.ID, invoice.TotalPrice, rsd(want))
+				}
+				escrow := invoice.MerchantID == "MERCHANT2"
+				if escrow != (invoice.OrderStatus == models.OrderPending) {
+					t.Errorf("invoice %s order status = %q", invoice.ID, invoice.OrderStatus)
+				}
+			}
+			for name, got := range map[string]int64{
+				"user":      s.user.Balance.Amount,
+				"MERCHANT1": s.merchants["MERCHANT1"].Balance.Amount,
+				"MERCHANT2": s.merchants["MERCHANT2"].Balance.Amount,
+			} {
+				want := map[string]int64{"user": tt.wantUser, "MERCHANT1": tt.wantMerchant1, "MERCHANT2": tt.wantMerchant2}[name]
+				if got != want {
+					t.Errorf("%s balance = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPurchaseCartPromotionUses(t *testing.T) {
+	s := newShop()
+	promotion := &models.Promotion{
+		ID: "PROMO1", MerchantID: "MERCHANT1", Kind: models.DiscountFixed, AmountOff: &money.Money{Amount: 500, Currency: "RSD"},
+		Code: "JESEN26", StartsAt: "2026-10-01T00:00:00Z", EndsAt: "2026-11-01T00:00:00Z", MaxUses: 1, Active: true,
+	}
+	pricing := Pricing{Promotions: []*models.Promotion{promotion}, PromoCode: "jesen26"}
+	lines := []models.CartLine{{ProductID: "PROD1", Quantity: 2}, {ProductID: "PROD2", Quantity: 1}}
+
+	invoices, err := PurchaseCart(FixedClock(testNow), s.user, lines, s.products, s.merchants, pricing, nil, "INV1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := invoices[0]; got.TotalPrice != rsd(10500) || got.PromoCode != "JESEN26" || got.Discount == nil || *got.Discount != rsd(1500) {
+		t.Errorf("invoice = total %v, code %q, discount %v", got.TotalPrice, got.PromoCode, got.Discount)
+	}
+	if promotion.Uses != 1 {
+		t.Errorf("uses = %d, want 1 per purchase", promotion.Uses)
+	}
+
+	if _, err := PurchaseCart(FixedClock(testNow), s.user, lines, s.products, s.merchants, pricing, nil, "INV2"); !errors.Is(err, ErrPromoUnavailable) {
+		t.Errorf("used-up code: got %v, want ErrPromoUnavailable", err)
+	}
+}
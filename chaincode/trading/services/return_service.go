@@ -0,0 +1,178 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"time"
+)
+
+// RequestReturn opens a return of quantity units of productID from invoice.
+// Units already returned or awaiting approval count against the purchased
+// quantity, so concurrent requests can never exceed it.
+func RequestReturn(clock Clock, invoice *models.Invoice, returnID, productID string, quantity int) (*models.ReturnRequest, error) {
+	if invoice == nil || returnID == "" || productID == "" {
+		return nil, ErrInvalidInput
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	// The merchant of an escrowed order has not been paid until delivery.
+	if invoice.OrderStatus != "" && invoice.OrderStatus != models.OrderDelivered {
+		return nil, fmt.Errorf("%w: order %s is %s", ErrInvalidState, invoice.ID, invoice.OrderStatus)
+	}
+
+	item := findInvoiceItem(invoice, productID)
+	if item == nil {
+		return nil, ErrNotFound
+	}
+
+	if item.ReturnedQuantity+item.PendingReturnQuantity+quantity > item.Quantity {
+		return nil, ErrReturnExceeds
+	}
+
+	item.PendingReturnQuantity += quantity
+
+	// Points that paid part of the invoice are refunded as points, in
+	// proportion; they are reserved until the merchant decides.
+	amount, err := item.UnitPrice.Mul(int64(quantity))
+	if err != nil {
+		return nil, err
+	}
+	points := min(pointsShare(invoice.PointsRedeemed, amount, invoice), invoice.PointsRedeemed-invoice.PointsRefunded)
+	invoice.PointsRefunded += points
+
+	return &models.ReturnRequest{
+		DocType:     models.DocTypeReturn,
+		ID:          returnID,
+		InvoiceID:   invoice.ID,
+		UserID:      invoice.UserID,
+		MerchantID:  invoice.MerchantID,
+		ProductID:   productID,
+		Quantity:    quantity,
+		Amount:      amount,
+		Points:      points,
+		Status:      models.ReturnRequested,
+		RequestedAt: clock.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// ApproveReturn restocks the product, moves the refund from the merchant to
+// the user and links a credit note to the invoice. product is nil when the
+// merchant has deleted it or its ID now names another merchant's product;
+// the refund is still made but nothing is restocked. The part of the refund
+// in ret.Points goes back to the user's loyalty account, which also loses
+// the points the returned units earned. Those of them the user has already
+// spent are withheld from the cash refund (see withholdPoints). loyalty is
+// only needed when the invoice redeemed or earned points.
+func ApproveReturn(
+	clock Clock,
+	ret *models.ReturnRequest,
+	invoice *models.Invoice,
+	product *models.Product,
+	merchant *models.Merchant,
+	user *models.User,
+	loyalty *Loyalty,
+	creditNoteID string,
+) (*models.CreditNote, error) {
+	if ret == nil || invoice == nil || merchant == nil || user == nil || creditNoteID == "" {
+		return nil, ErrInvalidInput
+	}
+	if loyalty == nil && (ret.Points > 0 || invoice.PointsEarned > 0) {
+		return nil, ErrInvalidInput
+	}
+	if ret.Status != models.ReturnRequested {
+		return nil, ErrInvalidState
+	}
+
+	item := findInvoiceItem(invoice, ret.ProductID)
+	if item == nil || item.PendingReturnQuantity < ret.Quantity {
+		return nil, ErrInvalidState
+	}
+
+	cash, err := ret.Amount.Sub(money.New(int64(ret.Points), ret.Amount.Currency))
+	if err != nil {
+		return nil, err
+	}
+	withheld := 0
+	if loyalty != nil {
+		refundPoints(clock, loyalty, invoice, ret.Points)
+		earned := min(pointsShare(invoice.PointsEarned, ret.Amount, invoice), invoice.PointsEarned-invoice.PointsReversed)
+		cash, withheld = withholdPoints(invoice, cash, reversePoints(clock, loyalty, invoice, earned))
+	}
+	if cash.IsPositive() {
+		if err := WithdrawFromMerchant(merchant, cash); err != nil {
+			return nil, err
+		}
+		if err := DepositToUser(user, cash); err != nil {
+			return nil, err
+		}
+	}
+
+	if product != nil {
+		product.Quantity += ret.Quantity
+	}
+	item.PendingReturnQuantity -= ret.Quantity
+	item.ReturnedQuantity += ret.Quantity
+
+	now := clock.Now().Format(time.RFC3339)
+	note := &models.CreditNote{
+		DocType:    models.DocTypeCreditNote,
+		ID:         creditNoteID,
+		InvoiceID:  invoice.ID,
+		ReturnID:   ret.ID,
+		UserID:     ret.UserID,
+		MerchantID: ret.MerchantID,
+		ProductID:  ret.ProductID,
+		Quantity:   ret.Quantity,
+		Amount:     ret.Amount,
+		Points:     ret.Points,
+		Date:       now,
+
+		PointsWithheld: withheld,
+	}
+	// Invoices issued before VAT was tracked have no breakdown to refund from.
+	if len(invoice.VATBreakdown) > 0 {
+		note.VATPercent = item.VATPercent
+		note.NetAmount, note.VATAmount = SplitVAT(ret.Amount, item.VATPercent)
+	}
+
+	invoice.CreditNotes = append(invoice.CreditNotes, note.ID)
+	ret.Status = models.ReturnApproved
+	ret.CreditNoteID = note.ID
+	ret.ResolvedAt = now
+
+	return note, nil
+}
+
+// RejectReturn closes a pending return and releases its reserved units.
+func RejectReturn(clock Clock, ret *models.ReturnRequest, invoice *models.Invoice) error {
+	if ret == nil || invoice == nil {
+		return ErrInvalidInput
+	}
+	if ret.Status != models.ReturnRequested {
+		return ErrInvalidState
+	}
+
+	item := findInvoiceItem(invoice, ret.ProductID)
+	if item == nil || item.PendingReturnQuantity < ret.Quantity {
+		return ErrInvalidState
+	}
+
+	item.PendingReturnQuantity -= ret.Quantity
+	invoice.PointsRefunded -= ret.Points
+	ret.Status = models.ReturnRejected
+	ret.ResolvedAt = clock.Now().Format(time.RFC3339)
+
+	return nil
+}
+
+func findInvoiceItem(invoice *models.Invoice, productID string) *models.InvoiceItem {
+	for i := range invoice.Items {
+		if invoice.Items[i].ProductID == productID {
+			return &invoice.Items[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"testing"
+)
+
+// purchased buys 3 × PROD1 and 1 × PROD2 from MERCHANT1 for 170.00 RSD.
+func purchased(t *testing.T) (*shop, *models.Invoice) {
+	t.Helper()
+
+	s := newShop()
+	lines := []models.CartLine{{ProductID: "PROD1", Quantity: 3}, {ProductID: "PROD2", Quantity: 1}}
+	invoices, err := PurchaseCart(FixedClock(testNow), s.user, lines, s.products, s.merchants, Pricing{}, nil, "INV1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, invoices[0]
+}
+
+func TestRequestReturn(t *testing.T) {
+	tests := []struct {
+		name       string
+		productID  string
+		quantity   int
+		edit       func(invoice *models.Invoice)
+		wantErr    error
+		wantAmount int64
+	}{
+		{name: "part of a line", productID: "PROD1", quantity: 2, wantAmount: 10000},
+		{name: "whole line", productID: "PROD2", quantity: 1, wantAmount: 2000},
+		{name: "more than bought", productID: "PROD2", quantity: 2, wantErr: ErrReturnExceeds},
+		{
+			name: "pending units count", productID: "PROD1", quantity: 2,
+			edit:    func(invoice *models.Invoice) { invoice.Items[0].PendingReturnQuantity = 2 },
+			wantErr: ErrReturnExceeds,
+		},
+		{
+			name: "returned units count", productID: "PROD1", quantity: 1,
+			edit:    func(invoice *models.Invoice) { invoice.Items[0].ReturnedQuantity = 3 },
+			wantErr: ErrReturnExceeds,
+		},
+		{name: "product not on the invoice", productID: "PROD3", quantity: 1, wantErr: ErrNotFound},
+		{name: "zero quantity", productID: "PROD1", wantErr: ErrInvalidQuantity},
+		{
+			name: "undelivered order", productID: "PROD1", quantity: 1,
+			edit:    func(invoice *models.Invoice) { invoice.OrderStatus = models.OrderShipped },
+			wantErr: ErrInvalidState,
+		},
+		{
+			name: "delivered order", productID: "PROD1", quantity: 1,
+			edit:       func(invoice *models.Invoice) { invoice.OrderStatus = models.OrderDelivered },
+			wantAmount: 5000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, invoice := purchased(t)
+			if tt.edit != nil {
+				tt.edit(invoice)
+			}
+
+			ret, err := RequestReturn(FixedClock(testNow), invoice, "RET1", tt.productID, tt.quantity)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ret.Amount != rsd(tt.wantAmount) || ret.Status != models.ReturnRequested {
+				t.Errorf("return = %v %s, want %v requested", ret.Amount, ret.Status, tt.wantAmount)
+			}
+			if item := findInvoiceItem(invoice, tt.productID); item.PendingReturnQuantity < tt.quantity {
+				t.Errorf("pending = %d, want the %d units reserved", item.PendingReturnQuantity, tt.quantity)
+			}
+		})
+	}
+}
+
+func TestApproveReturn(t *testing.T) {
+	tests := []struct {
+		name         string
+		deleted      bool
+		merchantLeft int64
+		wantErr      error
+		wantStock    int
+	}{
+		{name: "refund and restock", wantStock: 9},
+		{name: "missing product is refunded only", deleted: true, wantStock: 7},
+		{name: "merchant cannot cover the refund", merchantLeft: 5000, wantErr: ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, invoice := purchased(t)
+			merchant := s.merchants["MERCHANT1"]
+			if tt.merchantLeft != 0 {
+				merchant.Balance = rsd(tt.merchantLeft)
+			}
+			ret, err := RequestReturn(FixedClock(testNow), invoice, "RET1", "PROD1", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			product := s.products["PROD1"]
+			if tt.deleted {
+				product = nil
+			}
+
+			note, err := ApproveReturn(FixedClock(testNow), ret, invoice, product, merchant, s.user, nil, "CN1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if ret.Status != models.ReturnRequested || invoice.Items[0].PendingReturnQuantity != 2 {
+					t.Errorf("a failed approval changed the return")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if note.Amount != rsd(10000) || note.InvoiceID != invoice.ID || ret.CreditNoteID != "CN1" {
+				t.Errorf("credit note = %+v", note)
+			}
+			if s.user.Balance != rsd(43000) || merchant.Balance != rsd(7000) {
+				t.Errorf("balances = user %v, merchant %v; want 430.00 RSD and 70.00 RSD", s.user.Balance, merchant.Balance)
+			}
+			if got := s.products["PROD1"].Quantity; got != tt.wantStock {
+				t.Errorf("stock = %d, want %d", got, tt.wantStock)
+			}
+			if item := invoice.Items[0]; item.ReturnedQuantity != 2 || item.PendingReturnQuantity != 0 {
+				t.Errorf("item returned %d, pending %d; want 2 and 0", item.ReturnedQuantity, item.PendingReturnQuantity)
+			}
+
+			if _, err := ApproveReturn(FixedClock(testNow), ret, invoice, product, merchant, s.user, nil, "CN2"); !errors.Is(err, ErrInvalidState) {
+				t.Errorf("second approval: got %v, want ErrInvalidState", err)
+			}
+		})
+	}
+}
+
+func TestRejectReturn(t *testing.T) {
+	s, invoice := purchased(t)
+	ret, err := RequestReturn(FixedClock(testNow), invoice, "RET1", "PROD1", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RejectReturn(FixedClock(testNow), ret, invoice); err != nil {
+		t.Fatal(err)
+	}
+	if ret.Status != models.ReturnRejected || invoice.Items[0].PendingReturnQuantity != 0 {
+		t.Errorf("return %s with %d pending, want rejected and released", ret.Status, invoice.Items[0].PendingReturnQuantity)
+	}
+	if s.user.Balance != rsd(33000) {
+		t.Errorf("user balance = %v, want it unchanged", s.user.Balance)
+	}
+	if _, err := RequestReturn(FixedClock(testNow), invoice, "RET2", "PROD1", 3); err != nil {
+		t.Errorf("released units cannot be returned again: %v", err)
+	}
+}
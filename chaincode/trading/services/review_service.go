@@ -0,0 +1,75 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// maxReviewLength bounds the text of a review, in characters.
+const maxReviewLength = 1000
+
+// ReviewPurchase records the buyer's review of product on invoice and adds
+// score to the ratings of product and merchant. Only delivered purchases can
+// be reviewed, and not products the buyer has returned in full. product must
+// still belong to the invoice's merchant.
+func ReviewPurchase(
+	clock Clock,
+	invoice *models.Invoice,
+	product *models.Product,
+	merchant *models.Merchant,
+	score int,
+	text string,
+) (*models.Review, error) {
+	if invoice == nil || product == nil || merchant == nil || merchant.ID != invoice.MerchantID {
+		return nil, ErrInvalidInput
+	}
+
+	var v validator
+	v.check(score >= models.MinReviewScore && score <= models.MaxReviewScore, "score",
+		fmt.Sprintf("must be between %d and %d", models.MinReviewScore, models.MaxReviewScore))
+	v.check(utf8.RuneCountInString(text) <= maxReviewLength, "text", fmt.Sprintf("must be at most %d characters", maxReviewLength))
+	item := findInvoiceItem(invoice, product.ID)
+	v.check(item != nil, "productId", "is not on the invoice")
+	v.check(product.MerchantID == invoice.MerchantID, "productId",
+		fmt.Sprintf("belongs to merchant %s, not to the invoice's merchant %s", product.MerchantID, invoice.MerchantID))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if invoice.OrderStatus != "" && invoice.OrderStatus != models.OrderDelivered {
+		return nil, fmt.Errorf("%w: order %s is %s", ErrInvalidState, invoice.ID, invoice.OrderStatus)
+	}
+	if item.ReturnedQuantity >= item.Quantity {
+		return nil, fmt.Errorf("%w: product %s was returned", ErrInvalidState, product.ID)
+	}
+
+	addRating(&product.Rating, score)
+	addRating(&merchant.Rating, score)
+
+	return &models.Review{
+		DocType:    models.DocTypeReview,
+		ID:         invoice.ID,
+		InvoiceID:  invoice.ID,
+		UserID:     invoice.UserID,
+		MerchantID: invoice.MerchantID,
+		ProductID:  product.ID,
+		Score:      score,
+		Text:       text,
+		Date:       clock.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// addRating counts one more score, starting the rating on the first review.
+func addRating(rating **models.Rating, score int) {
+	if *rating == nil {
+		*rating = &models.Rating{}
+	}
+
+	r := *rating
+	r.Count++
+	r.Total += score
+	r.Average = math.Round(float64(r.Total)*100/float64(r.Count)) / 100
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Sort orders of TopSellers.
+const (
+	SortByUnits   = "units"
+	SortByRevenue = "revenue"
+)
+
+const (
+	maxTopSellers = 100
+	maxSeriesDays = 366
+)
+
+// NewSalesStats returns empty statistics of docType for merchantID, or for
+// one of its products when productID is set, over all time or on day, with
+// revenue in currency.
+func NewSalesStats(docType models.DocType, merchantID, productID, day, currency string) *models.SalesStats {
+	return &models.SalesStats{
+		DocType:    docType,
+		MerchantID: merchantID,
+		ProductID:  productID,
+		Day:        day,
+		Revenue:    money.Zero(currency),
+	}
+}
+
+// InvoiceSales returns what invoice adds to the sales statistics: one order
+// with its units and total for the merchant, in total and on the invoice day,
+// and the same for each product on it.
+func InvoiceSales(invoice *models.Invoice) ([]*models.SalesStats, error) {
+	day, err := invoiceDay(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	sale := func(docType models.DocType, productID, day string, units int, revenue money.Money) *models.SalesStats {
+		s := NewSalesStats(docType, invoice.MerchantID, productID, day, invoice.TotalPrice.Currency)
+		s.Orders, s.UnitsSold, s.Revenue = 1, units, revenue
+		return s
+	}
+
+	units := 0
+	sales := make([]*models.SalesStats, 0, 2+2*len(invoice.Items))
+	for _, item := range invoice.Items {
+		units += item.Quantity
+		sales = append(sales,
+			sale(models.DocTypeProductStats, item.ProductID, "", item.Quantity, item.TotalPrice),
+			sale(models.DocTypeProductDaily, item.ProductID, day, item.Quantity, item.TotalPrice))
+	}
+	sales = append(sales,
+		sale(models.DocTypeMerchantStats, "", "", units, invoice.TotalPrice),
+		sale(models.DocTypeMerchantDaily, "", day, units, invoice.TotalPrice))
+
+	return sales, nil
+}
+
+// CancelledSales returns what cancelling the order of invoice takes back
+// from the sales statistics: everything InvoiceSales added.
+func CancelledSales(invoice *models.Invoice) ([]*models.SalesStats, error) {
+	sales, err := InvoiceSales(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sales {
+		if s.Revenue, err = s.Revenue.Mul(-1); err != nil {
+			return nil, err
+		}
+		s.Orders, s.UnitsSold = -s.Orders, -s.UnitsSold
+	}
+
+	return sales, nil
+}
+
+// ReturnedSales returns what the credit note of an approved return takes back
+// from the sales statistics of invoice: the returned units and refunded
+// amount, for the merchant and the product, in total and on the invoice day.
+// The order itself still counts.
+func ReturnedSales(invoice *models.Invoice, note *models.CreditNote) ([]*models.SalesStats, error) {
+	day, err := invoiceDay(invoice)
+	if err != nil {
+		return nil, err
+	}
+	revenue, err := note.Amount.Mul(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make([]*models.SalesStats, 0, 4)
+	for _, key := range []struct {
+		docType   models.DocType
+		productID string
+		day       string
+	}{
+		{models.DocTypeProductStats, note.ProductID, ""},
+		{models.DocTypeProductDaily, note.ProductID, day},
+		{models.DocTypeMerchantStats, "", ""},
+		{models.DocTypeMerchantDaily, "", day},
+	} {
+		s := NewSalesStats(key.docType, invoice.MerchantID, key.productID, key.day, revenue.Currency)
+		s.UnitsSold, s.Revenue = -note.Quantity, revenue
+		sales = append(sales, s)
+	}
+
+	return sales, nil
+}
+
+// AddSales adds the orders, units and revenue of sale to stats.
+func AddSales(stats, sale *models.SalesStats) error {
+	revenue, err := stats.Revenue.Add(sale.Revenue)
+	if err != nil {
+		return err
+	}
+
+	stats.Orders += sale.Orders
+	stats.UnitsSold += sale.UnitsSold
+	stats.Revenue = revenue
+
+	return nil
+}
+
+// TopSellers orders the product statistics of a merchant by units sold or
+// revenue, highest first, and keeps the first limit of them.
+func TopSellers(stats []*models.SalesStats, sortBy string, limit int) ([]*models.SalesStats, error) {
+	var v validator
+	v.check(sortBy == SortByUnits || sortBy == SortByRevenue, "sortBy", fmt.Sprintf("must be %q or %q", SortByUnits, SortByRevenue))
+	v.check(limit >= 1 && limit <= maxTopSellers, "limit", fmt.Sprintf("must be between 1 and %d", maxTopSellers))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	rank := func(s *models.SalesStats) (int64, int64) {
+		if sortBy == SortByRevenue {
+			return s.Revenue.Amount, int64(s.UnitsSold)
+		}
+		return int64(s.UnitsSold), s.Revenue.Amount
+	}
+
+	sorted := append([]*models.SalesStats{}, stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a1, a2 := rank(sorted[i])
+		b1, b2 := rank(sorted[j])
+		if a1 != b1 {
+			return a1 > b1
+		}
+		if a2 != b2 {
+			return a2 > b2
+		}
+		return sorted[i].ProductID < sorted[j].ProductID
+	})
+
+	return sorted[:min(limit, len(sorted))], nil
+}
+
+// SeriesDays lists the UTC days from from to to ("2026-10-17"), inclusive,
+// for a time series of at most a year.
+func SeriesDays(from, to string) ([]string, error) {
+	var v validator
+	fromDay, fromErr := time.Parse(time.DateOnly, from)
+	v.check(fromErr == nil, "from", "must be a date such as 2026-10-17")
+	toDay, toErr := time.Parse(time.DateOnly, to)
+	v.check(toErr == nil, "to", "must be a date such as 2026-10-17")
+	if fromErr == nil && toErr == nil {
+		v.check(!toDay.Before(fromDay), "to", "must not be before from")
+		v.check(toDay.Sub(fromDay) < maxSeriesDays*24*time.Hour, "to", fmt.Sprintf("must be within %d days of from", maxSeriesDays))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	var days []string
+	for d := fromDay; !d.After(toDay); d = d.AddDate(0, 0, 1) {
+		days = append(days, SalesDay(d))
+	}
+
+	return days, nil
+}
+
+func invoiceDay(invoice *models.Invoice) (string, error) {
+	date, err := time.Parse(time.RFC3339, invoice.Date)
+	if err != nil {
+		return "", fmt.Errorf("invoice %s has an invalid date: %v", invoice.ID, err)
+	}
+
+	return SalesDay(date), nil
+}
+
+// SalesDay is the UTC calendar day sales are bucketed by.
+func SalesDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSalesStats(t *testing.T) {
+	type totals struct {
+		orders, units int
+		revenue       int64
+	}
+
+	tests := []struct {
+		name string
+		// after changes the invoice bought by purchased and returns what it
+		// takes back from the statistics.
+		after        func(t *testing.T, s *shop, invoice *models.Invoice) []*models.SalesStats
+		wantMerchant totals
+		wantProd1    totals
+	}{
+		{
+			name:         "purchase",
+			wantMerchant: totals{1, 4, 17000},
+			wantProd1:    totals{1, 3, 15000},
+		},
+		{
+			name: "approved return",
+			after: func(t *testing.T, s *shop, invoice *models.Invoice) []*models.SalesStats {
+				ret, err := RequestReturn(FixedClock(testNow), invoice, "RET1", "PROD1", 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				note, err := ApproveReturn(FixedClock(testNow), ret, invoice, s.products["PROD1"], s.merchants["MERCHANT1"], s.user, nil, "CN1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				sales, err := ReturnedSales(invoice, note)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sales
+			},
+			wantMerchant: totals{1, 2, 7000},
+			wantProd1:    totals{1, 1, 5000},
+		},
+		{
+			name: "cancelled order",
+			after: func(t *testing.T, s *shop, invoice *models.Invoice) []*models.SalesStats {
+				sales, err := CancelledSales(invoice)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sales
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, invoice := purchased(t)
+
+			stats := map[models.DocType]map[string]*models.SalesStats{}
+			add := func(sales []*models.SalesStats) {
+				for _, sale := range sales {
+					if sale.MerchantID != "MERCHANT1" {
+						t.Errorf("sale of %s", sale.MerchantID)
+					}
+					if stats[sale.DocType] == nil {
+						stats[sale.DocType] = map[string]*models.SalesStats{}
+					}
+					key := sale.ProductID + "/" + sale.Day
+					if stats[sale.DocType][key] == nil {
+						stats[sale.DocType][key] = NewSalesStats(sale.DocType, sale.MerchantID, sale.ProductID, sale.Day, "RSD")
+					}
+					if err := AddSales(stats[sale.DocType][key], sale); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			sales, err := InvoiceSales(invoice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			add(sales)
+			if tt.after != nil {
+				add(tt.after(t, s, invoice))
+			}
+
+			for _, check := range []struct {
+				docType models.DocType
+				key     string
+				want    totals
+			}{
+				{models.DocTypeMerchantStats, "/", tt.wantMerchant},
+				{models.DocTypeMerchantDaily, "/2026-10-17", tt.wantMerchant},
+				{models.DocTypeProductStats, "PROD1/", tt.wantProd1},
+				{models.DocTypeProductDaily, "PROD1/2026-10-17", tt.wantProd1},
+			} {
+				got := stats[check.docType][check.key]
+				if got == nil {
+					t.Errorf("no %s statistics for %q", check.docType, check.key)
+					continue
+				}
+				if (totals{got.Orders, got.UnitsSold, got.Revenue.Amount}) != check.want {
+					t.Errorf("%s %q = %d orders, %d units, %v; want %+v", check.docType, check.key, got.Orders, got.UnitsSold, got.Revenue, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTopSellers(t *testing.T) {
+	stats := []*models.SalesStats{
+		{ProductID: "PROD1", UnitsSold: 3, Revenue: rsd(15000)},
+		{ProductID: "PROD2", UnitsSold: 10, Revenue: rsd(4000)},
+		{ProductID: "PROD3", UnitsSold: 3, Revenue: rsd(15000)},
+		{ProductID: "PROD4", UnitsSold: 1, Revenue: rsd(20000)},
+	}
+
+	tests := []struct {
+		sortBy  string
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{sortBy: SortByUnits, limit: 10, want: []string{"PROD2", "PROD1", "PROD3", "PROD4"}},
+		{sortBy: SortByRevenue, limit: 10, want: []string{"PROD4", "PROD1", "PROD3", "PROD2"}},
+		{sortBy: SortByRevenue, limit: 2, want: []string{"PROD4", "PROD1"}},
+		{sortBy: "name", limit: 10, wantErr: true},
+		{sortBy: SortByUnits, limit: 0, wantErr: true},
+		{sortBy: SortByUnits, limit: maxTopSellers + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := TopSellers(stats, tt.sortBy, tt.limit)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("TopSellers(%q, %d) error = %v, want ErrInvalidInput", tt.sortBy, tt.limit, err)
+			}
+			continue
+		}
+		var ids []string
+		for _, s := range got {
+			ids = append(ids, s.ProductID)
+		}
+		if err != nil || !slices.Equal(ids, tt.want) {
+			t.Errorf("TopSellers(%q, %d) = %v, %v; want %v", tt.sortBy, tt.limit, ids, err, tt.want)
+		}
+	}
+	if stats[0].ProductID != "PROD1" {
+		t.Errorf("TopSellers reordered its input")
+	}
+}
+
+func TestSeriesDays(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantLen  int
+		wantErr  bool
+	}{
+		{from: "2026-10-17", to: "2026-10-17", wantLen: 1},
+		{from: "2026-02-27", to: "2026-03-01", wantLen: 3},
+		{from: "2026-01-01", to: "2026-12-31", wantLen: 365},
+		{from: "2026-01-01", to: "2027-01-02", wantErr: true},
+		{from: "2026-10-18", to: "2026-10-17", wantErr: true},
+		{from: "17.10.2026", to: "2026-10-17", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		days, err := SeriesDays(tt.from, tt.to)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("SeriesDays(%s, %s) error = %v, want ErrInvalidInput", tt.from, tt.to, err)
+			}
+			continue
+		}
+		if err != nil || len(days) != tt.wantLen || days[0] != tt.from || days[len(days)-1] != tt.to {
+			t.Errorf("SeriesDays(%s, %s) = %d days, %v; want %d", tt.from, tt.to, len(days), err, tt.wantLen)
+		}
+	}
+}
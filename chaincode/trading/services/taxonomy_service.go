@@ -0,0 +1,63 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"fmt"
+	"strings"
+)
+
+// NewMerchantType validates an entry of the merchant type taxonomy.
+func NewMerchantType(id, name string) (*models.MerchantType, error) {
+	var v validator
+	v.check(ValidTaxonomyID(id), "id", "must be 1-40 lower-case letters, digits or underscores")
+	v.required(name, "name")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return &models.MerchantType{
+		DocType: models.DocTypeMerchantType,
+		ID:      id,
+		Name:    strings.TrimSpace(name),
+	}, nil
+}
+
+// NewCategory validates a product category. parent is nil for a top-level
+// category; the new category may not be deeper than MaxCategoryDepth.
+func NewCategory(id, name string, parent *models.Category) (*models.Category, error) {
+	var v validator
+	v.check(ValidTaxonomyID(id), "id", "must be 1-40 lower-case letters, digits or underscores")
+	v.required(name, "name")
+	if parent != nil {
+		v.check(len(parent.Path) < models.MaxCategoryDepth, "parentId",
+			fmt.Sprintf("categories may be nested at most %d levels deep", models.MaxCategoryDepth))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	category := &models.Category{
+		DocType: models.DocTypeCategory,
+		ID:      id,
+		Name:    strings.TrimSpace(name),
+		Path:    []string{id},
+	}
+	if parent != nil {
+		category.ParentID = parent.ID
+		category.Path = append(append([]string{}, parent.Path...), id)
+	}
+
+	return category, nil
+}
+
+// AssignCategory files p under category. category is nil when no category
+// with the requested ID exists.
+func AssignCategory(p *models.Product, category *models.Category, categoryID string) error {
+	if category == nil {
+		return &ValidationError{Fields: []FieldError{{Field: "category", Message: fmt.Sprintf("unknown category %q", categoryID)}}}
+	}
+
+	p.Category = category.ID
+	p.CategoryPath = append([]string{}, category.Path...)
+	return nil
+}
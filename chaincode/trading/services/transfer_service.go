@@ -0,0 +1,44 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// maxMemoLength bounds the free-text note on a transfer, in characters.
+const maxMemoLength = 140
+
+// Transfer moves amount from one user's balance to another's and returns
+// the transfer record.
+func Transfer(clock Clock, from, to *models.User, amount money.Money, transferID, memo string) (*models.Transfer, error) {
+	if from == nil || to == nil || transferID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	var v validator
+	v.check(from.ID != to.ID, "toUserId", "must differ from the sender")
+	v.check(utf8.RuneCountInString(memo) <= maxMemoLength, "memo", fmt.Sprintf("must be at most %d characters", maxMemoLength))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if err := WithdrawFromUser(from, amount); err != nil {
+		return nil, err
+	}
+	if err := DepositToUser(to, amount); err != nil {
+		return nil, err
+	}
+
+	return &models.Transfer{
+		DocType:    models.DocTypeTransfer,
+		ID:         transferID,
+		FromUserID: from.ID,
+		ToUserID:   to.ID,
+		Amount:     amount,
+		Memo:       memo,
+		Date:       clock.Now().Format(time.RFC3339),
+	}, nil
+}
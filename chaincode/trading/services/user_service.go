@@ -1,60 +1,103 @@
 package services
 
-import "chaincode/trading/models"
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
 
-func CreateUser(id, firstName, lastName, email string) (*models.User, error) {
-	if id == "" || firstName == "" || lastName == "" || email == "" {
-		return nil, ErrInvalidInput
+// minSaltLength keeps the PII hash from being brute-forced from guessed
+// names and emails.
+const minSaltLength = 16
+
+// CreateUser builds the public user record and its private personal data.
+// The public record only carries the salted hash of pii. Invalid fields are
+// reported together in a *ValidationError.
+func CreateUser(id string, pii models.UserPII, owner models.Owner) (*models.User, *models.UserPII, error) {
+	var v validator
+	v.required(id, "id")
+	v.required(pii.FirstName, "firstName")
+	v.required(pii.LastName, "lastName")
+	v.check(ValidEmail(pii.Email), "email", "must be a valid address such as name@example.com")
+	v.check(len(pii.Salt) >= minSaltLength, "salt", fmt.Sprintf("must be at least %d characters", minSaltLength))
+	if err := v.err(); err != nil {
+		return nil, nil, err
 	}
 
+	pii.DocType = models.DocTypeUserPII
+	pii.UserID = id
+
 	return &models.User{
-		DocType:   models.DocTypeUser,
-		ID:        id,
-		FirstName: firstName,
-		LastName:  lastName,
-		Email:     email,
-		Invoices:  []string{},
-		Balance:   0,
-	}, nil
+		DocType:  models.DocTypeUser,
+		ID:       id,
+		PIIHash:  HashPII(pii),
+		Invoices: []string{},
+		Balance:  money.Zero(money.DefaultCurrency),
+		Owner:    owner,
+	}, &pii, nil
+}
+
+// DeriveSalt derives the salt of userID from secret, for users whose data
+// no client submitted a salt with (seeded and migrated users). The secret is
+// never stored, so the salt cannot be recomputed from public data.
+func DeriveSalt(secret, userID string) (string, error) {
+	var v validator
+	v.check(len(secret) >= minSaltLength, "piiSalt", fmt.Sprintf("must be at least %d characters", minSaltLength))
+	if err := v.err(); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(secret + "\x00" + userID))
+	return hex.EncodeToString(sum[:]), nil
 }
 
-func CreateMultipleUsers(usersData []struct {
-	ID        string
-	FirstName string
-	LastName  string
-	Email     string
-}) ([]*models.User, error) {
+// HashPII returns the hex SHA-256 of the salt followed by the personal
+// fields, each terminated by a NUL byte so field boundaries cannot shift.
+func HashPII(pii models.UserPII) string {
+	h := sha256.New()
+	for _, field := range []string{pii.Salt, pii.UserID, pii.FirstName, pii.LastName, strings.ToLower(pii.Email)} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func CreateMultipleUsers(usersData []models.UserPII, owner models.Owner) ([]*models.User, []*models.UserPII, error) {
 	users := make([]*models.User, 0, len(usersData))
+	pii := make([]*models.UserPII, 0, len(usersData))
 	for _, u := range usersData {
-		user, err := CreateUser(u.ID, u.FirstName, u.LastName, u.Email)
+		user, userPII, err := CreateUser(u.UserID, u, owner)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		users = append(users, user)
+		pii = append(pii, userPII)
 	}
 
-	return users, nil
+	return users, pii, nil
 }
 
-func DepositToUser(u *models.User, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
+func DepositToUser(u *models.User, amount money.Money) error {
+	balance, err := credit(u.Balance, amount)
+	if err != nil {
+		return err
 	}
 
-	u.Balance += amount
+	u.Balance = balance
 	return nil
 }
 
-func WithdrawFromUser(u *models.User, amount float64) error {
-	if amount <= 0 {
-		return ErrInvalidAmount
-	}
-
-	if u.Balance < amount {
-		return ErrInsufficientFunds
+func WithdrawFromUser(u *models.User, amount money.Money) error {
+	balance, err := debit(u.Balance, amount)
+	if err != nil {
+		return err
 	}
 
-	u.Balance -= amount
+	u.Balance = balance
 	return nil
 }
@@ -0,0 +1,167 @@
+package services
+
+import "strings"
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request. It unwraps to
+// ErrInvalidInput, so errors.Is checks keep working.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+
+	return ErrInvalidInput.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// validator collects field errors so a request reports all of them at once.
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) check(ok bool, field, message string) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *validator) required(value, field string) {
+	v.check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// err returns nil when every check passed.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: v.fields}
+}
+
+// ValidPIB reports whether pib is a Serbian tax ID: nine digits, the last of
+// which is the ISO 7064 MOD 11,10 check digit of the first eight.
+func ValidPIB(pib string) bool {
+	if len(pib) != 9 || !isDigits(pib) {
+		return false
+	}
+
+	p := 10
+	for _, r := range pib[:8] {
+		s := (int(r-'0') + p) % 10
+		if s == 0 {
+			s = 10
+		}
+		p = (2 * s) % 11
+	}
+
+	return int(pib[8]-'0') == (11-p)%10
+}
+
+// ValidEmail reports whether email is a plain addr-spec: a dot-atom local
+// part and a domain of at least two DNS labels. Quoted local parts, comments
+// and IP literals are deliberately not accepted.
+func ValidEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || len(local) == 0 || len(local) > 64 || strings.Contains(domain, "@") {
+		return false
+	}
+
+	for _, atom := range strings.Split(local, ".") {
+		if atom == "" || strings.IndexFunc(atom, func(r rune) bool { return !isAtext(r) }) >= 0 {
+			return false
+		}
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if !validDNSLabel(label) {
+			return false
+		}
+	}
+	tld := labels[len(labels)-1]
+
+	return len(tld) >= 2 && !isDigits(tld)
+}
+
+// ValidMerchantType reports whether t is one of the merchant types on the
+// ledger.
+func ValidMerchantType(t string, merchantTypes []string) bool {
+	for _, allowed := range merchantTypes {
+		if t == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAtext reports whether r may appear in an RFC 5322 dot-atom.
+func isAtext(r rune) bool {
+	return r < 0x80 && (isAlnum(r) || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r))
+}
+
+func validDNSLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+
+	for _, r := range label {
+		if !isAlnum(r) && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidTaxonomyID reports whether id can name a merchant type or category:
+// 1 to 40 lower-case letters, digits and underscores, e.g. "auto_parts".
+func ValidTaxonomyID(id string) bool {
+	if id == "" || len(id) > 40 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidPIB(t *testing.T) {
+	tests := []struct {
+		pib  string
+		want bool
+	}{
+		{"123456788", true},
+		{"987654328", true},
+		{"101234569", true},
+		{"123456789", false},
+		{"101234560", false},
+		{"12345678", false},
+		{"1234567888", false},
+		{"", false},
+		{"12345678a", false},
+		{"1234-5678", false},
+		{" 12345678", false},
+		{"１２３４５６７８８", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidPIB(tt.pib); got != tt.want {
+			t.Errorf("ValidPIB(%q) = %v, want %v", tt.pib, got, tt.want)
+		}
+	}
+}
+
+func TestValidEmail(t *testing.T) {
+	local64 := strings.Repeat("a", 64)
+	// domain189 is 189 characters, so local64@domain189 is exactly 254.
+	domain189 := strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 58) + ".rs"
+
+	tests := []struct {
+		name  string
+		email string
+		want  bool
+	}{
+		{"plain", "ana@example.rs", true},
+		{"dotted local part", "ana.anic+shop@mail.example.com", true},
+		{"hyphenated label", "ana@my-shop.rs", true},
+		{"quoted local part", `"ana anic"@example.rs`, false},
+		{"IP literal", "ana@[192.168.0.1]", false},
+		{"bare IP", "ana@192.168.0.1", false},
+		{"single label", "ana@localhost", false},
+		{"numeric TLD", "ana@example.123", false},
+		{"one-letter TLD", "ana@example.r", false},
+		{"no at sign", "ana.example.rs", false},
+		{"two at signs", "ana@anic@example.rs", false},
+		{"empty local part", "@example.rs", false},
+		{"leading dot", ".ana@example.rs", false},
+		{"double dot", "ana..anic@example.rs", false},
+		{"label starting with hyphen", "ana@-example.rs", false},
+		{"non-ASCII", "ana@primer.срб", false},
+		{"64-character local part", local64 + "@example.rs", true},
+		{"65-character local part", local64 + "a@example.rs", false},
+		{"254 characters", local64 + "@" + domain189, true},
+		{"255 characters", local64 + "@d" + domain189, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidEmail(tt.email); got != tt.want {
+				t.Errorf("ValidEmail(%q) = %v, want %v", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorReportsEveryField(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       func() error
+		wantFields []string
+	}{
+		{
+			name: "merchant",
+			call: func() error {
+				_, err := CreateMerchant(" ", "bakery", "12345678", models.Owner{}, models.MerchantTypes)
+				return err
+			},
+			wantFields: []string{"id", "type", "pib"},
+		},
+		{
+			name: "user",
+			call: func() error {
+				_, _, err := CreateUser("", models.UserPII{Email: "ana@localhost", Salt: "short"}, models.Owner{})
+				return err
+			},
+			wantFields: []string{"id", "firstName", "lastName", "email", "salt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("got %v, want ErrInvalidInput", err)
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got %T, want *ValidationError", err)
+			}
+			var fields []string
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+				if !strings.Contains(err.Error(), f.Field+": "+f.Message) {
+					t.Errorf("message %q does not mention %s", err.Error(), f.Field)
+				}
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/money"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewVATRate validates a PDV rate for a product or one of merchantTypes.
+func NewVATRate(scope models.VATScope, target string, percent int, merchantTypes []string) (*models.VATRate, error) {
+	var v validator
+	switch scope {
+	case models.VATScopeProduct:
+		v.required(target, "target")
+	case models.VATScopeMerchantType:
+		v.check(ValidMerchantType(target, merchantTypes), "target", "must be one of "+strings.Join(merchantTypes, ", "))
+	default:
+		v.check(false, "scope", fmt.Sprintf("must be %q or %q", models.VATScopeProduct, models.VATScopeMerchantType))
+	}
+	v.check(percent >= 0 && percent <= 100, "percent", "must be between 0 and 100")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return &models.VATRate{
+		DocType: models.DocTypeVATRate,
+		Scope:   scope,
+		Target:  target,
+		Percent: percent,
+	}, nil
+}
+
+// SplitVAT splits a VAT-inclusive amount into its net part and the VAT at
+// percent. The net part is rounded half up to whole minor units and the VAT
+// is the remainder, so the two always add up to gross.
+func SplitVAT(gross money.Money, percent int) (net, vat money.Money) {
+	divisor := int64(100 + percent)
+	net = money.New((gross.Amount*200+divisor)/(2*divisor), gross.Currency)
+	vat = money.New(gross.Amount-net.Amount, gross.Currency)
+
+	return net, vat
+}
+
+// applyVAT records the VAT of every invoice line, at the rate percents holds
+// for its product or DefaultVATPercent, and the invoice totals per rate.
+func applyVAT(invoice *models.Invoice, percents map[string]int) {
+	var lines []models.VATLine
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+
+		percent, ok := percents[item.ProductID]
+		if !ok {
+			percent = models.DefaultVATPercent
+		}
+
+		item.VATPercent = percent
+		item.NetAmount, item.VATAmount = SplitVAT(item.TotalPrice, percent)
+		lines = addVATLine(lines, percent, item.NetAmount, item.VATAmount, item.TotalPrice)
+	}
+
+	invoice.NetAmount = money.Zero(invoice.TotalPrice.Currency)
+	invoice.VATAmount = money.Zero(invoice.TotalPrice.Currency)
+	for _, line := range lines {
+		invoice.NetAmount, _ = invoice.NetAmount.Add(line.Net)
+		invoice.VATAmount, _ = invoice.VATAmount.Add(line.VAT)
+	}
+	invoice.VATBreakdown = lines
+}
+
+// SummarizeVAT totals the VAT of merchantID's invoices and credit notes dated
+// within [from, to]. noteInvoices holds the invoice of every credit note.
+// Credit notes are subtracted and cancelled orders are left out. Invoices
+// without a VAT breakdown predate VAT tracking; they and their credit notes
+// are only counted as untracked.
+func SummarizeVAT(merchantID string, from, to time.Time, invoices []*models.Invoice,
+	creditNotes []*models.CreditNote, noteInvoices map[string]*models.Invoice) (*models.VATSummary, error) {
+
+	if !to.After(from) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "to", Message: "must be after from"}}}
+	}
+
+	summary := &models.VATSummary{
+		MerchantID: merchantID,
+		From:       from.UTC().Format(time.RFC3339),
+		To:         to.UTC().Format(time.RFC3339),
+		Lines:      []models.VATLine{},
+		Net:        money.Zero(money.DefaultCurrency),
+		VAT:        money.Zero(money.DefaultCurrency),
+		Gross:      money.Zero(money.DefaultCurrency),
+	}
+	inRange := func(date string) bool {
+		t, err := time.Parse(time.RFC3339, date)
+		return err == nil && !t.Before(from) && !t.After(to)
+	}
+
+	for _, invoice := range invoices {
+		if invoice.MerchantID != merchantID || invoice.OrderStatus == models.OrderCancelled || !inRange(invoice.Date) {
+			continue
+		}
+		if len(invoice.VATBreakdown) == 0 {
+			summary.UntrackedInvoices++
+			continue
+		}
+
+		summary.Invoices++
+		for _, line := range invoice.VATBreakdown {
+			summary.Lines = addVATLine(summary.Lines, line.Percent, line.Net, line.VAT, line.Gross)
+		}
+	}
+
+	for _, note := range creditNotes {
+		invoice, ok := noteInvoices[note.InvoiceID]
+		if !ok || invoice.MerchantID != merchantID || invoice.OrderStatus == models.OrderCancelled ||
+			len(invoice.VATBreakdown) == 0 || !inRange(note.Date) {
+			continue
+		}
+		net, netErr := note.NetAmount.Mul(-1)
+		vat, vatErr := note.VATAmount.Mul(-1)
+		gross, grossErr := note.Amount.Mul(-1)
+		if err := errors.Join(netErr, vatErr, grossErr); err != nil {
+			return nil, err
+		}
+		summary.CreditNotes++
+		summary.Lines = addVATLine(summary.Lines, note.VATPercent, net, vat, gross)
+	}
+
+	for _, line := range summary.Lines {
+		var err error
+		if summary.Net, err = summary.Net.Add(line.Net); err != nil {
+			return nil, err
+		}
+		if summary.VAT, err = summary.VAT.Add(line.VAT); err != nil {
+			return nil, err
+		}
+		if summary.Gross, err = summary.Gross.Add(line.Gross); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// addVATLine adds the amounts to the line of percent, keeping lines sorted
+// by descending rate.
+func addVATLine(lines []models.VATLine, percent int, net, vat, gross money.Money) []models.VATLine {
+	for i := range lines {
+		if lines[i].Percent == percent {
+			lines[i].Net, _ = lines[i].Net.Add(net)
+			lines[i].VAT, _ = lines[i].VAT.Add(vat)
+			lines[i].Gross, _ = lines[i].Gross.Add(gross)
+			return lines
+		}
+	}
+
+	lines = append(lines, models.VATLine{Percent: percent, Net: net, VAT: vat, Gross: gross})
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Percent > lines[j].Percent })
+
+	return lines
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"chaincode/trading/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSplitVAT(t *testing.T) {
+	tests := []struct {
+		gross, net, vat int64
+		percent         int
+	}{
+		{gross: 12000, percent: 20, net: 10000, vat: 2000},
+		{gross: 11000, percent: 10, net: 10000, vat: 1000},
+		{gross: 999, percent: 20, net: 833, vat: 166},
+		{gross: 1, percent: 20, net: 1, vat: 0},
+		{gross: 5000, percent: 0, net: 5000, vat: 0},
+	}
+
+	for _, tt := range tests {
+		net, vat := SplitVAT(rsd(tt.gross), tt.percent)
+		if net != rsd(tt.net) || vat != rsd(tt.vat) {
+			t.Errorf("SplitVAT(%d, %d%%) = %v + %v, want %d + %d", tt.gross, tt.percent, net, vat, tt.net, tt.vat)
+		}
+	}
+}
+
+func TestSummarizeVATPeriod(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 31, 23, 59, 59, 0, time.UTC)
+
+	invoice := func(id, merchantID, date string, gross int64) *models.Invoice {
+		inv := &models.Invoice{ID: id, MerchantID: merchantID, Date: date, TotalPrice: rsd(gross),
+			Items: []models.InvoiceItem{{ProductID: "PROD1", Quantity: 1, UnitPrice: rsd(gross), TotalPrice: rsd(gross)}}}
+		applyVAT(inv, nil)
+		return inv
+	}
+	invoices := []*models.Invoice{
+		invoice("FIRST", "MERCHANT1", "2026-10-01T00:00:00Z", 12000),
+		invoice("LAST", "MERCHANT1", "2026-10-31T23:59:59Z", 6000),
+		invoice("BEFORE", "MERCHANT1", "2026-09-30T23:59:59Z", 24000),
+		invoice("AFTER", "MERCHANT1", "2026-11-01T00:00:00Z", 24000),
+		invoice("OTHER", "MERCHANT2", "2026-10-15T00:00:00Z", 24000),
+		{ID: "UNTRACKED", MerchantID: "MERCHANT1", Date: "2026-10-10T00:00:00Z", TotalPrice: rsd(5000)},
+	}
+	noteInvoices := make(map[string]*models.Invoice)
+	for _, inv := range invoices {
+		noteInvoices[inv.ID] = inv
+	}
+	note := func(invoiceID, date string, gross int64) *models.CreditNote {
+		n := &models.CreditNote{InvoiceID: invoiceID, MerchantID: "MERCHANT1", Date: date, Amount: rsd(gross), VATPercent: 20}
+		n.NetAmount, n.VATAmount = SplitVAT(n.Amount, n.VATPercent)
+		return n
+	}
+	creditNotes := []*models.CreditNote{
+		note("BEFORE", "2026-10-02T00:00:00Z", 1200),
+		note("FIRST", "2026-11-01T00:00:00Z", 1200),
+		note("UNTRACKED", "2026-10-11T00:00:00Z", 1200),
+	}
+
+	summary, err := SummarizeVAT("MERCHANT1", from, to, invoices, creditNotes, noteInvoices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// FIRST and LAST are on the bounds, and the note on BEFORE is dated
+	// within the period even though its invoice is not.
+	if summary.Invoices != 2 || summary.CreditNotes != 1 || summary.UntrackedInvoices != 1 {
+		t.Errorf("counted %d invoices, %d credit notes, %d untracked; want 2, 1, 1",
+			summary.Invoices, summary.CreditNotes, summary.UntrackedInvoices)
+	}
+	if summary.Gross != rsd(16800) || summary.Net != rsd(14000) || summary.VAT != rsd(2800) {
+		t.Errorf("totals = %v net + %v VAT = %v gross, want 140.00 + 28.00 = 168.00", summary.Net, summary.VAT, summary.Gross)
+	}
+	if len(summary.Lines) != 1 || summary.Lines[0].Percent != models.DefaultVATPercent {
+		t.Errorf("lines = %+v, want one at the default rate", summary.Lines)
+	}
+
+	if _, err := SummarizeVAT("MERCHANT1", to, from, invoices, creditNotes, noteInvoices); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("to before from: got %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,215 @@
+package trading
+
+import (
+	"chaincode/trading/models"
+	"chaincode/trading/services"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Secondary index object types. Their entries hold no data; the composite key
+// itself links the two IDs, e.g. merchant~product{merchantID, productID}.
+const (
+	indexMerchantProduct = "merchant~product"
+	indexMerchantInvoice = "merchant~invoice"
+	indexUserInvoice     = "user~invoice"
+	indexMerchantPromo   = "merchant~promotion"
+	indexPromoCode       = "promoCode~promotion"
+	// merchant~activePromotion lists the active promotions without a code,
+	// which purchases apply on their own.
+	indexActivePromo = "merchant~activePromotion"
+	// entity~payout{entityType, entityID, day, payoutID} and
+	// day~payout{day, payoutID}.
+	indexEntityPayout = "entity~payout"
+	indexDayPayout    = "day~payout"
+	// user~transfer{userID, date, transferID} has an entry for both the
+	// sender and the recipient.
+	indexUserTransfer = "user~transfer"
+	// user~order and merchant~order list escrowed orders.
+	indexUserOrder     = "user~order"
+	indexMerchantOrder = "merchant~order"
+	// user~loyalty{userID, date, entryID} orders a user's points statement.
+	indexUserLoyalty = "user~loyalty"
+)
+
+// indexValue is stored under secondary index keys; an empty value would
+// delete the key.
+var indexValue = []byte{0x00}
+
+// ledgerKey builds the composite key of a record: its docType as the object
+// type, followed by the attributes that identify it (usually just the ID).
+func ledgerKey(ctx contractapi.TransactionContextInterface, docType models.DocType, attrs ...string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(string(docType), attrs)
+}
+
+// getEntity reads the record identified by docType and attrs into out.
+func getEntity(ctx contractapi.TransactionContextInterface, out interface{}, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	data, err := ctx.GetStub().GetState(key)
+	if err != nil || data == nil {
+		return services.ErrNotFound
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// putEntity stamps the audit fields of entity from the transaction timestamp
+// and writes it under the key identified by docType and attrs.
+func putEntity(ctx contractapi.TransactionContextInterface, entity auditable, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	entity.Touch(clock.Now())
+	return ctx.GetStub().PutState(key, mustMarshal(entity))
+}
+
+// delEntity deletes the record identified by docType and attrs.
+func delEntity(ctx contractapi.TransactionContextInterface, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(key)
+}
+
+// requireAbsent fails with ErrAlreadyExists when the record is already on the
+// ledger, so create transactions never overwrite an existing one.
+func requireAbsent(ctx contractapi.TransactionContextInterface, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s %s", services.ErrAlreadyExists, docType, strings.Join(attrs, "/"))
+	}
+
+	return nil
+}
+
+// requireNewProduct is requireAbsent for products, naming the merchant that
+// owns the ID in the error. IDs of deleted products stay taken.
+func requireNewProduct(ctx contractapi.TransactionContextInterface, productID string) error {
+	var existing models.Product
+	err := getEntity(ctx, &existing, models.DocTypeProduct, productID)
+	if err == nil {
+		return fmt.Errorf("%w: product %s already belongs to merchant %s", services.ErrAlreadyExists, productID, existing.MerchantID)
+	}
+	if err != services.ErrNotFound {
+		return err
+	}
+
+	var deleted models.DeletedProduct
+	err = getEntity(ctx, &deleted, models.DocTypeDeletedProduct, productID)
+	if err == nil {
+		return fmt.Errorf("%w: product %s was deleted by merchant %s and its ID cannot be reused", services.ErrAlreadyExists, productID, deleted.MerchantID)
+	}
+	if err != services.ErrNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// putIndex links two records through a secondary index entry.
+func putIndex(ctx contractapi.TransactionContextInterface, index string, attrs ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(index, attrs)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, indexValue)
+}
+
+// delIndex removes a secondary index entry written by putIndex.
+func delIndex(ctx contractapi.TransactionContextInterface, index string, attrs ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(index, attrs)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState(key)
+}
+
+// indexedIDs returns the last attribute of every index entry under prefix,
+// e.g. the product IDs of one merchant for merchant~product{merchantID}.
+func indexedIDs(ctx contractapi.TransactionContextInterface, index string, prefix ...string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	ids := []string{}
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attrs, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attrs) > 0 {
+			ids = append(ids, attrs[len(attrs)-1])
+		}
+	}
+
+	return ids, nil
+}
+
+// getPrivateEntity reads a record from a private data collection into out.
+// Only peers of member orgs hold the data.
+func getPrivateEntity(ctx contractapi.TransactionContextInterface, collection string, out interface{}, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	data, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+	if data == nil {
+		return services.ErrNotFound
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// putPrivateEntity is putEntity for a private data collection. Only the hash
+// of the record reaches the channel's blocks.
+func putPrivateEntity(ctx contractapi.TransactionContextInterface, collection string, entity auditable, docType models.DocType, attrs ...string) error {
+	key, err := ledgerKey(ctx, docType, attrs...)
+	if err != nil {
+		return err
+	}
+
+	clock, err := txClock(ctx)
+	if err != nil {
+		return err
+	}
+
+	entity.Touch(clock.Now())
+	return ctx.GetStub().PutPrivateData(collection, key, mustMarshal(entity))
+}
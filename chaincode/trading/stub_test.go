@@ -0,0 +1,281 @@
+package trading
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeStub is an in-memory world state for contract tests. Unlike a peer it
+// applies writes at once, so a transaction sees its own writes; the contract
+// never relies on either behavior. Rich queries are not supported.
+type fakeStub struct {
+	shim.ChaincodeStubInterface
+	state     map[string][]byte
+	private   map[string]map[string][]byte
+	history   map[string][]*queryresult.KeyModification
+	events    map[string][]byte
+	transient map[string][]byte
+	creator   []byte
+	txID      string
+	ts        time.Time
+}
+
+func newFakeStub() *fakeStub {
+	return &fakeStub{
+		state:   map[string][]byte{},
+		private: map[string]map[string][]byte{},
+		history: map[string][]*queryresult.KeyModification{},
+		events:  map[string][]byte{},
+		txID:    "tx0",
+		ts:      time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func (s *fakeStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *fakeStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{TxId: s.txID, Value: value, Timestamp: timestamppb.New(s.ts)})
+	return nil
+}
+
+func (s *fakeStub) DelState(key string) error {
+	delete(s.state, key)
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{TxId: s.txID, IsDelete: true, Timestamp: timestamppb.New(s.ts)})
+	return nil
+}
+
+func (s *fakeStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.ts), nil
+}
+
+func (s *fakeStub) GetTxID() string                          { return s.txID }
+func (s *fakeStub) GetChannelID() string                     { return "mychannel" }
+func (s *fakeStub) GetCreator() ([]byte, error)              { return s.creator, nil }
+func (s *fakeStub) GetTransient() (map[string][]byte, error) { return s.transient, nil }
+
+// SetEvent keeps the last event of each name; a transaction sets one.
+func (s *fakeStub) SetEvent(name string, payload []byte) error {
+	s.events[name] = payload
+	return nil
+}
+
+func (s *fakeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *fakeStub) SplitCompositeKey(key string) (string, []string, error) {
+	parts := strings.Split(strings.Trim(key, "\x00"), "\x00")
+	return parts[0], parts[1:], nil
+}
+
+func (s *fakeStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return s.rangeIterator(startKey, endKey), nil
+}
+
+func (s *fakeStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rangeIterator(prefix, prefix+string(rune(0x10FFFF))), nil
+}
+
+func (s *fakeStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	mods := s.history[key]
+	newestFirst := make([]*queryresult.KeyModification, len(mods))
+	for i, m := range mods {
+		newestFirst[len(mods)-1-i] = m
+	}
+
+	return &historyIterator{mods: newestFirst}, nil
+}
+
+func (s *fakeStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection][key], nil
+}
+
+func (s *fakeStub) PutPrivateData(collection, key string, value []byte) error {
+	if s.private[collection] == nil {
+		s.private[collection] = map[string][]byte{}
+	}
+	s.private[collection][key] = value
+	return nil
+}
+
+func (s *fakeStub) DelPrivateData(collection, key string) error {
+	delete(s.private[collection], key)
+	return nil
+}
+
+// rangeIterator lists the keys in [startKey, endKey) in key order, like the
+// peer does; an empty endKey is unbounded.
+func (s *fakeStub) rangeIterator(startKey, endKey string) *stateIterator {
+	var keys []string
+	for key := range s.state {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &stateIterator{}
+	for _, key := range keys {
+		it.kvs = append(it.kvs, &queryresult.KV{Key: key, Value: s.state[key]})
+	}
+	return it
+}
+
+type stateIterator struct {
+	kvs  []*queryresult.KV
+	next int
+}
+
+func (it *stateIterator) HasNext() bool { return it.next < len(it.kvs) }
+func (it *stateIterator) Close() error  { return nil }
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[it.next]
+	it.next++
+	return kv, nil
+}
+
+type historyIterator struct {
+	mods []*queryresult.KeyModification
+	next int
+}
+
+func (it *historyIterator) HasNext() bool { return it.next < len(it.mods) }
+func (it *historyIterator) Close() error  { return nil }
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	m := it.mods[it.next]
+	it.next++
+	return m, nil
+}
+
+// attrsOID is the certificate extension the Fabric CA stores attributes in.
+var attrsOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// identity returns a serialized identity of mspID with a self-signed
+// certificate carrying ou and, when set, the trading attributes.
+func identity(t *testing.T, mspID, cn, ou string, attrs map[string]string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn, OrganizationalUnit: []string{ou}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if attrs != nil {
+		value, _ := json.Marshal(map[string]interface{}{"attrs": attrs})
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: attrsOID, Value: value}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sid, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sid
+}
+
+// testLedger is a contract over a fakeStub seeded by InitLedger, with
+// identities that own USER1, MERCHANT1 and MERCHANT2.
+type testLedger struct {
+	t        *testing.T
+	contract *TradingContract
+	stub     *fakeStub
+	ctx      contractapi.TransactionContextInterface
+
+	admin, user1, merchant1, merchant2 []byte
+}
+
+// testSaltSecret is sent as the piiSalt transient of InitLedger.
+const testSaltSecret = "0123456789abcdef0123"
+
+func newTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+
+	stub := newFakeStub()
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+
+	l := &testLedger{
+		t:         t,
+		contract:  &TradingContract{},
+		stub:      stub,
+		ctx:       ctx,
+		admin:     identity(t, "Org1MSP", "Admin@org1.example.com", "admin", nil),
+		user1:     identity(t, "Org1MSP", "user1", "client", map[string]string{roleAttribute: "user", entityIDAttribute: "USER1"}),
+		merchant1: identity(t, "Org2MSP", "merchant1", "client", map[string]string{roleAttribute: "merchant", entityIDAttribute: "MERCHANT1"}),
+		merchant2: identity(t, "Org3MSP", "merchant2", "client", map[string]string{roleAttribute: "merchant", entityIDAttribute: "MERCHANT2"}),
+	}
+
+	l.as(l.admin, "init")
+	stub.transient = map[string][]byte{"piiSalt": []byte(testSaltSecret)}
+	if err := l.contract.InitLedger(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stub.transient = nil
+
+	for _, owner := range []struct {
+		entityType, entityID string
+		creator              []byte
+	}{
+		{"user", "USER1", l.user1},
+		{"merchant", "MERCHANT1", l.merchant1},
+		{"merchant", "MERCHANT2", l.merchant2},
+	} {
+		l.as(owner.creator, "whoami")
+		who, err := l.contract.WhoAmI(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.as(l.admin, "transfer-"+owner.entityID)
+		if err := l.contract.TransferOwnership(ctx, owner.entityType, owner.entityID, who.MSPID, who.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return l
+}
+
+// as submits the next transaction as creator under txID.
+func (l *testLedger) as(creator []byte, txID string) {
+	l.stub.creator = creator
+	l.stub.txID = txID
+	l.stub.events = map[string][]byte{}
+}
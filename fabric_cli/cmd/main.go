@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -50,34 +51,18 @@ func main() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		printMenu()
-		choice := prompt(scanner, "Enter choice")
+		choice := strings.TrimSpace(prompt(scanner, "Enter choice"))
 
-		switch strings.TrimSpace(choice) {
-		case "1":
-			handleInitLedger(scanner, conn)
-		case "2":
-			handleCreateMerchant(scanner, conn)
-		case "3":
-			handleAddProducts(scanner, conn)
-		case "4":
-			handleCreateUser(scanner, conn)
-		case "5":
-			handleDeposit(scanner, conn)
-		case "6":
-			handlePurchase(scanner, conn)
-		case "7":
-			handleGetAllProducts(conn)
-		case "8":
-			handleRichQuery(scanner, conn)
+		switch choice {
 		case "9":
 			handleSwitchProfile(cfg, conn)
 			return
-		case "10":
-			handleRegisterAndEnroll(scanner)
 		case "0":
 			fmt.Println("Goodbye!")
 			return
-		default:
+		}
+
+		if !dispatchMenu(choice, scanner, conn, cfg) {
 			fmt.Println("⚠️  Unknown option, try again.")
 		}
 	}
@@ -94,13 +79,34 @@ func printMenu() {
 	fmt.Println("  3) Add Products to Merchant")
 	fmt.Println("  4) Create User")
 	fmt.Println("  5) Deposit Funds")
-	fmt.Println("  6) Purchase Product")
+	fmt.Println("  6) Purchase (Cart)")
+	fmt.Println("  18) Promotions (create / deactivate / list)")
+	fmt.Println("  20) Withdraw and Payouts")
+	fmt.Println("  21) Transfers between users")
+	fmt.Println("  22) Orders in escrow (ship / confirm / release / cancel)")
+	fmt.Println("  23) Restock Product")
+	fmt.Println("  24) Update Product Price")
+	fmt.Println("  25) Update Product Details")
+	fmt.Println("  26) Delist Product")
+	fmt.Println("  27) Delete Product")
+	fmt.Println("  28) Merchant Types and Categories")
+	fmt.Println("  29) Review Purchase")
+	fmt.Println("  30) Loyalty Points (program / account / statement)")
 	fmt.Println("  QUERY")
 	fmt.Println("  7) Get All Products")
 	fmt.Println("  8) Rich Query Products")
+	fmt.Println("  14) Entity History (timeline)")
+	fmt.Println("  15) Paged Queries")
+	fmt.Println("  16) User Personal Data (private)")
+	fmt.Println("  17) Expired Products (write-off)")
+	fmt.Println("  19) VAT Rates and Summary")
+	fmt.Println("  31) Sales Statistics (totals / top sellers / revenue series)")
 	fmt.Println("  OTHER")
 	fmt.Println("  9) Switch Identity / Re-login")
 	fmt.Println("  10) Enroll / Register user")
+	fmt.Println("  11) Who Am I")
+	fmt.Println("  12) Transfer Ownership (admin)")
+	fmt.Println("  13) Watch Chaincode Events")
 	fmt.Println("  0) Exit")
 	fmt.Println("════════════════════════════════════")
 }
@@ -113,8 +119,12 @@ func handleInitLedger(scanner *bufio.Scanner, conn *gw.Connection) {
 
 func handleCreateMerchant(scanner *bufio.Scanner, conn *gw.Connection) {
 	id := prompt(scanner, "Merchant ID")
-	mtype := prompt(scanner, "Type (e.g. supermarket, auto_parts)")
-	pib := prompt(scanner, "PIB")
+	label := "Type (see option 28 for the list)"
+	if ids, err := commands.MerchantTypeIDs(conn.Contract); err == nil && len(ids) > 0 {
+		label = fmt.Sprintf("Type (%s)", strings.Join(ids, ", "))
+	}
+	mtype := prompt(scanner, label)
+	pib := prompt(scanner, "PIB (9 digits with check digit, e.g. 123456788)")
 	if err := commands.CreateMerchant(conn.Contract, id, mtype, pib); err != nil {
 		printErr(err)
 	}
@@ -123,7 +133,9 @@ func handleCreateMerchant(scanner *bufio.Scanner, conn *gw.Connection) {
 func handleAddProducts(scanner *bufio.Scanner, conn *gw.Connection) {
 	merchantID := prompt(scanner, "Merchant ID")
 	fmt.Println("Enter products as JSON array, e.g.:")
-	fmt.Println(`  [{"id":"P5","name":"Cola","expiration":"2026-12-31T00:00:00Z","price":120,"quantity":30}]`)
+	fmt.Println(`  [{"id":"P5","name":"Cola","expiration":"2027-12-31T00:00:00Z","price":"120.00","quantity":30,"category":"food"}]`)
+	fmt.Println("  (expiration is RFC 3339 and must be in the future; omit it for one year from now)")
+	fmt.Println("  (category is optional and must exist, see option 28)")
 	productsJSON := prompt(scanner, "Products JSON")
 	if err := commands.AddProducts(conn.Contract, merchantID, productsJSON); err != nil {
 		printErr(err)
@@ -143,10 +155,9 @@ func handleCreateUser(scanner *bufio.Scanner, conn *gw.Connection) {
 func handleDeposit(scanner *bufio.Scanner, conn *gw.Connection) {
 	entityType := promptChoice(scanner, "Entity type", "user", "merchant")
 	id := prompt(scanner, "ID")
-	amtStr := prompt(scanner, "Amount")
-	amt, err := strconv.ParseFloat(strings.TrimSpace(amtStr), 64)
-	if err != nil || amt <= 0 {
-		fmt.Println("⚠️  Invalid amount")
+	amt := prompt(scanner, "Amount (e.g. 150.00)")
+	if err := commands.ValidateAmount(amt); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
 		return
 	}
 	if err := commands.Deposit(conn.Contract, entityType, id, amt); err != nil {
@@ -154,17 +165,354 @@ func handleDeposit(scanner *bufio.Scanner, conn *gw.Connection) {
 	}
 }
 
-func handlePurchase(scanner *bufio.Scanner, conn *gw.Connection) {
-	userID := prompt(scanner, "User ID")
+func handlePayouts(scanner *bufio.Scanner, conn *gw.Connection) {
+	switch promptChoice(scanner, "Action", "withdraw", "list", "day", "policy") {
+	case "withdraw":
+		entityType := promptChoice(scanner, "Entity type", "user", "merchant")
+		id := prompt(scanner, "ID")
+		amt := prompt(scanner, "Amount (e.g. 150.00)")
+		reference := prompt(scanner, "Bank account / reference")
+		result, err := commands.Withdraw(conn.Contract, entityType, id, amt, reference)
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "list":
+		entityType := promptChoice(scanner, "Entity type", "user", "merchant")
+		result, err := commands.GetPayouts(conn.Contract, entityType, prompt(scanner, "ID"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "day":
+		result, err := commands.GetPayoutsByDay(conn.Contract, prompt(scanner, "Day (YYYY-MM-DD, UTC)"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "policy":
+		minPayout := prompt(scanner, "Minimum payout (e.g. 100.00)")
+		dailyLimit := prompt(scanner, "Daily limit (e.g. 100000.00)")
+		if err := commands.SetPayoutPolicy(conn.Contract, minPayout, dailyLimit); err != nil {
+			printErr(err)
+		}
+	}
+}
+
+func handleTransfers(scanner *bufio.Scanner, conn *gw.Connection) {
+	switch promptChoice(scanner, "Action", "send", "list", "get") {
+	case "send":
+		fromUserID := prompt(scanner, "From user ID")
+		toUserID := prompt(scanner, "To user ID")
+		amt := prompt(scanner, "Amount (e.g. 150.00)")
+		memo := prompt(scanner, "Memo (optional)")
+		result, err := commands.Transfer(conn.Contract, fromUserID, toUserID, amt, memo)
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "list":
+		result, err := commands.GetTransfersByUser(conn.Contract, prompt(scanner, "User ID"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "get":
+		result, err := commands.GetTransfer(conn.Contract, prompt(scanner, "Transfer ID"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	}
+}
+
+func handleOrders(scanner *bufio.Scanner, conn *gw.Connection) {
+	actions := map[string]string{
+		"ship":    "ShipOrder",
+		"confirm": "ConfirmDelivery",
+		"release": "ReleaseOrder",
+		"cancel":  "CancelOrder",
+	}
+
+	switch choice := promptChoice(scanner, "Action", "ship", "confirm", "release", "cancel", "get", "list", "policy"); choice {
+	case "ship", "confirm", "release", "cancel":
+		result, err := commands.OrderAction(conn.Contract, actions[choice], prompt(scanner, "Order ID (invoice ID)"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "get":
+		result, err := commands.GetOrder(conn.Contract, prompt(scanner, "Order ID (invoice ID)"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "list":
+		entityType := promptChoice(scanner, "Entity type", "user", "merchant")
+		result, err := commands.GetOrders(conn.Contract, entityType, prompt(scanner, "ID"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "policy":
+		days, err := strconv.Atoi(prompt(scanner, "Release after days (e.g. 14)"))
+		if err != nil {
+			fmt.Println("⚠️  Invalid number of days")
+			return
+		}
+		if err := commands.SetEscrowPolicy(conn.Contract, days); err != nil {
+			printErr(err)
+		}
+	}
+}
+
+func handleRestockProduct(scanner *bufio.Scanner, conn *gw.Connection) {
 	productID := prompt(scanner, "Product ID")
-	invoiceID := prompt(scanner, "Invoice ID (e.g. INV001)")
-	qtyStr := prompt(scanner, "Quantity")
-	qty, err := strconv.Atoi(strings.TrimSpace(qtyStr))
+	qty, err := strconv.Atoi(prompt(scanner, "Units to add"))
 	if err != nil || qty <= 0 {
 		fmt.Println("⚠️  Invalid quantity")
 		return
 	}
-	if err := commands.Purchase(conn.Contract, userID, productID, invoiceID, qty); err != nil {
+	result, err := commands.RestockProduct(conn.Contract, productID, qty)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleUpdateProductPrice(scanner *bufio.Scanner, conn *gw.Connection) {
+	productID := prompt(scanner, "Product ID")
+	result, err := commands.UpdateProductPrice(conn.Contract, productID, prompt(scanner, "New price (e.g. 129.90)"))
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleUpdateProductDetails(scanner *bufio.Scanner, conn *gw.Connection) {
+	productID := prompt(scanner, "Product ID")
+	details := commands.ProductDetails{
+		Name:       prompt(scanner, "New name (blank to keep)"),
+		Expiration: prompt(scanner, "New expiration (RFC 3339, blank to keep)"),
+		Category:   prompt(scanner, "New category (blank to keep)"),
+	}
+	result, err := commands.UpdateProductDetails(conn.Contract, productID, details)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleDelistProduct(scanner *bufio.Scanner, conn *gw.Connection) {
+	result, err := commands.DelistProduct(conn.Contract, prompt(scanner, "Product ID"))
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleDeleteProduct(scanner *bufio.Scanner, conn *gw.Connection) {
+	productID := prompt(scanner, "Product ID")
+	if prompt(scanner, fmt.Sprintf("Delete %s permanently? (yes/no)", productID)) != "yes" {
+		fmt.Println("Cancelled")
+		return
+	}
+	if err := commands.DeleteProduct(conn.Contract, productID); err != nil {
+		printErr(err)
+	}
+}
+
+func handleTaxonomy(scanner *bufio.Scanner, conn *gw.Connection) {
+	var (
+		result []byte
+		err    error
+	)
+	switch promptChoice(scanner, "Action", "types", "categories", "add-type", "add-category") {
+	case "types":
+		result, err = commands.GetMerchantTypes(conn.Contract)
+	case "categories":
+		result, err = commands.GetCategories(conn.Contract)
+	case "add-type":
+		id := prompt(scanner, "Type ID (lower case, e.g. bookstore)")
+		result, err = commands.AddMerchantType(conn.Contract, id, prompt(scanner, "Display name"))
+	case "add-category":
+		id := prompt(scanner, "Category ID (lower case, e.g. milk)")
+		name := prompt(scanner, "Display name")
+		result, err = commands.AddCategory(conn.Contract, id, name, prompt(scanner, "Parent category ID (blank for top level)"))
+	}
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleLoyalty(scanner *bufio.Scanner, conn *gw.Connection) {
+	var (
+		result []byte
+		err    error
+	)
+	switch promptChoice(scanner, "Action", "account", "statement", "program", "set-program") {
+	case "account":
+		result, err = commands.GetLoyaltyAccount(conn.Contract, prompt(scanner, "User ID"))
+	case "statement":
+		result, err = commands.GetLoyaltyStatement(conn.Contract, prompt(scanner, "User ID"))
+	case "program":
+		result, err = commands.GetLoyaltyProgram(conn.Contract, prompt(scanner, "Merchant ID"))
+	case "set-program":
+		merchantID := prompt(scanner, "Merchant ID")
+		earnPercent, convErr := strconv.Atoi(prompt(scanner, "Earn percent of amount paid (0-100)"))
+		if convErr != nil {
+			fmt.Println("⚠️  Invalid percent")
+			return
+		}
+		expiryDays, convErr := strconv.Atoi(prompt(scanner, "Points expire after days (e.g. 365)"))
+		if convErr != nil {
+			fmt.Println("⚠️  Invalid number of days")
+			return
+		}
+		platformWide := strings.EqualFold(prompt(scanner, "Redeemable at every merchant? (y/N)"), "y")
+		result, err = commands.SetLoyaltyProgram(conn.Contract, merchantID, earnPercent, expiryDays, platformWide)
+	}
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleSalesStats(scanner *bufio.Scanner, conn *gw.Connection) {
+	var (
+		result []byte
+		err    error
+	)
+	merchantID := prompt(scanner, "Merchant ID")
+	switch promptChoice(scanner, "Action", "totals", "top-sellers", "series") {
+	case "totals":
+		result, err = commands.GetMerchantSalesStats(conn.Contract, merchantID)
+	case "top-sellers":
+		sortBy := promptChoice(scanner, "Sort by", "units", "revenue")
+		limit, convErr := strconv.Atoi(prompt(scanner, "How many products (1-100)"))
+		if convErr != nil {
+			fmt.Println("⚠️  Invalid number")
+			return
+		}
+		result, err = commands.GetTopSellingProducts(conn.Contract, merchantID, sortBy, limit)
+	case "series":
+		productID := prompt(scanner, "Product ID (empty for the whole merchant)")
+		from := prompt(scanner, "From day (e.g. 2026-10-01)")
+		to := prompt(scanner, "To day (e.g. 2026-10-31)")
+		result, err = commands.GetRevenueSeries(conn.Contract, merchantID, productID, from, to)
+	}
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleReviewPurchase(scanner *bufio.Scanner, conn *gw.Connection) {
+	invoiceID := prompt(scanner, "Invoice ID")
+	productID := prompt(scanner, "Product ID")
+	score, err := strconv.Atoi(prompt(scanner, "Score (1-5)"))
+	if err != nil || score < 1 || score > 5 {
+		fmt.Println("⚠️  Score must be a whole number from 1 to 5")
+		return
+	}
+	result, err := commands.ReviewPurchase(conn.Contract, invoiceID, productID, score, prompt(scanner, "Review text (optional)"))
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handlePurchase(scanner *bufio.Scanner, conn *gw.Connection) {
+	userID := prompt(scanner, "User ID")
+
+	fmt.Println("Add items to the cart (leave Product ID blank to finish):")
+	var cart []commands.CartLine
+	for {
+		productID := prompt(scanner, "  Product ID")
+		if productID == "" {
+			break
+		}
+		qtyStr := prompt(scanner, "  Quantity")
+		qty, err := strconv.Atoi(strings.TrimSpace(qtyStr))
+		if err != nil || qty <= 0 {
+			fmt.Println("⚠️  Invalid quantity, item skipped")
+			continue
+		}
+		cart = append(cart, commands.CartLine{ProductID: productID, Quantity: qty})
+	}
+
+	if len(cart) == 0 {
+		fmt.Println("⚠️  Cart is empty, nothing to purchase")
+		return
+	}
+
+	fmt.Println("─── Cart ──────────────────────────────")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, line := range cart {
+		fmt.Fprintf(tw, "  %d)\t%s\tx%d\n", i+1, line.ProductID, line.Quantity)
+	}
+	tw.Flush()
+
+	// The same key is sent on every retry, so a purchase that went through
+	// despite an error (e.g. a gateway timeout) is not charged again.
+	opts := commands.PurchaseOptions{
+		IdempotencyKey: commands.NewIdempotencyKey(),
+		PromoCode:      prompt(scanner, "Promo code (optional)"),
+	}
+	if points := prompt(scanner, "Loyalty points to redeem (optional, 100 = 1.00 RSD)"); points != "" {
+		n, err := strconv.Atoi(points)
+		if err != nil || n < 0 {
+			fmt.Println("⚠️  Invalid number of points")
+			return
+		}
+		opts.RedeemPoints = n
+	}
+	for {
+		result, err := commands.PurchaseCart(conn.Contract, userID, cart, opts)
+		if err == nil {
+			printResult(result)
+			return
+		}
+		printErr(err)
+		if commands.IsAccessDenied(err) || !strings.EqualFold(prompt(scanner, "Retry with the same idempotency key? (y/N)"), "y") {
+			return
+		}
+	}
+}
+
+func handleWhoAmI(conn *gw.Connection) {
+	result, err := commands.WhoAmI(conn.Contract)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handleTransferOwnership(scanner *bufio.Scanner, conn *gw.Connection) {
+	entityType := promptChoice(scanner, "Entity type", "user", "merchant")
+	id := prompt(scanner, "ID")
+	mspID := prompt(scanner, "New owner MSP ID (e.g. Org1MSP)")
+	ownerID := prompt(scanner, "New owner ID (from Who Am I)")
+	if err := commands.TransferOwnership(conn.Contract, entityType, id, mspID, ownerID); err != nil {
 		printErr(err)
 	}
 }
@@ -192,15 +540,22 @@ func handleRichQuery(scanner *bufio.Scanner, conn *gw.Connection) {
 	if v := prompt(scanner, "  Merchant type (e.g. supermarket)"); v != "" {
 		filter["merchantType"] = v
 	}
+	if v := prompt(scanner, "  Category, including subcategories (e.g. food)"); v != "" {
+		filter["category"] = v
+	}
 	if v := prompt(scanner, "  Min price (leave blank to skip)"); v != "" {
-		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
-			filter["priceMin"] = f
+		if err := commands.ValidateAmount(v); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return
 		}
+		filter["priceMin"] = v
 	}
 	if v := prompt(scanner, "  Max price (leave blank to skip)"); v != "" {
-		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
-			filter["priceMax"] = f
+		if err := commands.ValidateAmount(v); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			return
 		}
+		filter["priceMax"] = v
 	}
 
 	filterBytes, _ := json.Marshal(filter)
@@ -234,7 +589,8 @@ func handleSwitchProfile(cfg *gw.Config, oldConn *gw.Connection) {
 	}
 }
 
-func dispatchMenu(choice string, scanner *bufio.Scanner, conn *gw.Connection, cfg *gw.Config) {
+// dispatchMenu runs the handler for choice and reports whether it was known.
+func dispatchMenu(choice string, scanner *bufio.Scanner, conn *gw.Connection, cfg *gw.Config) bool {
 	switch strings.TrimSpace(choice) {
 	case "1":
 		handleInitLedger(scanner, conn)
@@ -256,7 +612,204 @@ func dispatchMenu(choice string, scanner *bufio.Scanner, conn *gw.Connection, cf
 		handleSwitchProfile(cfg, conn)
 	case "10":
 		handleRegisterAndEnroll(scanner)
+	case "11":
+		handleWhoAmI(conn)
+	case "12":
+		handleTransferOwnership(scanner, conn)
+	case "13":
+		handleWatchEvents(scanner, conn)
+	case "14":
+		handleHistory(scanner, conn)
+	case "15":
+		handlePagedQuery(scanner, conn)
+	case "16":
+		handleGetUserPII(scanner, conn)
+	case "17":
+		handleGetExpiredProducts(scanner, conn)
+	case "18":
+		handlePromotions(scanner, conn)
+	case "19":
+		handleVAT(scanner, conn)
+	case "20":
+		handlePayouts(scanner, conn)
+	case "21":
+		handleTransfers(scanner, conn)
+	case "22":
+		handleOrders(scanner, conn)
+	case "23":
+		handleRestockProduct(scanner, conn)
+	case "24":
+		handleUpdateProductPrice(scanner, conn)
+	case "25":
+		handleUpdateProductDetails(scanner, conn)
+	case "26":
+		handleDelistProduct(scanner, conn)
+	case "27":
+		handleDeleteProduct(scanner, conn)
+	case "28":
+		handleTaxonomy(scanner, conn)
+	case "29":
+		handleReviewPurchase(scanner, conn)
+	case "30":
+		handleLoyalty(scanner, conn)
+	case "31":
+		handleSalesStats(scanner, conn)
+	default:
+		return false
 	}
+	return true
+}
+
+func handleHistory(scanner *bufio.Scanner, conn *gw.Connection) {
+	entityType := promptChoice(scanner, "Entity type", "user", "product", "merchant")
+	id := prompt(scanner, "ID")
+	timeline, err := commands.GetHistory(conn.Contract, entityType, id)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	fmt.Println("─── History ───────────────────────────")
+	fmt.Println(timeline)
+	fmt.Println("───────────────────────────────────────")
+}
+
+func handleGetUserPII(scanner *bufio.Scanner, conn *gw.Connection) {
+	id := prompt(scanner, "User ID")
+	result, err := commands.GetUserPII(conn.Contract, id)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handlePromotions(scanner *bufio.Scanner, conn *gw.Connection) {
+	switch promptChoice(scanner, "Action", "create", "deactivate", "list") {
+	case "create":
+		merchantID := prompt(scanner, "Merchant ID")
+		fmt.Println("Enter the promotion as JSON, e.g.:")
+		fmt.Println(`  {"id":"PROMO1","productId":"PROD1","kind":"percent","percentOff":10,`)
+		fmt.Println(`   "startsAt":"2026-11-01T00:00:00Z","endsAt":"2026-12-01T00:00:00Z","code":"MLEKO10","maxUses":100}`)
+		fmt.Println(`  (omit productId for the whole catalog; use "kind":"fixed","amountOff":"50.00" for a fixed discount)`)
+		promotionJSON := prompt(scanner, "Promotion JSON")
+		result, err := commands.CreatePromotion(conn.Contract, merchantID, promotionJSON)
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "deactivate":
+		if err := commands.DeactivatePromotion(conn.Contract, prompt(scanner, "Promotion ID")); err != nil {
+			printErr(err)
+		}
+	case "list":
+		result, err := commands.GetPromotionsByMerchant(conn.Contract, prompt(scanner, "Merchant ID"))
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	}
+}
+
+func handleVAT(scanner *bufio.Scanner, conn *gw.Connection) {
+	switch promptChoice(scanner, "Action", "set", "rates", "summary") {
+	case "set":
+		scope := promptChoice(scanner, "Scope", "product", "merchantType")
+		target := prompt(scanner, "Product ID or merchant type")
+		percent, err := strconv.Atoi(prompt(scanner, "Rate in percent (e.g. 20 or 10)"))
+		if err != nil {
+			fmt.Println("⚠️  Invalid rate")
+			return
+		}
+		if err := commands.SetVATRate(conn.Contract, scope, target, percent); err != nil {
+			printErr(err)
+		}
+	case "rates":
+		result, err := commands.GetVATRates(conn.Contract)
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	case "summary":
+		merchantID := prompt(scanner, "Merchant ID")
+		from := prompt(scanner, "From (RFC 3339, e.g. 2026-10-01T00:00:00Z)")
+		to := prompt(scanner, "To (RFC 3339, e.g. 2026-10-31T23:59:59Z)")
+		result, err := commands.GetVATSummary(conn.Contract, merchantID, from, to)
+		if err != nil {
+			printErr(err)
+			return
+		}
+		printResult(result)
+	}
+}
+
+func handleGetExpiredProducts(scanner *bufio.Scanner, conn *gw.Connection) {
+	merchantID := prompt(scanner, "Merchant ID")
+	result, err := commands.GetExpiredProducts(conn.Contract, merchantID)
+	if err != nil {
+		printErr(err)
+		return
+	}
+	printResult(result)
+}
+
+func handlePagedQuery(scanner *bufio.Scanner, conn *gw.Connection) {
+	for i, q := range commands.PagedQueries {
+		fmt.Printf("  %d) %s\n", i+1, q.Name)
+	}
+	n, err := strconv.Atoi(prompt(scanner, "Query"))
+	if err != nil || n < 1 || n > len(commands.PagedQueries) {
+		fmt.Println("⚠️  Unknown query")
+		return
+	}
+	q := commands.PagedQueries[n-1]
+
+	args := make([]string, 0, len(q.Params))
+	for _, param := range q.Params {
+		args = append(args, prompt(scanner, param))
+	}
+
+	size, err := strconv.Atoi(prompt(scanner, "Page size (e.g. 10)"))
+	if err != nil || size <= 0 {
+		fmt.Println("⚠️  Invalid page size")
+		return
+	}
+	pageSize := int32(size)
+	stream := promptChoice(scanner, "Mode", "interactive", "stream") == "stream"
+
+	bookmark := ""
+	for pageNo := 1; ; pageNo++ {
+		page, err := commands.FetchPage(conn.Contract, q, args, pageSize, bookmark)
+		if err != nil {
+			printErr(err)
+			return
+		}
+
+		fmt.Printf("─── Page %d (%d records) ───────────────\n", pageNo, page.RecordsCount)
+		fmt.Println(string(page.Pretty()))
+
+		if page.Last(pageSize) {
+			fmt.Println("✓ No more pages")
+			return
+		}
+		if !stream && strings.EqualFold(prompt(scanner, "Enter for next page, q to stop"), "q") {
+			return
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+func handleWatchEvents(scanner *bufio.Scanner, conn *gw.Connection) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := commands.WatchEvents(ctx, conn.Network, conn.Contract.ChaincodeName()); err != nil {
+		printErr(err)
+		return
+	}
+	prompt(scanner, "Press Enter to stop watching")
 }
 
 func handleRegisterAndEnroll(scanner *bufio.Scanner) {
@@ -264,8 +817,13 @@ func handleRegisterAndEnroll(scanner *bufio.Scanner) {
 	username := prompt(scanner, "Username")
 	password := prompt(scanner, "Password for user")
 	orgMSP := prompt(scanner, "Org MSP (e.g. Org1MSP)")
+	role := promptChoice(scanner, "Trading role", "admin", "merchant", "user")
+	entityID := ""
+	if role != "admin" {
+		entityID = prompt(scanner, "Trading ID this identity acts as (e.g. MERCHANT1, USER1)")
+	}
 
-	profile, err := gw.RegisterAndEnrollUser(username, password, orgMSP)
+	profile, err := gw.RegisterAndEnrollUser(username, password, orgMSP, role, entityID)
 	if err != nil {
 		printErr(err)
 		return
@@ -335,6 +893,16 @@ func promptChoice(scanner *bufio.Scanner, label string, options ...string) strin
 }
 
 func printErr(err error) {
+	if commands.IsAccessDenied(err) {
+		fmt.Println("⛔ Access denied: the current identity is not allowed to perform this action.")
+		fmt.Println("   Switch identity (option 9) or enroll one with the required role (option 10).")
+	}
+	if commands.IsAlreadyExists(err) {
+		fmt.Println("⚠️  That ID is already taken on the ledger; choose a different one.")
+	}
+	if commands.IsInvalidInput(err) {
+		fmt.Println("⚠️  The chaincode rejected some fields; each one is listed with its reason below.")
+	}
 	fmt.Printf("❌ Error: %v\n", err)
 }
 
@@ -0,0 +1,31 @@
+package commands
+
+import "strings"
+
+// accessDeniedMarker is the message prefix the chaincode uses when its role
+// checks reject the submitting identity.
+const accessDeniedMarker = "access denied"
+
+// IsAccessDenied reports whether err was caused by the chaincode rejecting
+// the current identity.
+func IsAccessDenied(err error) bool {
+	return err != nil && strings.Contains(err.Error(), accessDeniedMarker)
+}
+
+// alreadyExistsMarker is the message prefix the chaincode uses when a create
+// transaction reuses an ID that is already on the ledger.
+const alreadyExistsMarker = "entity already exists"
+
+// IsAlreadyExists reports whether err was caused by an ID conflict.
+func IsAlreadyExists(err error) bool {
+	return err != nil && strings.Contains(err.Error(), alreadyExistsMarker)
+}
+
+// invalidInputMarker is the message prefix the chaincode uses when it rejects
+// transaction arguments; field errors follow as "field: reason; ...".
+const invalidInputMarker = "invalid input data"
+
+// IsInvalidInput reports whether err was caused by rejected arguments.
+func IsInvalidInput(err error) bool {
+	return err != nil && strings.Contains(err.Error(), invalidInputMarker)
+}
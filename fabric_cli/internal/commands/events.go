@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// WatchEvents prints the trading chaincode's events as they are committed
+// until ctx is cancelled. Each payload is a versioned envelope
+// {"version":1,"name":...,"txId":...,"timestamp":...,"payload":{...}}.
+func WatchEvents(ctx context.Context, network *client.Network, chaincodeName string) error {
+	events, err := network.ChaincodeEvents(ctx, chaincodeName)
+	if err != nil {
+		return fmt.Errorf("cannot subscribe to chaincode events: %w", err)
+	}
+
+	fmt.Printf("→ Listening for %s events\n", chaincodeName)
+	go func() {
+		for event := range events {
+			fmt.Printf("\n📣 %s  block=%d  tx=%s\n", event.EventName, event.BlockNumber, event.TransactionID)
+			fmt.Println(string(prettyJSON(event.Payload)))
+		}
+	}()
+
+	return nil
+}
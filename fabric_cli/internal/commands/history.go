@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// historyFunctions maps an entity type to its chaincode history query.
+var historyFunctions = map[string]string{
+	"user":     "GetUserHistory",
+	"product":  "GetProductHistory",
+	"merchant": "GetMerchantHistory",
+}
+
+// auditFields change on every write and are already shown as the version
+// timestamp, so the timeline leaves them out of the diffs.
+var auditFields = map[string]bool{"createdAt": true, "updatedAt": true}
+
+type historyEntry struct {
+	TxID      string                 `json:"txId"`
+	Timestamp string                 `json:"timestamp"`
+	IsDelete  bool                   `json:"isDelete"`
+	Value     map[string]interface{} `json:"value"`
+}
+
+// GetHistory queries every version of a user, product or merchant and
+// renders them as a timeline with field-level changes.
+// entityType: "user" | "product" | "merchant"
+func GetHistory(contract *client.Contract, entityType, id string) (string, error) {
+	fn, ok := historyFunctions[entityType]
+	if !ok {
+		return "", fmt.Errorf("unknown entity type %q", entityType)
+	}
+
+	fmt.Printf("→ Querying %s (id=%s)\n", fn, id)
+	result, err := contract.EvaluateTransaction(fn, id)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", fn, err)
+	}
+	return RenderTimeline(result)
+}
+
+// RenderTimeline formats history entries (oldest first) as a timeline. Each
+// version lists the fields that differ from the previous one.
+func RenderTimeline(raw []byte) (string, error) {
+	var entries []historyEntry
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&entries); err != nil {
+		return "", fmt.Errorf("cannot decode history: %w", err)
+	}
+	if len(entries) == 0 {
+		return "(no history)", nil
+	}
+
+	var b strings.Builder
+	var previous map[string]string
+	for _, entry := range entries {
+		tx := entry.TxID
+		if len(tx) > 12 {
+			tx = tx[:12] + "…"
+		}
+
+		switch {
+		case entry.IsDelete:
+			fmt.Fprintf(&b, "● %s  tx %s  deleted\n", entry.Timestamp, tx)
+			previous = nil
+			continue
+		case previous == nil:
+			fmt.Fprintf(&b, "● %s  tx %s  created\n", entry.Timestamp, tx)
+		default:
+			fmt.Fprintf(&b, "● %s  tx %s  updated\n", entry.Timestamp, tx)
+		}
+
+		current := make(map[string]string)
+		flattenFields("", humanizeMoney(map[string]interface{}(entry.Value)), current)
+		for _, line := range diffFields(previous, current) {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+		previous = current
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// flattenFields writes every leaf of v into out under its dotted path, with
+// list elements as path[i].
+func flattenFields(path string, v interface{}, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if path == "" && auditFields[k] {
+				continue
+			}
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			flattenFields(key, item, out)
+		}
+	case []interface{}:
+		for i, item := range val {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), item, out)
+		}
+	case nil:
+		out[path] = "null"
+	default:
+		out[path] = fmt.Sprint(val)
+	}
+}
+
+// diffFields describes how after differs from before, sorted by field.
+// With no previous version every field is listed as its initial value.
+func diffFields(before, after map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		old, hadOld := before[k]
+		cur, hasCur := after[k]
+		switch {
+		case before == nil:
+			lines = append(lines, fmt.Sprintf("%s: %s", k, cur))
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, cur))
+		case !hasCur:
+			lines = append(lines, fmt.Sprintf("- %s: %s", k, old))
+		case old != cur:
+			lines = append(lines, fmt.Sprintf("~ %s: %s → %s", k, old, cur))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "(no field changes)")
+	}
+
+	return lines
+}
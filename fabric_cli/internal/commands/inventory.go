@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// ProductDetails mirrors models.ProductDetailsInput; empty fields are left
+// unchanged.
+type ProductDetails struct {
+	Name       string `json:"name,omitempty"`
+	Expiration string `json:"expiration,omitempty"`
+	Category   string `json:"category,omitempty"`
+}
+
+// RestockProduct invokes RestockProduct on the chaincode.
+func RestockProduct(contract *client.Contract, productID string, quantity int) ([]byte, error) {
+	fmt.Printf("→ Invoking RestockProduct (productId=%s, quantity=%d)\n", productID, quantity)
+	result, err := contract.SubmitTransaction("RestockProduct", productID, strconv.Itoa(quantity))
+	if err != nil {
+		return nil, fmt.Errorf("RestockProduct failed: %w", err)
+	}
+	fmt.Printf("✓ Added %d units to %s\n", quantity, productID)
+	return prettyJSON(result), nil
+}
+
+// UpdateProductPrice invokes UpdateProductPrice on the chaincode.
+func UpdateProductPrice(contract *client.Contract, productID, price string) ([]byte, error) {
+	if err := ValidateAmount(price); err != nil {
+		return nil, err
+	}
+	fmt.Printf("→ Invoking UpdateProductPrice (productId=%s, price=%s)\n", productID, price)
+	result, err := contract.SubmitTransaction("UpdateProductPrice", productID, price)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProductPrice failed: %w", err)
+	}
+	fmt.Printf("✓ %s now costs %s\n", productID, price)
+	return prettyJSON(result), nil
+}
+
+// UpdateProductDetails invokes UpdateProductDetails on the chaincode.
+func UpdateProductDetails(contract *client.Contract, productID string, details ProductDetails) ([]byte, error) {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("→ Invoking UpdateProductDetails (productId=%s, details=%s)\n", productID, payload)
+	result, err := contract.SubmitTransaction("UpdateProductDetails", productID, string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("UpdateProductDetails failed: %w", err)
+	}
+	fmt.Printf("✓ Details of %s updated\n", productID)
+	return prettyJSON(result), nil
+}
+
+// DelistProduct invokes DelistProduct on the chaincode.
+func DelistProduct(contract *client.Contract, productID string) ([]byte, error) {
+	fmt.Printf("→ Invoking DelistProduct (productId=%s)\n", productID)
+	result, err := contract.SubmitTransaction("DelistProduct", productID)
+	if err != nil {
+		return nil, fmt.Errorf("DelistProduct failed: %w", err)
+	}
+	fmt.Printf("✓ %s is no longer for sale\n", productID)
+	return prettyJSON(result), nil
+}
+
+// DeleteProduct invokes DeleteProduct on the chaincode.
+func DeleteProduct(contract *client.Contract, productID string) error {
+	fmt.Printf("→ Invoking DeleteProduct (productId=%s)\n", productID)
+	if _, err := contract.SubmitTransaction("DeleteProduct", productID); err != nil {
+		return fmt.Errorf("DeleteProduct failed: %w", err)
+	}
+	fmt.Printf("✓ %s deleted\n", productID)
+	return nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// SetLoyaltyProgram invokes SetLoyaltyProgram on the chaincode.
+func SetLoyaltyProgram(contract *client.Contract, merchantID string, earnPercent, expiryDays int, platformWide bool) ([]byte, error) {
+	fmt.Printf("→ Invoking SetLoyaltyProgram (merchantId=%s, earnPercent=%d, expiryDays=%d, platformWide=%t)\n",
+		merchantID, earnPercent, expiryDays, platformWide)
+	result, err := contract.SubmitTransaction("SetLoyaltyProgram", merchantID,
+		strconv.Itoa(earnPercent), strconv.Itoa(expiryDays), strconv.FormatBool(platformWide))
+	if err != nil {
+		return nil, fmt.Errorf("SetLoyaltyProgram failed: %w", err)
+	}
+	fmt.Printf("✓ Loyalty program of %s updated\n", merchantID)
+	return prettyJSON(result), nil
+}
+
+// GetLoyaltyProgram queries a merchant's loyalty program.
+func GetLoyaltyProgram(contract *client.Contract, merchantID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetLoyaltyProgram (merchantId=%s)\n", merchantID)
+	result, err := contract.EvaluateTransaction("GetLoyaltyProgram", merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("GetLoyaltyProgram failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetLoyaltyAccount queries a user's points balance and lots.
+func GetLoyaltyAccount(contract *client.Contract, userID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetLoyaltyAccount (userId=%s)\n", userID)
+	result, err := contract.EvaluateTransaction("GetLoyaltyAccount", userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetLoyaltyAccount failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetLoyaltyStatement queries every points entry of a user.
+func GetLoyaltyStatement(contract *client.Contract, userID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetLoyaltyStatement (userId=%s)\n", userID)
+	result, err := contract.EvaluateTransaction("GetLoyaltyStatement", userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetLoyaltyStatement failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// moneyScale is the number of minor units in one major unit on the ledger.
+const moneyScale = 100
+
+var amountPattern = regexp.MustCompile(`^\d+(\.\d{1,2})?( [A-Za-z]{3})?$`)
+
+// ValidateAmount checks that s is a decimal amount with at most two
+// fractional digits and an optional currency code, e.g. "150.00 RSD".
+func ValidateAmount(s string) error {
+	if !amountPattern.MatchString(s) {
+		return fmt.Errorf("invalid amount %q (expected e.g. 150 or 150.50)", s)
+	}
+	return nil
+}
+
+// formatMoney renders minor units in major units: 12050 RSD → "120.50 RSD".
+func formatMoney(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/moneyScale, amount%moneyScale, currency)
+}
+
+// humanizeMoney replaces every {"amount": n, "currency": c} object in v with
+// its formatted string so results show "120.50 RSD" instead of minor units.
+func humanizeMoney(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 2 {
+			amount, okAmount := val["amount"].(json.Number)
+			currency, okCurrency := val["currency"].(string)
+			if okAmount && okCurrency {
+				if n, err := amount.Int64(); err == nil {
+					return formatMoney(n, currency)
+				}
+			}
+		}
+		for k, item := range val {
+			val[k] = humanizeMoney(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = humanizeMoney(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// OrderAction invokes one of the escrow order transitions on the chaincode:
+// "ShipOrder", "ConfirmDelivery", "ReleaseOrder" or "CancelOrder".
+func OrderAction(contract *client.Contract, action, orderID string) ([]byte, error) {
+	fmt.Printf("→ Invoking %s (orderId=%s)\n", action, orderID)
+	result, err := contract.SubmitTransaction(action, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", action, err)
+	}
+	fmt.Printf("✓ %s done for order %s\n", action, orderID)
+	return prettyJSON(result), nil
+}
+
+// GetOrder queries one escrowed order; its ID is the invoice ID.
+func GetOrder(contract *client.Contract, orderID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetOrder (orderId=%s)\n", orderID)
+	result, err := contract.EvaluateTransaction("GetOrder", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetOrders queries the escrowed orders of a user or merchant.
+func GetOrders(contract *client.Contract, entityType, id string) ([]byte, error) {
+	fmt.Printf("→ Querying GetOrders (type=%s, id=%s)\n", entityType, id)
+	result, err := contract.EvaluateTransaction("GetOrders", entityType, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrders failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// SetEscrowPolicy invokes SetEscrowPolicy on the chaincode.
+func SetEscrowPolicy(contract *client.Contract, releaseAfterDays int) error {
+	fmt.Printf("→ Invoking SetEscrowPolicy (releaseAfterDays=%d)\n", releaseAfterDays)
+	if _, err := contract.SubmitTransaction("SetEscrowPolicy", strconv.Itoa(releaseAfterDays)); err != nil {
+		return fmt.Errorf("SetEscrowPolicy failed: %w", err)
+	}
+	fmt.Println("✓ Escrow policy updated")
+	return nil
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// PagedQuery describes a paginated chaincode query. Params are prompted for
+// and passed before pageSize and bookmark.
+type PagedQuery struct {
+	Name     string
+	Function string
+	Params   []string
+}
+
+// PagedQueries lists the paginated queries offered by the CLI.
+var PagedQueries = []PagedQuery{
+	{"All products", "GetAllProductsPaginated", nil},
+	{"Rich query products", "RichQueryProductsPaginated", []string{`Filter JSON (e.g. {"name":"Mleko"})`}},
+	{"Products expiring before date", "GetProductsExpiringSoonPaginated", []string{"Expires before (e.g. 2026-12-31T23:59:59Z)"}},
+	{"Users with minimum balance", "GetUsersWithMinBalancePaginated", []string{"Min balance (e.g. 100.00)"}},
+	{"User invoices in date range", "GetInvoicesByUserAndDateRangePaginated", []string{"User ID", "From (RFC 3339)", "To (RFC 3339)"}},
+	{"Low stock products", "GetLowStockProductsPaginated", []string{"Merchant type", "Max quantity"}},
+	{"Merchant high-value invoices", "GetMerchantHighValueInvoicesPaginated", []string{"Merchant ID", "Min total (e.g. 200.00)"}},
+	{"Product reviews", "GetProductReviewsPaginated", []string{"Product ID", "Sort by (date or score)"}},
+}
+
+// Page is one page of a paginated query.
+type Page struct {
+	Records      []json.RawMessage `json:"records"`
+	Bookmark     string            `json:"bookmark"`
+	RecordsCount int32             `json:"recordsCount"`
+}
+
+// Last reports whether no further page needs to be requested.
+func (p *Page) Last(pageSize int32) bool {
+	return p.Bookmark == "" || p.RecordsCount < pageSize
+}
+
+// Pretty renders the page's records as indented JSON.
+func (p *Page) Pretty() []byte {
+	raw, err := json.Marshal(p.Records)
+	if err != nil {
+		return nil
+	}
+	return prettyJSON(raw)
+}
+
+// FetchPage evaluates one page of q. Pass an empty bookmark for the first page.
+func FetchPage(contract *client.Contract, q PagedQuery, args []string, pageSize int32, bookmark string) (*Page, error) {
+	fmt.Printf("→ Querying %s (pageSize=%d, bookmark=%q)\n", q.Function, pageSize, bookmark)
+	callArgs := append(append([]string{}, args...), strconv.Itoa(int(pageSize)), bookmark)
+	result, err := contract.EvaluateTransaction(q.Function, callArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", q.Function, err)
+	}
+
+	var page Page
+	if err := json.Unmarshal(result, &page); err != nil {
+		return nil, fmt.Errorf("cannot decode page: %w", err)
+	}
+	return &page, nil
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Withdraw invokes Withdraw on the chaincode.
+// entityType: "user" | "merchant"; reference is the payee's bank account.
+func Withdraw(contract *client.Contract, entityType, id, amount, reference string) ([]byte, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	fmt.Printf("→ Invoking Withdraw (type=%s, id=%s, amount=%s, reference=%s)\n", entityType, id, amount, reference)
+	result, err := contract.SubmitTransaction("Withdraw", entityType, id, amount, reference)
+	if err != nil {
+		return nil, fmt.Errorf("Withdraw failed: %w", err)
+	}
+	fmt.Printf("✓ Paid out %s from %s %s\n", amount, entityType, id)
+	return prettyJSON(result), nil
+}
+
+// GetPayouts queries the payouts of a user or merchant.
+func GetPayouts(contract *client.Contract, entityType, id string) ([]byte, error) {
+	fmt.Printf("→ Querying GetPayouts (type=%s, id=%s)\n", entityType, id)
+	result, err := contract.EvaluateTransaction("GetPayouts", entityType, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetPayouts failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetPayoutsByDay queries every payout of one UTC day, e.g. "2026-10-17".
+func GetPayoutsByDay(contract *client.Contract, day string) ([]byte, error) {
+	fmt.Printf("→ Querying GetPayoutsByDay (day=%s)\n", day)
+	result, err := contract.EvaluateTransaction("GetPayoutsByDay", day)
+	if err != nil {
+		return nil, fmt.Errorf("GetPayoutsByDay failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// SetPayoutPolicy invokes SetPayoutPolicy on the chaincode.
+func SetPayoutPolicy(contract *client.Contract, minPayout, dailyLimit string) error {
+	for _, amount := range []string{minPayout, dailyLimit} {
+		if err := ValidateAmount(amount); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("→ Invoking SetPayoutPolicy (min=%s, dailyLimit=%s)\n", minPayout, dailyLimit)
+	if _, err := contract.SubmitTransaction("SetPayoutPolicy", minPayout, dailyLimit); err != nil {
+		return fmt.Errorf("SetPayoutPolicy failed: %w", err)
+	}
+	fmt.Println("✓ Payout policy updated")
+	return nil
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// CreatePromotion invokes CreatePromotion – promotionJSON is one promotion
+// object.
+func CreatePromotion(contract *client.Contract, merchantID, promotionJSON string) ([]byte, error) {
+	fmt.Printf("→ Invoking CreatePromotion (merchantID=%s)\n", merchantID)
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(promotionJSON), &raw); err != nil {
+		return nil, fmt.Errorf("invalid promotion JSON: %w", err)
+	}
+	result, err := contract.SubmitTransaction("CreatePromotion", merchantID, promotionJSON)
+	if err != nil {
+		return nil, fmt.Errorf("CreatePromotion failed: %w", err)
+	}
+	fmt.Println("✓ Promotion created successfully")
+	return prettyJSON(result), nil
+}
+
+// DeactivatePromotion invokes DeactivatePromotion.
+func DeactivatePromotion(contract *client.Contract, promotionID string) error {
+	fmt.Printf("→ Invoking DeactivatePromotion (id=%s)\n", promotionID)
+	if _, err := contract.SubmitTransaction("DeactivatePromotion", promotionID); err != nil {
+		return fmt.Errorf("DeactivatePromotion failed: %w", err)
+	}
+	fmt.Println("✓ Promotion deactivated")
+	return nil
+}
+
+// GetPromotionsByMerchant queries a merchant's promotions.
+func GetPromotionsByMerchant(contract *client.Contract, merchantID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetPromotionsByMerchant (merchant=%s)\n", merchantID)
+	result, err := contract.EvaluateTransaction("GetPromotionsByMerchant", merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPromotionsByMerchant failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
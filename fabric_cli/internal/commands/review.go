@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// ReviewPurchase invokes ReviewPurchase on the chaincode. text may be empty.
+func ReviewPurchase(contract *client.Contract, invoiceID, productID string, score int, text string) ([]byte, error) {
+	fmt.Printf("→ Invoking ReviewPurchase (invoiceId=%s, productId=%s, score=%d)\n", invoiceID, productID, score)
+	result, err := contract.SubmitTransaction("ReviewPurchase", invoiceID, productID, strconv.Itoa(score), text)
+	if err != nil {
+		return nil, fmt.Errorf("ReviewPurchase failed: %w", err)
+	}
+	fmt.Printf("✓ Reviewed %s from invoice %s\n", productID, invoiceID)
+	return prettyJSON(result), nil
+}
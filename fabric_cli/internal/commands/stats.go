@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// GetMerchantSalesStats queries a merchant's all-time sales statistics.
+func GetMerchantSalesStats(contract *client.Contract, merchantID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetMerchantSalesStats (merchantId=%s)\n", merchantID)
+	result, err := contract.EvaluateTransaction("GetMerchantSalesStats", merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("GetMerchantSalesStats failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetTopSellingProducts queries a merchant's best selling products by units
+// sold or revenue.
+func GetTopSellingProducts(contract *client.Contract, merchantID, sortBy string, limit int) ([]byte, error) {
+	fmt.Printf("→ Querying GetTopSellingProducts (merchantId=%s, sortBy=%s, limit=%d)\n", merchantID, sortBy, limit)
+	result, err := contract.EvaluateTransaction("GetTopSellingProducts", merchantID, sortBy, strconv.Itoa(limit))
+	if err != nil {
+		return nil, fmt.Errorf("GetTopSellingProducts failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetRevenueSeries queries the daily sales of a merchant, or of one of its
+// products when productID is set, between two dates.
+func GetRevenueSeries(contract *client.Contract, merchantID, productID, from, to string) ([]byte, error) {
+	fmt.Printf("→ Querying GetRevenueSeries (merchantId=%s, productId=%s, from=%s, to=%s)\n", merchantID, productID, from, to)
+	result, err := contract.EvaluateTransaction("GetRevenueSeries", merchantID, productID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("GetRevenueSeries failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
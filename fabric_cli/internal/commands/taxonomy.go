@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// GetMerchantTypes queries the merchant types on the ledger.
+func GetMerchantTypes(contract *client.Contract) ([]byte, error) {
+	fmt.Println("→ Querying GetMerchantTypes")
+	result, err := contract.EvaluateTransaction("GetMerchantTypes")
+	if err != nil {
+		return nil, fmt.Errorf("GetMerchantTypes failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// MerchantTypeIDs returns the IDs of the merchant types on the ledger, for
+// prompts that list the allowed values.
+func MerchantTypeIDs(contract *client.Contract) ([]string, error) {
+	result, err := contract.EvaluateTransaction("GetMerchantTypes")
+	if err != nil {
+		return nil, err
+	}
+	var merchantTypes []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(result, &merchantTypes); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(merchantTypes))
+	for _, t := range merchantTypes {
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// AddMerchantType invokes AddMerchantType on the chaincode.
+func AddMerchantType(contract *client.Contract, id, name string) ([]byte, error) {
+	fmt.Printf("→ Invoking AddMerchantType (id=%s, name=%s)\n", id, name)
+	result, err := contract.SubmitTransaction("AddMerchantType", id, name)
+	if err != nil {
+		return nil, fmt.Errorf("AddMerchantType failed: %w", err)
+	}
+	fmt.Printf("✓ Merchant type %s added\n", id)
+	return prettyJSON(result), nil
+}
+
+// GetCategories queries the product category tree.
+func GetCategories(contract *client.Contract) ([]byte, error) {
+	fmt.Println("→ Querying GetCategories")
+	result, err := contract.EvaluateTransaction("GetCategories")
+	if err != nil {
+		return nil, fmt.Errorf("GetCategories failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// AddCategory invokes AddCategory on the chaincode; parentID is empty for a
+// top-level category.
+func AddCategory(contract *client.Contract, id, name, parentID string) ([]byte, error) {
+	fmt.Printf("→ Invoking AddCategory (id=%s, name=%s, parent=%s)\n", id, name, parentID)
+	result, err := contract.SubmitTransaction("AddCategory", id, name, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("AddCategory failed: %w", err)
+	}
+	fmt.Printf("✓ Category %s added\n", id)
+	return prettyJSON(result), nil
+}
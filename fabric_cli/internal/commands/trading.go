@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,17 +11,63 @@ import (
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
-// Purchase invokes Purchase on the chaincode.
-func Purchase(contract *client.Contract, userID, productID, invoiceID string, quantity int) error {
-	fmt.Printf("→ Invoking Purchase (user=%s, product=%s, qty=%d, invoiceID=%s)\n",
-		userID, productID, quantity, invoiceID)
+// PurchaseOptions mirrors the chaincode's optional purchase settings.
+type PurchaseOptions struct {
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	PromoCode      string `json:"promoCode,omitempty"`
+	RedeemPoints   int    `json:"redeemPoints,omitempty"`
+}
+
+// NewIdempotencyKey returns a random key for one purchase attempt. Reuse it
+// when retrying the same purchase so the chaincode cannot charge twice.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Purchase invokes Purchase on the chaincode and returns the issued invoice.
+func Purchase(contract *client.Contract, userID, productID string, quantity int, opts PurchaseOptions) ([]byte, error) {
+	fmt.Printf("→ Invoking Purchase (user=%s, product=%s, qty=%d, key=%s)\n",
+		userID, productID, quantity, opts.IdempotencyKey)
+	optsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode options: %w", err)
+	}
 	qty := strconv.Itoa(quantity)
-	_, err := contract.SubmitTransaction("Purchase", userID, productID, invoiceID, qty)
+	result, err := contract.SubmitTransaction("Purchase", userID, productID, qty, string(optsJSON))
 	if err != nil {
-		return fmt.Errorf("Purchase failed: %w", err)
+		return nil, fmt.Errorf("Purchase failed: %w", err)
 	}
 	fmt.Println("✓ Purchase completed successfully")
-	return nil
+	return prettyJSON(result), nil
+}
+
+// CartLine is one product/quantity pair of a shopping cart.
+type CartLine struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
+
+// PurchaseCart invokes PurchaseCart and returns the issued invoices.
+func PurchaseCart(contract *client.Contract, userID string, lines []CartLine, opts PurchaseOptions) ([]byte, error) {
+	fmt.Printf("→ Invoking PurchaseCart (user=%s, items=%d, key=%s)\n", userID, len(lines), opts.IdempotencyKey)
+	linesJSON, err := json.Marshal(lines)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode cart: %w", err)
+	}
+	optsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode options: %w", err)
+	}
+	result, err := contract.SubmitTransaction("PurchaseCart", userID, string(linesJSON), string(optsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("PurchaseCart failed: %w", err)
+	}
+	fmt.Println("✓ Purchase completed successfully")
+	return prettyJSON(result), nil
 }
 
 // GetAllProducts queries all products via range query.
@@ -31,8 +80,19 @@ func GetAllProducts(contract *client.Contract) ([]byte, error) {
 	return prettyJSON(result), nil
 }
 
+// GetExpiredProducts queries a merchant's expired products that still have
+// stock.
+func GetExpiredProducts(contract *client.Contract, merchantID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetExpiredProducts (merchant=%s)\n", merchantID)
+	result, err := contract.EvaluateTransaction("GetExpiredProducts", merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExpiredProducts failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
 // RichQueryProducts sends a CouchDB selector-based rich query.
-// filterJSON example: {"name":"Mleko","priceMin":10,"priceMax":100}
+// filterJSON example: {"name":"Mleko","priceMin":"10","priceMax":"99.90"}
 func RichQueryProducts(contract *client.Contract, filterJSON string) ([]byte, error) {
 	fmt.Printf("→ Querying RichQueryProducts (filter=%s)\n", filterJSON)
 	// Validate JSON
@@ -47,10 +107,21 @@ func RichQueryProducts(contract *client.Contract, filterJSON string) ([]byte, er
 	return prettyJSON(result), nil
 }
 
-// InitLedger invokes InitLedger.
+// piiSaltTransientKey is the transient map key the chaincode reads the secret
+// from which it derives the salts of seeded users.
+const piiSaltTransientKey = "piiSalt"
+
+// InitLedger invokes InitLedger with a random salt secret. The salts derived
+// from it are stored with the private personal data, so the secret itself is
+// not kept.
 func InitLedger(contract *client.Contract) error {
-	fmt.Println("→ Invoking InitLedger")
-	_, err := contract.SubmitTransaction("InitLedger")
+	fmt.Println("→ Invoking InitLedger (salt secret sent as transient)")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("cannot generate salt secret: %w", err)
+	}
+	_, err := contract.Submit("InitLedger",
+		client.WithTransient(map[string][]byte{piiSaltTransientKey: []byte(hex.EncodeToString(secret))}))
 	if err != nil {
 		return fmt.Errorf("InitLedger failed: %w", err)
 	}
@@ -58,14 +129,16 @@ func InitLedger(contract *client.Contract) error {
 	return nil
 }
 
-// prettyJSON re-formats raw JSON bytes with indentation.
-// Falls back to raw bytes if parsing fails.
+// prettyJSON re-formats raw JSON bytes with indentation and shows money
+// amounts in major units. Falls back to raw bytes if parsing fails.
 func prettyJSON(raw []byte) []byte {
 	var v interface{}
-	if err := json.Unmarshal(raw, &v); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
 		return raw
 	}
-	pretty, err := json.MarshalIndent(v, "", "  ")
+	pretty, err := json.MarshalIndent(humanizeMoney(v), "", "  ")
 	if err != nil {
 		return raw
 	}
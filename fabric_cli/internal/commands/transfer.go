@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Transfer invokes Transfer on the chaincode. memo may be empty.
+func Transfer(contract *client.Contract, fromUserID, toUserID, amount, memo string) ([]byte, error) {
+	if err := ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+	fmt.Printf("→ Invoking Transfer (from=%s, to=%s, amount=%s)\n", fromUserID, toUserID, amount)
+	result, err := contract.SubmitTransaction("Transfer", fromUserID, toUserID, amount, memo)
+	if err != nil {
+		return nil, fmt.Errorf("Transfer failed: %w", err)
+	}
+	fmt.Printf("✓ Sent %s from %s to %s\n", amount, fromUserID, toUserID)
+	return prettyJSON(result), nil
+}
+
+// GetTransfer queries one transfer by ID.
+func GetTransfer(contract *client.Contract, id string) ([]byte, error) {
+	fmt.Printf("→ Querying GetTransfer (id=%s)\n", id)
+	result, err := contract.EvaluateTransaction("GetTransfer", id)
+	if err != nil {
+		return nil, fmt.Errorf("GetTransfer failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetTransfersByUser queries the transfers a user sent or received.
+func GetTransfersByUser(contract *client.Contract, userID string) ([]byte, error) {
+	fmt.Printf("→ Querying GetTransfersByUser (userId=%s)\n", userID)
+	result, err := contract.EvaluateTransaction("GetTransfersByUser", userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetTransfersByUser failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
@@ -1,15 +1,41 @@
 package commands
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 )
 
-// CreateUser invokes CreateUser on the chaincode.
+// userPIITransientKey is the transient map key the chaincode reads personal
+// data from.
+const userPIITransientKey = "user"
+
+type userPII struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+	Salt      string `json:"salt"`
+}
+
+// CreateUser invokes CreateUser on the chaincode. The personal data travels
+// in the transient map, together with a random salt for its public hash, so
+// it is never written to a block.
 func CreateUser(contract *client.Contract, id, firstName, lastName, email string) error {
-	fmt.Printf("→ Invoking CreateUser (id=%s, name=%s %s, email=%s)\n", id, firstName, lastName, email)
-	_, err := contract.SubmitTransaction("CreateUser", id, firstName, lastName, email)
+	fmt.Printf("→ Invoking CreateUser (id=%s, personal data sent as transient)\n", id)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("cannot generate salt: %w", err)
+	}
+	pii, err := json.Marshal(userPII{FirstName: firstName, LastName: lastName, Email: email, Salt: hex.EncodeToString(salt)})
+	if err != nil {
+		return fmt.Errorf("cannot encode personal data: %w", err)
+	}
+	_, err = contract.Submit("CreateUser",
+		client.WithArguments(id),
+		client.WithTransient(map[string][]byte{userPIITransientKey: pii}))
 	if err != nil {
 		return fmt.Errorf("CreateUser failed: %w", err)
 	}
@@ -18,14 +44,48 @@ func CreateUser(contract *client.Contract, id, firstName, lastName, email string
 }
 
 // Deposit invokes Deposit on the chaincode.
-// entityType: "user" | "merchant"
-func Deposit(contract *client.Contract, entityType, id string, amount float64) error {
-	fmt.Printf("→ Invoking Deposit (type=%s, id=%s, amount=%.2f)\n", entityType, id, amount)
-	amountStr := fmt.Sprintf("%.2f", amount)
-	_, err := contract.SubmitTransaction("Deposit", entityType, id, amountStr)
+// entityType: "user" | "merchant"; amount is a decimal string such as "150.00".
+func Deposit(contract *client.Contract, entityType, id, amount string) error {
+	if err := ValidateAmount(amount); err != nil {
+		return err
+	}
+	fmt.Printf("→ Invoking Deposit (type=%s, id=%s, amount=%s)\n", entityType, id, amount)
+	_, err := contract.SubmitTransaction("Deposit", entityType, id, amount)
 	if err != nil {
 		return fmt.Errorf("Deposit failed: %w", err)
 	}
-	fmt.Printf("✓ Deposited %.2f to %s %s\n", amount, entityType, id)
+	fmt.Printf("✓ Deposited %s to %s %s\n", amount, entityType, id)
+	return nil
+}
+
+// GetUserPII queries a user's personal data from the private data collection.
+func GetUserPII(contract *client.Contract, id string) ([]byte, error) {
+	fmt.Printf("→ Querying GetUserPII (id=%s)\n", id)
+	result, err := contract.EvaluateTransaction("GetUserPII", id)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserPII failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// WhoAmI queries the MSP ID and client ID of the current identity.
+func WhoAmI(contract *client.Contract) ([]byte, error) {
+	fmt.Println("→ Querying WhoAmI")
+	result, err := contract.EvaluateTransaction("WhoAmI")
+	if err != nil {
+		return nil, fmt.Errorf("WhoAmI failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// TransferOwnership rebinds a user or merchant record to another identity.
+// entityType: "user" | "merchant"
+func TransferOwnership(contract *client.Contract, entityType, id, newMSPID, newOwnerID string) error {
+	fmt.Printf("→ Invoking TransferOwnership (type=%s, id=%s, msp=%s)\n", entityType, id, newMSPID)
+	_, err := contract.SubmitTransaction("TransferOwnership", entityType, id, newMSPID, newOwnerID)
+	if err != nil {
+		return fmt.Errorf("TransferOwnership failed: %w", err)
+	}
+	fmt.Printf("✓ Ownership of %s %s transferred\n", entityType, id)
 	return nil
 }
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// SetVATRate invokes SetVATRate – scope is "product" or "merchantType".
+func SetVATRate(contract *client.Contract, scope, target string, percent int) error {
+	fmt.Printf("→ Invoking SetVATRate (scope=%s, target=%s, percent=%d)\n", scope, target, percent)
+	if _, err := contract.SubmitTransaction("SetVATRate", scope, target, strconv.Itoa(percent)); err != nil {
+		return fmt.Errorf("SetVATRate failed: %w", err)
+	}
+	fmt.Println("✓ VAT rate set")
+	return nil
+}
+
+// GetVATRates queries every VAT rate set on the ledger.
+func GetVATRates(contract *client.Contract) ([]byte, error) {
+	fmt.Println("→ Querying GetVATRates")
+	result, err := contract.EvaluateTransaction("GetVATRates")
+	if err != nil {
+		return nil, fmt.Errorf("GetVATRates failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
+
+// GetVATSummary queries the VAT a merchant collected between from and to.
+func GetVATSummary(contract *client.Contract, merchantID, from, to string) ([]byte, error) {
+	fmt.Printf("→ Querying GetVATSummary (merchant=%s, from=%s, to=%s)\n", merchantID, from, to)
+	result, err := contract.EvaluateTransaction("GetVATSummary", merchantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("GetVATSummary failed: %w", err)
+	}
+	return prettyJSON(result), nil
+}
@@ -9,8 +9,10 @@ import (
 )
 
 // RegisterAndEnrollUser registers and enrolls a new user via Fabric CA.
+// The role and entityID are issued as the trading.role and trading.id
+// certificate attributes the chaincode uses for access control.
 // The enrolled MSP credentials are saved to fabric_cli/wallet/<org>/<username>/msp/
-func RegisterAndEnrollUser(username, password, orgMSP string) (*ProfileInfo, error) {
+func RegisterAndEnrollUser(username, password, orgMSP, role, entityID string) (*ProfileInfo, error) {
 	// 1. Resolve paths
 	fabricSamplesDir, err := findFabricSamplesDir()
 	if err != nil {
@@ -54,7 +56,7 @@ func RegisterAndEnrollUser(username, password, orgMSP string) (*ProfileInfo, err
 	// 4. Register user with CA
 	fmt.Printf("→ Registering user '%s' with CA...\n", username)
 	alreadyRegistered := false
-	if err := registerUser(caFolder, tlsCertPath, caName, caPort, username, password); err != nil {
+	if err := registerUser(caFolder, tlsCertPath, caName, caPort, username, password, tradingAttrs(role, entityID)); err != nil {
 		errStr := err.Error()
 		// Check if user already registered on CA server
 		if strings.Contains(errStr, "already registered") || strings.Contains(errStr, "is already registered") {
@@ -122,9 +124,8 @@ func enrollCAAdmin(caFolder, tlsCertPath, caName, caPort string) error {
 	return nil
 }
 
-func registerUser(caFolder, tlsCertPath, caName, caPort, username, password string) error {
-	cmd := exec.Command(
-		"fabric-ca-client",
+func registerUser(caFolder, tlsCertPath, caName, caPort, username, password, attrs string) error {
+	args := []string{
 		"register",
 		"--id.name", username,
 		"--id.secret", password,
@@ -133,7 +134,11 @@ func registerUser(caFolder, tlsCertPath, caName, caPort, username, password stri
 		"--caname", caName,
 		"--tls.certfiles", tlsCertPath,
 		"-M", filepath.Join(caFolder, "admin-msp"), // Use admin MSP for registration
-	)
+	}
+	if attrs != "" {
+		args = append(args, "--id.attrs", attrs)
+	}
+	cmd := exec.Command("fabric-ca-client", args...)
 
 	cmd.Env = append(os.Environ(), "FABRIC_CA_CLIENT_HOME="+caFolder)
 
@@ -165,6 +170,19 @@ func enrollUser(caFolder, tlsCertPath, caName, caPort, username, password, walle
 	return nil
 }
 
+// tradingAttrs builds the --id.attrs value for the chaincode role attributes.
+// The ":ecert" suffix makes the CA embed them in the enrollment certificate.
+func tradingAttrs(role, entityID string) string {
+	if role == "" {
+		return ""
+	}
+	attrs := fmt.Sprintf("trading.role=%s:ecert", role)
+	if entityID != "" {
+		attrs += fmt.Sprintf(",trading.id=%s:ecert", entityID)
+	}
+	return attrs
+}
+
 func getWalletDir(orgMSP, username string) (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
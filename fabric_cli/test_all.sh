@@ -58,7 +58,7 @@ pass "InitLedger"
 # ─────────────────────────────────────────────────────────────────────────────
 section "2. Create Merchant  [Org1Admin]"
 # ─────────────────────────────────────────────────────────────────────────────
-output=$(cli_menu "$PROFILE" "2\nMERCHANT3\npharmacy\n111222333\n0")
+output=$(cli_menu "$PROFILE" "2\nMERCHANT3\npharmacy\n111222339\n0")
 echo "$output"
 echo "$output" | grep -q "Merchant created successfully" || fail "CreateMerchant"
 pass "CreateMerchant"
@@ -66,7 +66,7 @@ pass "CreateMerchant"
 # ─────────────────────────────────────────────────────────────────────────────
 section "3. Add Products to Merchant  [Org1Admin]"
 # ─────────────────────────────────────────────────────────────────────────────
-PRODUCTS_JSON='[{"id":"PROD5","name":"Aspirin","expiration":"2027-06-01T00:00:00Z","price":250,"quantity":100},{"id":"PROD6","name":"Paracetamol","expiration":"2027-01-01T00:00:00Z","price":180,"quantity":50}]'
+PRODUCTS_JSON='[{"id":"PROD5","name":"Aspirin","expiration":"2027-06-01T00:00:00Z","price":"250.00","quantity":100},{"id":"PROD6","name":"Paracetamol","expiration":"2027-01-01T00:00:00Z","price":"180.00","quantity":50}]'
 output=$(cli_menu "$PROFILE" "3\nMERCHANT3\n${PRODUCTS_JSON}\n0")
 echo "$output"
 echo "$output" | grep -q "Products added successfully" || fail "AddProducts"
@@ -99,7 +99,7 @@ pass "Deposit to Merchant"
 # ─────────────────────────────────────────────────────────────────────────────
 section "7. Purchase Product  [Org2Admin]"
 # ─────────────────────────────────────────────────────────────────────────────
-output=$(cli_menu "$PROFILE2" "6\nUSER3\nPROD5\nINV_TEST_001\n2\n0")
+output=$(cli_menu "$PROFILE2" "6\nUSER3\nPROD5\n2\n\n\n0")
 echo "$output"
 echo "$output" | grep -q "Purchase completed successfully" || fail "Purchase"
 pass "Purchase"
@@ -108,7 +108,7 @@ pass "Purchase"
 section "8. Purchase – Insufficient Funds (should fail gracefully)"
 # ─────────────────────────────────────────────────────────────────────────────
 # USER1 has only 500 deposited initially; we try to buy 200 * 150 = 30000
-output=$(cli_menu "$PROFILE" "6\nUSER1\nPROD3\nINV_FAIL\n200\n0")
+output=$(cli_menu "$PROFILE" "6\nUSER1\nPROD3\n200\n\n\nn\n0")
 echo "$output"
 echo "$output" | grep -qi "error\|insufficient\|failed" || fail "Purchase-insufficient-funds should have errored"
 pass "Purchase – insufficient funds returns an error message"
@@ -117,7 +117,7 @@ pass "Purchase – insufficient funds returns an error message"
 section "9. Purchase – Insufficient Stock (should fail gracefully)"
 # ─────────────────────────────────────────────────────────────────────────────
 # PROD2 (Hleb) has quantity 15; request 9999
-output=$(cli_menu "$PROFILE" "6\nUSER1\nPROD2\nINV_FAIL2\n9999\n0")
+output=$(cli_menu "$PROFILE" "6\nUSER1\nPROD2\n9999\n\n\nn\n0")
 echo "$output"
 echo "$output" | grep -qi "error\|insufficient\|failed" || fail "Purchase-insufficient-stock should have errored"
 pass "Purchase – insufficient stock returns an error message"
@@ -175,7 +175,7 @@ pass "Org2Admin can query independently"
 # ─────────────────────────────────────────────────────────────────────────────
 section "16. Error handling – entity not found"
 # ─────────────────────────────────────────────────────────────────────────────
-output=$(cli_menu "$PROFILE" "6\nNONEXISTENT_USER\nPROD1\nINV_NOUSER\n1\n0")
+output=$(cli_menu "$PROFILE" "6\nNONEXISTENT_USER\nPROD1\n1\n\n\nn\n0")
 echo "$output"
 echo "$output" | grep -qi "error\|not found\|failed" || fail "Purchase with nonexistent user should error"
 pass "Error – nonexistent user"
@@ -1,2 +1,5 @@
 #!/bin/bash
-peer chaincode invoke -C mychannel -n trading -c '{"function":"InitLedger","Args":[]}' --waitForEvent
+# Soli seed korisnika izvode se iz nasumične tajne poslate kroz transient mapu
+PII_SALT=$(head -c 32 /dev/urandom | od -An -tx1 | tr -d ' \n' | base64 | tr -d '\n')
+peer chaincode invoke -C mychannel -n trading -c '{"function":"InitLedger","Args":[]}' \
+  --transient "{\"piiSalt\":\"$PII_SALT\"}" --waitForEvent
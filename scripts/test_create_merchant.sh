@@ -39,7 +39,7 @@ export CORE_PEER_ADDRESS="localhost:7051"
 # ─────────────────────────────────────────────────────────────
 MERCHANT_ID="${MERCHANT_ID:-m1}"
 MERCHANT_TYPE="${MERCHANT_TYPE:-retail}"
-MERCHANT_PIB="${MERCHANT_PIB:-123456789}"
+MERCHANT_PIB="${MERCHANT_PIB:-123456788}"
 
 echo "══════════════════════════════════════════"
 echo "  TEST CreateMerchant invoke"
@@ -41,8 +41,8 @@ MERCHANT_ID="${MERCHANT_ID:-MERCHANT1}"
 
 # Raw JSON niz proizvoda
 RAW_PRODUCTS_JSON='[
-  {"ID":"PROD_NEW1","Name":"Test Proizvod 1","Expiration":"2027-12-31T23:59:59Z","Price":100,"Quantity":5},
-  {"ID":"PROD_NEW2","Name":"Test Proizvod 2","Expiration":"2027-11-30T23:59:59Z","Price":200,"Quantity":3}
+  {"ID":"PROD_NEW1","Name":"Test Proizvod 1","Expiration":"2027-12-31T23:59:59Z","Price":"100.00","Quantity":5},
+  {"ID":"PROD_NEW2","Name":"Test Proizvod 2","Expiration":"2027-11-30T23:59:59Z","Price":"200.00","Quantity":3}
 ]'
 
 # Escape u jedan string za CLI
@@ -82,7 +82,7 @@ MERCHANT_ID="${MERCHANT_ID:-MERCHANT1}"
 
 # Raw JSON niz proizvoda
 RAW_PRODUCTS_JSON='[
-  {"ID":"PROD_NEW1","Name":"Test Proizvod 3","Expiration":"2027-12-31T23:59:59Z","Price":150,"Quantity":8}
+  {"ID":"PROD_NEW1","Name":"Test Proizvod 3","Expiration":"2027-12-31T23:59:59Z","Price":"150.00","Quantity":8}
 ]'
 
 # Escape u jedan string za CLI
@@ -41,6 +41,7 @@ USER_ID="${USER_ID:-u1}"
 USER_FIRSTNAME="${USER_FIRSTNAME:-Davor}"
 USER_LASTNAME="${USER_LASTNAME:-Homa}"
 USER_EMAIL="${USER_EMAIL:-davor.homa@example.com}"
+USER_SALT="${USER_SALT:-$(openssl rand -hex 16)}"
 
 echo "══════════════════════════════════════════"
 echo "  TEST CreateUser invoke"
@@ -51,6 +52,10 @@ echo "LASTNAME:  $USER_LASTNAME"
 echo "EMAIL:  $USER_EMAIL"
 echo
 
+# Lični podaci se šalju kroz transient mapu (ključ "user"), pa ne završavaju u bloku
+USER_PII=$(printf '{"firstName":"%s","lastName":"%s","email":"%s","salt":"%s"}' \
+  "$USER_FIRSTNAME" "$USER_LASTNAME" "$USER_EMAIL" "$USER_SALT" | base64 | tr -d '\n')
+
 peer chaincode invoke \
   -o localhost:7050 \
   --ordererTLSHostnameOverride orderer.example.com \
@@ -62,7 +67,8 @@ peer chaincode invoke \
   --tlsRootCertFiles "$ORG1_PEER_TLS_ROOTCERT_FILE" \
   --peerAddresses "$ORG2_PEER_ADDRESS" \
   --tlsRootCertFiles "$ORG2_PEER_TLS_ROOTCERT_FILE" \
-  -c "{\"function\":\"CreateUser\",\"Args\":[\"$USER_ID\",\"$USER_FIRSTNAME\",\"$USER_LASTNAME\",\"$USER_EMAIL\"]}" \
+  --transient "{\"user\":\"$USER_PII\"}" \
+  -c "{\"function\":\"CreateUser\",\"Args\":[\"$USER_ID\"]}" \
   --waitForEvent
 
 # echo
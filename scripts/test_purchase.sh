@@ -39,18 +39,16 @@ export CORE_PEER_ADDRESS="localhost:7051"
 # ─────────────────────────────────────────────────────────────
 USER="USER1"
 PRODUCT="${PRODUCT:-PROD1}"
-MERCHANT_ID="${MERCHANT_ID:-MERCHANT1}"
 QUANTITY="${QUANTITY:-2}"
-INVOICE="${INVOICE:-inv1}"
+IDEMPOTENCY_KEY="${IDEMPOTENCY_KEY:-$(date +%s%N)}"
 
 echo "══════════════════════════════════════════"
 echo "  TEST Purchase invoke"
 echo "══════════════════════════════════════════"
 echo "USER:   $USER"
 echo "PRODUCT: $PRODUCT"
-echo "MERCHANT_ID: $MERCHANT_ID"
 echo "QUANTITY: $QUANTITY"
-echo "INVOICE: $INVOICE"
+echo "IDEMPOTENCY_KEY: $IDEMPOTENCY_KEY"
 echo
 
 peer chaincode invoke \
@@ -64,5 +62,5 @@ peer chaincode invoke \
   --tlsRootCertFiles "$ORG1_PEER_TLS_ROOTCERT_FILE" \
   --peerAddresses "$ORG2_PEER_ADDRESS" \
   --tlsRootCertFiles "$ORG2_PEER_TLS_ROOTCERT_FILE" \
-  -c "{\"function\":\"Purchase\",\"Args\":[\"$USER\",\"$PRODUCT\",\"$MERCHANT_ID\",\"$QUANTITY\",\"$INVOICE\"]}" \
+  -c "{\"function\":\"Purchase\",\"Args\":[\"$USER\",\"$PRODUCT\",\"$QUANTITY\",\"{\\\"idempotencyKey\\\":\\\"$IDEMPOTENCY_KEY\\\"}\"]}" \
   --waitForEvent
\ No newline at end of file
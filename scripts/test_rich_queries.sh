@@ -80,10 +80,13 @@ info() { echo -e "${CYAN}  ℹ  $1${NC}"; }
 FAILED=0
 
 # ── Helper: invoke ─────────────────────────────────────────────────────────────
+# Ako je postavljena promenljiva TRANSIENT, šalje se kao --transient mapa.
 invoke() {
     local fn="$1"; shift
     local args_json
     args_json=$(printf '%s\n' "$@" | jq -R . | jq -s .)
+    local transient=()
+    [[ -n "${TRANSIENT:-}" ]] && transient=(--transient "${TRANSIENT}")
 
     local output
     if output=$(peer chaincode invoke \
@@ -95,6 +98,7 @@ invoke() {
         --tlsRootCertFiles "${ORG1_TLS_CERT}" \
         --peerAddresses localhost:9051 \
         --tlsRootCertFiles "${ORG2_TLS_CERT}" \
+        ${transient[@]+"${transient[@]}"} \
         -c "{\"function\":\"${fn}\",\"Args\":${args_json}}" 2>&1); then
         echo "$output"
     else
@@ -143,7 +147,9 @@ info "CHAINCODE       = ${CHAINCODE}"
 # =============================================================================
 section "PRIPREMA – Init Ledger i test podaci"
 info "Pokretanje InitLedger..."
-if invoke "InitLedger"; then
+# Soli seed korisnika izvode se iz nasumične tajne poslate kroz transient mapu
+PII_SALT=$(head -c 32 /dev/urandom | od -An -tx1 | tr -d ' \n' | base64 | tr -d '\n')
+if TRANSIENT="{\"piiSalt\":\"${PII_SALT}\"}" invoke "InitLedger"; then
     sleep 3
     pass "InitLedger završen"
 else
@@ -151,7 +157,9 @@ else
 fi
 
 info "Kreiranje korisnika USER10..."
-invoke "CreateUser" USER10 Bogdan Bogdanovic bogdan@example.com && sleep 2 || fail "CreateUser USER10"
+# Lični podaci idu kroz transient mapu i ne upisuju se u blok
+USER10_PII=$(jq -nc '{firstName:"Bogdan",lastName:"Bogdanovic",email:"bogdan@example.com",salt:"f3a9c1d27b4e8a60"}' | base64 | tr -d '\n')
+TRANSIENT="{\"user\":\"${USER10_PII}\"}" invoke "CreateUser" USER10 && sleep 2 || fail "CreateUser USER10"
 
 info "Uplata 9999 na USER10..."
 invoke "Deposit" user USER10 9999 && sleep 2 || fail "Deposit USER10"
@@ -159,9 +167,10 @@ invoke "Deposit" user USER10 9999 && sleep 2 || fail "Deposit USER10"
 pass "Korisnik USER10 kreiran sa stanjem 9999"
 
 info "Kreiranje test faktura..."
-invoke "Purchase" USER10 PROD1 INV_TEST_01 3 && sleep 2 || fail "Purchase INV_TEST_01"
-invoke "Purchase" USER10 PROD3 INV_TEST_02 2 && sleep 2 || fail "Purchase INV_TEST_02"
-invoke "Purchase" USER1 PROD2 INV_TEST_03 1 && sleep 2 || fail "Purchase INV_TEST_03"
+# ID fakture generiše chaincode iz ID-ja transakcije
+invoke "Purchase" USER10 PROD1 3 '{}' && sleep 2 || fail "Purchase USER10 PROD1"
+invoke "Purchase" USER10 PROD3 2 '{}' && sleep 2 || fail "Purchase USER10 PROD3"
+invoke "Purchase" USER1 PROD2 1 '{}' && sleep 2 || fail "Purchase USER1 PROD2"
 
 pass "Test fakture kreirane"
 
@@ -204,7 +213,7 @@ echo "════════════════════════
 QUERY_RESULT=$(peer chaincode query \
   -C "$CHANNEL" \
   -n "$CHAINCODE" \
-  -c "{\"function\":\"GetProductsExpiringSoon\",\"Args\":[\"2026-10-20T00:00:00Z\"]}" 2>/dev/null || true)
+  -c "{\"function\":\"GetProductsExpiringSoon\",\"Args\":[\"$(date -u -d '+21 days' +%Y-%m-%dT%H:%M:%SZ)\"]}" 2>/dev/null || true)
 
 if [[ -z "$QUERY_RESULT" ]]; then
   echo "❌ Query nije vratio podatke."
@@ -262,11 +271,13 @@ fi
 
 # =============================================================================
 section "RICH QUERY 1 – GetProductsExpiringSoon"
-info "expiresBefore = '2026-11-01T00:00:00Z'"
-RESULT=$(query "GetProductsExpiringSoon" 2026-11-01T00:00:00Z) || { fail "RQ1 query greška"; RESULT=""; }
+# InitLedger postavlja rokove relativno na vreme transakcije: PROD3 +10 dana, PROD4 +14 dana
+SOON=$(date -u -d '+21 days' +%Y-%m-%dT%H:%M:%SZ)
+info "expiresBefore = '${SOON}'"
+RESULT=$(query "GetProductsExpiringSoon" "$SOON") || { fail "RQ1 query greška"; RESULT=""; }
 echo ""; pretty "$RESULT"; echo ""
 if echo "$RESULT" | grep -q "PROD3\|Kocnica\|PROD4\|Filter ulja"; then
-    pass "RQ1 – Pronađeni proizvodi sa rokom pre 2026-11-01"
+    pass "RQ1 – Pronađeni proizvodi sa rokom pre ${SOON}"
 else
     fail "RQ1 – Nisu pronađeni PROD3/PROD4"
 fi
@@ -305,7 +316,7 @@ EMPTY=$(echo "$RESULT3" | python3 -c "import sys,json; d=json.load(sys.stdin); p
 # (Dalje ostaje identično kao tvoja originalna skripta – RQ3, RQ4, RQ5 i sažetak)
 # Samo se argumenti više ne stavljaju u ručne navodnike, npr:
 # query "GetInvoicesByUserAndDateRange" USER10 2026-02-17T00:00:00Z 2026-02-18T23:59:59Z
-# invoke "Purchase" USER10 PROD1 INV_TEST_01 3
+# invoke "Purchase" USER10 PROD1 3 '{}'
 # =============================================================================
 
 
@@ -317,12 +328,12 @@ TODAY=$(date -u +"%Y-%m-%dT00:00:00Z")
 TOMORROW=$(date -u -d "+1 day" +"%Y-%m-%dT23:59:59Z" 2>/dev/null \
         || date -u -v+1d      +"%Y-%m-%dT23:59:59Z")
 
-info "USER10, od $TODAY do $TOMORROW  →  INV_TEST_01 i INV_TEST_02"
+info "USER10, od $TODAY do $TOMORROW  →  fakture za PROD1 i PROD3"
 RESULT=$(query "GetInvoicesByUserAndDateRange" "USER10" "${TODAY}" "${TOMORROW}") \
     || { fail "RQ3 query greška"; RESULT=""; }
 echo ""; pretty "$RESULT"; echo ""
 
-if echo "$RESULT" | grep -q "INV_TEST_01\|INV_TEST_02"; then
+if echo "$RESULT" | grep -q "PROD1\|PROD3"; then
     pass "RQ3 – Fakture USER10 za danas pronađene"
 else
     fail "RQ3 – Fakture USER10 nisu pronađene"
@@ -382,13 +393,13 @@ else
     fail "RQ5 – Nema faktura za MERCHANT1"
 fi
 
-info "MERCHANT2, minPrice=200  →  INV_TEST_02 (2×150=300)"
+info "MERCHANT2, minPrice=200  →  faktura za PROD3 (2×150=300)"
 RESULT2=$(query "GetMerchantHighValueInvoices" 'MERCHANT2' "200") \
     || { fail "RQ5b query greška"; RESULT2=""; }
-if echo "$RESULT2" | grep -q "INV_TEST_02\|MERCHANT2"; then
-    pass "RQ5b – INV_TEST_02 (vrednost 300) pronađena"
+if echo "$RESULT2" | grep -q "PROD3\|MERCHANT2"; then
+    pass "RQ5b – Faktura za PROD3 (vrednost 300) pronađena"
 else
-    fail "RQ5b – INV_TEST_02 nije pronađena za minPrice=200"
+    fail "RQ5b – Faktura za PROD3 nije pronađena za minPrice=200"
 fi
 
 info "MERCHANT1, minPrice=999999  →  prazan"
@@ -398,6 +409,20 @@ EMPTY=$(echo "$RESULT3" | python3 -c "import sys,json; d=json.load(sys.stdin); p
 [ "$EMPTY" = "empty" ] && pass "RQ5c – Prazan za minPrice=999999 (ispravno)" \
                         || fail "RQ5c – Trebao biti prazan"
 
+# =============================================================================
+section "RICH QUERY 6 – RichQueryProducts po kategoriji"
+# =============================================================================
+
+info "category=food  →  PROD1 (dairy) i PROD2 (bakery), bez auto delova"
+RESULT=$(query "RichQueryProducts" '{"category":"food"}') \
+    || { fail "RQ6 query greška"; RESULT=""; }
+echo ""; pretty "$RESULT"; echo ""
+if echo "$RESULT" | grep -q "PROD1" && echo "$RESULT" | grep -q "PROD2" && ! echo "$RESULT" | grep -q "PROD3"; then
+    pass "RQ6 – Podstablo kategorije food pronađeno"
+else
+    fail "RQ6 – Očekivani su PROD1 i PROD2 bez PROD3"
+fi
+
 # =============================================================================
 section "SAŽETAK"
 # =============================================================================